@@ -0,0 +1,142 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"errors"
+)
+
+// Can be returned by the accept function of GetNWithError to stop the
+// search early. The candidate it was returned for is not selected.
+var ErrStopSearch = errors.New("Stop searching")
+
+// Gets the N closest items in the hash to the provided key, like GetN,
+// but with an accept function that can abort the search. Unlike GetN,
+// the accept function is also consulted for the very first candidate, so
+// it can reject it or stop the search before anything is selected. A nil
+// accept function accepts every candidate.
+//
+// If accept returns ErrStopSearch, the items selected so far are returned
+// without error. If it returns any other error, the search is aborted and
+// that error is returned along with the items selected so far.
+//
+// The accept function must not call back into the hash, see GetN.
+func (m *Map) GetNWithError(key string, n int, accept func([]string, string) (bool, error)) ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if accept == nil {
+		accept = func([]string, string) (bool, error) { return true, nil }
+	}
+
+	var err error
+	stopped := false
+	check := placementCheck{m: m, first: true, accepts: func(picked []string, found string) bool {
+		if stopped {
+			return false
+		}
+		var ok bool
+		if ok, err = accept(picked, found); err != nil {
+			stopped = true
+			return false
+		}
+		return ok
+	}}
+	out := m.placeChecked(PlacementView{m: m, stop: &stopped}, []string{}, m.hashKey(key), n, check)
+
+	if errors.Is(err, ErrStopSearch) {
+		err = nil
+	}
+	return out, err
+}
+
+// An accept function as used by GetN. It runs with the hash locked for
+// reading and must not call back into it.
+type AcceptFunc func(stack []string, found string) bool
+
+// Accepts an item only if all provided accept functions accept it.
+func And(fns ...AcceptFunc) AcceptFunc {
+	return func(stack []string, found string) bool {
+		for _, fn := range fns {
+			if !fn(stack, found) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// Accepts an item if any of the provided accept functions accepts it.
+func Or(fns ...AcceptFunc) AcceptFunc {
+	return func(stack []string, found string) bool {
+		for _, fn := range fns {
+			if fn(stack, found) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Accepts an item only if the provided accept function rejects it.
+func Not(fn AcceptFunc) AcceptFunc {
+	return func(stack []string, found string) bool {
+		return !fn(stack, found)
+	}
+}
+
+// Accepts an item as long as it was selected fewer than k times, see
+// AcceptAtMost.
+func MaxPerNode(k int) AcceptFunc {
+	return AcceptAtMost(k)
+}
+
+// Accepts an item as long as fewer than k selected items share its
+// label value, as returned by labelOf. Items with an empty label value
+// are always accepted.
+func MaxPerLabel(labelOf func(node string) string, k int) AcceptFunc {
+	return func(stack []string, found string) bool {
+		label := labelOf(found)
+		if label == "" {
+			return true
+		}
+		count := 0
+		for _, v := range stack {
+			if labelOf(v) == label {
+				count++
+			}
+		}
+		return count < k
+	}
+}
+
+// Returns a function that returns the value of the provided label for
+// every item, based on the labels at the time of calling. It is meant to
+// be used with MaxPerLabel; accept functions must not call back into the
+// hash during a lookup.
+func (m *Map) LabelOf(label string) func(node string) string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	values := make(map[string]string, len(m.entries))
+	for key, e := range m.entries {
+		values[key] = e.labels[label]
+	}
+	return func(node string) string {
+		return values[node]
+	}
+}
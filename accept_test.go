@@ -0,0 +1,106 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestGetNWithError(t *testing.T) {
+	hash := New(1, intHash)
+	hash.AddString("10", "20", "30", "40")
+
+	checked := 0
+	res, err := hash.GetNWithError("5", 3, func(stack []string, found string) (bool, error) {
+		checked++
+		if found == "30" {
+			return false, ErrStopSearch
+		}
+		return found != "10", nil
+	})
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+	}
+	if !reflect.DeepEqual(res, []string{"20"}) || checked != 3 {
+		t.Errorf("Expected search to stop at 30, but got %v after %d checks", res, checked)
+	}
+
+	failure := errors.New("capacity check failed")
+	res, err = hash.GetNWithError("5", 3, func(stack []string, found string) (bool, error) {
+		if found == "20" {
+			return false, failure
+		}
+		return true, nil
+	})
+	if err != failure || !reflect.DeepEqual(res, []string{"10"}) {
+		t.Errorf("Expected search to be aborted at 20, but got %v and error: %v", res, err)
+	}
+	res, err = hash.GetNWithError("5", 3, nil)
+	if err != nil || !reflect.DeepEqual(res, hash.GetN("5", 3, nil)) {
+		t.Errorf("Expected a nil accept function to behave like GetN, but got %v and error: %v", res, err)
+	}
+}
+
+func TestAcceptCombinators(t *testing.T) {
+	hash := New(2, intHash)
+	hash.AddWithWeight(&Node{ID: "10", Tags: map[string]string{"zone": "a"}}, 2)
+	hash.AddWithWeight(&Node{ID: "20", Tags: map[string]string{"zone": "a"}}, 2)
+	hash.AddWithWeight(&Node{ID: "30", Tags: map[string]string{"zone": "b"}}, 2)
+	hash.AddWithWeight(&Node{ID: "40"}, 2)
+
+	onePerZone := And(AcceptUnique, MaxPerLabel(hash.LabelOf("zone"), 1))
+	if res := hash.GetN("5", 4, onePerZone); !reflect.DeepEqual(res, []string{"10", "30", "40"}) {
+		t.Errorf("Expected one item per zone, but got: %v", res)
+	}
+
+	if res := hash.GetN("5", 4, MaxPerNode(2)); !reflect.DeepEqual(res, []string{"10", "20", "30", "40"}) {
+		t.Errorf("Expected items in ring order, but got: %v", res)
+	}
+
+	not20 := func(_ []string, found string) bool { return found != "20" }
+	is30 := func(_ []string, found string) bool { return found == "30" }
+	if res := hash.GetN("5", 3, And(AcceptUnique, Not(Or(Not(not20), is30)))); !reflect.DeepEqual(res, []string{"10", "40"}) {
+		t.Errorf("Expected 20 and 30 to be rejected, but got: %v", res)
+	}
+}
+
+func TestAcceptConcurrentWriter(t *testing.T) {
+	hash := New(10, intHash)
+	for _, id := range []string{"10", "20", "30", "40"} {
+		hash.AddWithWeight(&Node{ID: id, Tags: map[string]string{"zone": id}}, 1)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 200; i++ {
+			hash.AddString("50")
+			hash.Del("50")
+		}
+	}()
+
+	for i := 0; i < 200; i++ {
+		onePerZone := And(AcceptUnique, MaxPerLabel(hash.LabelOf("zone"), 1))
+		if res := hash.GetN(strconv.Itoa(i), 3, onePerZone); len(res) != 3 {
+			t.Fatalf("Expected 3 items, but got: %v", res)
+		}
+	}
+	<-done
+}
@@ -0,0 +1,139 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Wrapped by the errors AdminService returns for invalid requests, so a
+// server can map them to an invalid-argument status.
+var ErrInvalidArgument = errors.New("Invalid argument")
+
+// Implements the Admin service defined in proto/admin.proto on top of a
+// hash. It is independent of any RPC framework: the package has no
+// dependencies, so it ships neither generated stubs nor a gRPC server.
+// A server generated from the definition only needs to forward every
+// call to the method of the same name, see the README.
+type AdminService struct {
+	m *Map
+}
+
+// Returns an admin service that manages m.
+func NewAdminService(m *Map) *AdminService {
+	return &AdminService{m: m}
+}
+
+// Adds a node with the given weight and labels, and returns the epoch of
+// the hash after the node was added.
+func (s *AdminService) AddNode(ctx context.Context, id string, weight int, labels map[string]string) (uint64, error) {
+	if id == "" {
+		return 0, fmt.Errorf("%w: node id must not be empty", ErrInvalidArgument)
+	}
+	if weight < 1 {
+		return 0, fmt.Errorf("%w: weight %d, must be at least 1", ErrInvalidArgument, weight)
+	}
+	return s.apply(ctx, AddChange(&Node{ID: id, Tags: copyLabels(labels)}, weight))
+}
+
+// Removes a node and returns the epoch of the hash after it was removed.
+func (s *AdminService) RemoveNode(ctx context.Context, id string) (uint64, error) {
+	return s.apply(ctx, RemoveChange(id))
+}
+
+// Changes the weight of a node and returns the epoch of the hash after
+// the change.
+func (s *AdminService) SetWeight(ctx context.Context, id string, weight int) (uint64, error) {
+	if weight < 1 {
+		return 0, fmt.Errorf("%w: weight %d, must be at least 1", ErrInvalidArgument, weight)
+	}
+	return s.apply(ctx, WeightChange(id, weight))
+}
+
+func (s *AdminService) apply(ctx context.Context, c Change) (uint64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	return s.m.applyEpoch([]Change{c})
+}
+
+// Returns the owners of key. A replicas count of zero returns a single
+// owner.
+func (s *AdminService) GetOwner(ctx context.Context, key string, replicas int) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if replicas <= 0 {
+		replicas = 1
+	}
+	owners := s.m.GetN(key, replicas, AcceptUnique)
+	if len(owners) == 0 {
+		return nil, ErrEmptyRing
+	}
+	return owners, nil
+}
+
+// Returns the current topology of the hash.
+func (s *AdminService) GetTopology(ctx context.Context) (*Snapshot, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return s.m.Snapshot(), nil
+}
+
+// Calls send for every modification of the hash until ctx is done or
+// send returns an error, mirroring a server-side stream. Events are
+// queued, so a slow receiver never blocks modifications of the hash.
+func (s *AdminService) WatchChanges(ctx context.Context, send func(ChangeEvent) error) error {
+	var (
+		mu     sync.Mutex
+		queue  []ChangeEvent
+		notify = make(chan struct{}, 1)
+	)
+	cancel := s.m.OnChange(func(event ChangeEvent) {
+		mu.Lock()
+		queue = append(queue, event)
+		mu.Unlock()
+		select {
+		case notify <- struct{}{}:
+		default:
+		}
+	})
+	defer cancel()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-notify:
+		}
+
+		mu.Lock()
+		events := queue
+		queue = nil
+		mu.Unlock()
+
+		for _, event := range events {
+			if err := send(event); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,123 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestAdminService(t *testing.T) {
+	ctx := context.Background()
+	admin := NewAdminService(New(3, intHash))
+
+	if _, err := admin.GetOwner(ctx, "5", 0); err != ErrEmptyRing {
+		t.Errorf("Expected ErrEmptyRing, but got: %v", err)
+	}
+
+	epoch, err := admin.AddNode(ctx, "10", 3, map[string]string{"zone": "a"})
+	if err != nil || epoch != 1 {
+		t.Fatalf("Expected epoch 1 without error, but got: %d, %v", epoch, err)
+	}
+	admin.AddNode(ctx, "20", 1, nil)
+	if epoch, err := admin.SetWeight(ctx, "20", 2); err != nil || epoch != 3 {
+		t.Errorf("Expected epoch 3 without error, but got: %d, %v", epoch, err)
+	}
+	if _, err := admin.SetWeight(ctx, "30", 2); err == nil {
+		t.Errorf("Expected error when changing weight of an unknown node")
+	}
+	for _, weight := range []int{-3, 0} {
+		if _, err := admin.AddNode(ctx, "30", weight, nil); !errors.Is(err, ErrInvalidArgument) {
+			t.Errorf("Expected ErrInvalidArgument when adding a node with weight %d, but got: %v", weight, err)
+		}
+	}
+	if _, err := admin.AddNode(ctx, "", 1, nil); !errors.Is(err, ErrInvalidArgument) {
+		t.Errorf("Expected ErrInvalidArgument when adding a node without id, but got: %v", err)
+	}
+	if _, err := admin.SetWeight(ctx, "20", 0); !errors.Is(err, ErrInvalidArgument) {
+		t.Errorf("Expected ErrInvalidArgument when changing the weight to zero, but got: %v", err)
+	}
+
+	owners, err := admin.GetOwner(ctx, "15", 2)
+	if err != nil || !reflect.DeepEqual(owners, []string{"20", "10"}) {
+		t.Errorf("Expected owners [20 10], but got: %v, %v", owners, err)
+	}
+
+	topology, err := admin.GetTopology(ctx)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if len(topology.Nodes) != 2 || topology.Nodes[0].Weight != 3 || topology.Nodes[0].Labels["zone"] != "a" {
+		t.Errorf("Unexpected topology: %+v", topology)
+	}
+
+	if epoch, err := admin.RemoveNode(ctx, "10"); err != nil || epoch != 4 {
+		t.Errorf("Expected epoch 4 without error, but got: %d, %v", epoch, err)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := admin.AddNode(cancelled, "40", 1, nil); err != context.Canceled {
+		t.Errorf("Expected context.Canceled, but got: %v", err)
+	}
+}
+
+func TestAdminServiceWatchChanges(t *testing.T) {
+	hash := New(3, intHash)
+	admin := NewAdminService(hash)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stop := errors.New("stop")
+	events := make(chan ChangeEvent, 10)
+	done := make(chan error)
+	go func() {
+		done <- admin.WatchChanges(ctx, func(event ChangeEvent) error {
+			events <- event
+			if event.Epoch == 2 {
+				return stop
+			}
+			return nil
+		})
+	}()
+
+	// Wait for the watcher to be registered.
+	for hash.listeners.count() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	hash.AddString("10")
+	hash.AddString("20")
+
+	if err := <-done; err != stop {
+		t.Errorf("Expected error from send to be returned, but got: %v", err)
+	}
+	for _, expected := range []string{"10", "20"} {
+		if event := <-events; event.Changes[0].Key != expected {
+			t.Errorf("Expected change of %s, but got: %+v", expected, event)
+		}
+	}
+}
+
+func (l *listeners) count() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.list)
+}
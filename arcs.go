@@ -0,0 +1,117 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"math"
+	"sort"
+)
+
+// A contiguous range of the ring that is owned by a single virtual node.
+type Arc struct {
+	Owner string
+	Start uint32
+	Size  uint64 // The number of positions, up to the size of the ring.
+}
+
+// Returns the fraction of the ring covered by the arc, between 0 and 1.
+func (a Arc) Share() float64 {
+	return float64(a.Size) / ringSize
+}
+
+// The distribution of arc sizes over the ring.
+type ArcAnalysis struct {
+	Arcs int
+	// The largest arcs, the largest first.
+	Largest []Arc
+	// The sizes of all arcs, the smallest first.
+	sizes []uint64
+}
+
+// Returns the arc size below which the provided percentage (between 0
+// and 100) of arcs falls, using the nearest rank.
+func (a ArcAnalysis) Percentile(p float64) uint64 {
+	if len(a.sizes) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(a.sizes))))
+	rank = min(max(rank, 1), len(a.sizes))
+	return a.sizes[rank-1]
+}
+
+// Returns the distribution of arc sizes over the ring, along with the
+// top largest arcs and their owners. A few oversized arcs are the most
+// common reason for an item to receive more than its share of keys.
+func (m *Map) AnalyzeArcs(top int) ArcAnalysis {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var arcs []Arc
+	m.arcs(func(owner string, start uint32, size uint64) {
+		arcs = append(arcs, Arc{Owner: owner, Start: start, Size: size})
+	})
+
+	sort.SliceStable(arcs, func(i, j int) bool {
+		return arcs[i].Size > arcs[j].Size
+	})
+	a := ArcAnalysis{Arcs: len(arcs), sizes: make([]uint64, len(arcs))}
+	for i, arc := range arcs {
+		a.sizes[len(arcs)-1-i] = arc.Size
+	}
+	if top > len(arcs) {
+		top = len(arcs)
+	}
+	if top > 0 {
+		a.Largest = arcs[:top:top]
+	}
+	return a
+}
+
+// Visits every contiguous range of the ring that is owned by a single
+// virtual node, in ring order. The caller must hold at least a read lock.
+func (m *Map) arcs(visit func(owner string, start uint32, size uint64)) {
+	positions := make([]int, 0, len(m.keys))
+	for i, k := range m.keys {
+		if i == 0 || m.keys[i-1] != k {
+			positions = append(positions, k)
+		}
+	}
+	if len(positions) == 0 {
+		return
+	}
+
+	for i, k := range positions {
+		owner := m.names[m.hashMap[k]]
+		if m.direction == Counterclockwise {
+			next := positions[(i+1)%len(positions)]
+			visit(owner, uint32(k), arcSize(k, next))
+			continue
+		}
+		prev := positions[(i+len(positions)-1)%len(positions)]
+		visit(owner, uint32(prev+1), arcSize(prev, k))
+	}
+}
+
+// Returns the number of positions from a up to b, clockwise. Returns the
+// size of the ring if they are equal.
+func arcSize(a, b int) uint64 {
+	size := (uint64(b) - uint64(a)) & (1<<32 - 1)
+	if size == 0 {
+		return 1 << 32
+	}
+	return size
+}
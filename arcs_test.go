@@ -0,0 +1,52 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAnalyzeArcs(t *testing.T) {
+	hash := New(1, intHash)
+	hash.AddWithTokens(&Node{ID: "a"}, []uint32{100, 200})
+	hash.AddWithTokens(&Node{ID: "b"}, []uint32{300, 1 << 31})
+	hash.AddWithTokens(&Node{ID: "c"}, []uint32{1<<32 - 1})
+
+	a := hash.AnalyzeArcs(2)
+	expected := []Arc{
+		{Owner: "c", Start: 1<<31 + 1, Size: 1<<31 - 1},
+		{Owner: "b", Start: 301, Size: 1<<31 - 300},
+	}
+	if a.Arcs != 5 || !reflect.DeepEqual(a.Largest, expected) {
+		t.Errorf("Expected 5 arcs with largest %+v, but got: %d, %+v", expected, a.Arcs, a.Largest)
+	}
+
+	testCases := map[float64]uint64{0: 100, 20: 100, 50: 101, 80: 1<<31 - 300, 100: 1<<31 - 1}
+	for p, expected := range testCases {
+		if size := a.Percentile(p); size != expected {
+			t.Errorf("Expected p%v to be %d, but got: %d", p, expected, size)
+		}
+	}
+
+	if a := hash.AnalyzeArcs(10); len(a.Largest) != 5 {
+		t.Errorf("Expected all arcs, but got: %+v", a.Largest)
+	}
+	if a := New(1, nil).AnalyzeArcs(3); a.Arcs != 0 || a.Largest != nil || a.Percentile(50) != 0 {
+		t.Errorf("Expected no arcs for empty hash, but got: %+v", a)
+	}
+}
@@ -0,0 +1,58 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"math"
+	"math/rand"
+	"strconv"
+)
+
+// Hashes a pseudo-random sample of keys and returns the share of the
+// busiest item divided by its expected share, where the expected share
+// of an item is proportional to its weight. A perfectly balanced ring
+// scores 1; a score of 1.5 means some item receives 50% more keys than
+// its weight warrants. The sample is the same for every call, so scores
+// are reproducible and can be used to gate on ring quality.
+func (m *Map) BalanceScore(sample int) float64 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.isEmpty() || sample < 1 {
+		return 0
+	}
+
+	counts := make(map[string]int, len(m.entries))
+	rnd := rand.New(rand.NewSource(1))
+	for i := 0; i < sample; i++ {
+		counts[m.get(strconv.FormatUint(rnd.Uint64(), 36))]++
+	}
+
+	totalWeight := 0
+	for _, e := range m.entries {
+		totalWeight += len(e.positions)
+	}
+
+	score := 0.0
+	for key, e := range m.entries {
+		expected := float64(sample) * float64(len(e.positions)) / float64(totalWeight)
+		if expected > 0 {
+			score = math.Max(score, float64(counts[key])/expected)
+		}
+	}
+	return score
+}
@@ -0,0 +1,57 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"testing"
+)
+
+func TestBalanceScore(t *testing.T) {
+	if score := New(1, nil).BalanceScore(1000); score != 0 {
+		t.Errorf("Expected a score of 0 for an empty ring, but got: %v", score)
+	}
+
+	single := New(1, nil)
+	single.AddString("A")
+	if score := single.BalanceScore(1000); score != 1 {
+		t.Errorf("Expected a score of 1 for a single item, but got: %v", score)
+	}
+
+	coarse := New(1, nil)
+	coarse.AddString("A", "B", "C", "D")
+	fine := New(200, nil)
+	fine.AddString("A", "B", "C", "D")
+
+	coarseScore, fineScore := coarse.BalanceScore(10000), fine.BalanceScore(10000)
+	if fineScore >= coarseScore {
+		t.Errorf("Expected more virtual nodes to balance better, but got: %v and %v", coarseScore, fineScore)
+	}
+	if fineScore > 1.2 {
+		t.Errorf("Expected a score close to 1 with 200 virtual nodes, but got: %v", fineScore)
+	}
+	if fine.BalanceScore(10000) != fineScore {
+		t.Errorf("Expected scores to be reproducible")
+	}
+
+	// Weights are taken into account.
+	weighted := New(200, nil)
+	weighted.AddString("A", "B")
+	weighted.AddStringWithWeight("C", 400)
+	if score := weighted.BalanceScore(10000); score > 1.2 {
+		t.Errorf("Expected weighted items to be balanced, but got: %v", score)
+	}
+}
@@ -0,0 +1,299 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+)
+
+const (
+	binaryMagic   = "GCHB"
+	binaryVersion = 2
+)
+
+// Encodes the items of the hash in a compact binary layout, see
+// Snapshot.MarshalBinary.
+func (m *Map) MarshalBinary() ([]byte, error) {
+	return m.Snapshot().MarshalBinary()
+}
+
+// Replaces the items of the hash with the items encoded by MarshalBinary,
+// like Restore. The hash must have been created by New.
+func (m *Map) UnmarshalBinary(data []byte) error {
+	s := &Snapshot{}
+	if err := s.UnmarshalBinary(data); err != nil {
+		return err
+	}
+	return m.Restore(s)
+}
+
+// Encodes the snapshot in a compact binary layout, for embedding ring
+// state in other messages. All strings (names, label keys and values)
+// are stored once in a table and referred to by index, and positions are
+// stored as varint deltas, so their order is not preserved. Metadata is
+// stored as JSON.
+func (s *Snapshot) MarshalBinary() ([]byte, error) {
+	var strs []string
+	index := make(map[string]uint64)
+	intern := func(v string) uint64 {
+		i, exists := index[v]
+		if !exists {
+			i = uint64(len(strs))
+			index[v] = i
+			strs = append(strs, v)
+		}
+		return i
+	}
+
+	var body []byte
+	body = binary.AppendUvarint(body, uint64(len(s.Nodes)))
+	for _, n := range s.Nodes {
+		body = binary.AppendUvarint(body, intern(n.ID))
+		body = binary.AppendVarint(body, int64(n.Weight))
+
+		positions := n.VirtualNodes
+		explicit := n.Tokens != nil
+		if explicit {
+			positions = n.Tokens
+			body = append(body, 1)
+		} else {
+			body = append(body, 0)
+		}
+		body = appendPositions(body, positions)
+		body = appendPositions(body, n.RemovedVirtualNodes)
+
+		keys := make([]string, 0, len(n.Labels))
+		for k := range n.Labels {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		body = binary.AppendUvarint(body, uint64(len(keys)))
+		for _, k := range keys {
+			body = binary.AppendUvarint(body, intern(k))
+			body = binary.AppendUvarint(body, intern(n.Labels[k]))
+		}
+
+		var meta []byte
+		if len(n.Metadata) > 0 {
+			var err error
+			if meta, err = json.Marshal(n.Metadata); err != nil {
+				return nil, err
+			}
+		}
+		body = binary.AppendUvarint(body, uint64(len(meta)))
+		body = append(body, meta...)
+		body = binary.LittleEndian.AppendUint64(body, math.Float64bits(n.Capacity))
+	}
+
+	out := append([]byte(binaryMagic), binaryVersion)
+	out = binary.AppendUvarint(out, s.Epoch)
+	out = appendString(out, s.Compat)
+	out = binary.AppendUvarint(out, uint64(len(strs)))
+	for _, v := range strs {
+		out = appendString(out, v)
+	}
+	return append(out, body...), nil
+}
+
+// Decodes a snapshot encoded by MarshalBinary.
+func (s *Snapshot) UnmarshalBinary(data []byte) error {
+	if len(data) < len(binaryMagic)+1 || string(data[:len(binaryMagic)]) != binaryMagic {
+		return errors.New("Not a binary snapshot, invalid header")
+	}
+	// Version 1 lacks removed virtual nodes and capacities.
+	version := data[len(binaryMagic)]
+	if version < 1 || version > binaryVersion {
+		return fmt.Errorf("Unsupported binary snapshot version: %d", version)
+	}
+
+	r := &binaryReader{data: data[len(binaryMagic)+1:]}
+	decoded := Snapshot{Epoch: r.uvarint(), Compat: r.string()}
+	strs := make([]string, r.length())
+	for i := range strs {
+		strs[i] = r.string()
+	}
+	str := func() string {
+		i := r.uvarint()
+		if i >= uint64(len(strs)) {
+			r.fail()
+			return ""
+		}
+		return strs[i]
+	}
+
+	decoded.Nodes = make([]SnapshotNode, r.length())
+	for i := range decoded.Nodes {
+		n := SnapshotNode{ID: str(), Weight: int(r.varint())}
+		explicit := r.byte() == 1
+		positions := r.positions()
+		if explicit {
+			n.Tokens = positions
+			if n.Tokens == nil {
+				n.Tokens = []uint32{}
+			}
+		} else {
+			n.VirtualNodes = positions
+		}
+		if version >= 2 {
+			n.RemovedVirtualNodes = r.positions()
+		}
+
+		if labels := r.length(); labels > 0 {
+			n.Labels = make(map[string]string, labels)
+			for j := 0; j < labels; j++ {
+				k := str()
+				n.Labels[k] = str()
+			}
+		}
+
+		if meta := r.bytes(); len(meta) > 0 {
+			if err := json.Unmarshal(meta, &n.Metadata); err != nil {
+				return fmt.Errorf("Invalid metadata of '%s' in binary snapshot: %w", n.ID, err)
+			}
+		}
+		if version >= 2 {
+			n.Capacity = math.Float64frombits(r.uint64())
+		}
+		decoded.Nodes[i] = n
+	}
+
+	if r.err != nil {
+		return r.err
+	}
+	if len(r.data) > 0 {
+		return fmt.Errorf("Invalid binary snapshot: %d trailing bytes", len(r.data))
+	}
+	*s = decoded
+	return nil
+}
+
+func appendString(dst []byte, v string) []byte {
+	dst = binary.AppendUvarint(dst, uint64(len(v)))
+	return append(dst, v...)
+}
+
+func appendPositions(dst []byte, positions []uint32) []byte {
+	sorted := append([]uint32(nil), positions...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	dst = binary.AppendUvarint(dst, uint64(len(sorted)))
+	prev := uint32(0)
+	for _, pos := range sorted {
+		dst = binary.AppendUvarint(dst, uint64(pos-prev))
+		prev = pos
+	}
+	return dst
+}
+
+// Reads the fields of a binary snapshot. After the first error all reads
+// return zero values, so the error only needs to be checked at the end.
+type binaryReader struct {
+	data []byte
+	err  error
+}
+
+func (r *binaryReader) fail() {
+	if r.err == nil {
+		r.err = errors.New("Invalid binary snapshot: truncated or corrupt")
+	}
+	r.data = nil
+}
+
+func (r *binaryReader) uvarint() uint64 {
+	v, n := binary.Uvarint(r.data)
+	if n <= 0 {
+		r.fail()
+		return 0
+	}
+	r.data = r.data[n:]
+	return v
+}
+
+func (r *binaryReader) uint64() uint64 {
+	if len(r.data) < 8 {
+		r.fail()
+		return 0
+	}
+	v := binary.LittleEndian.Uint64(r.data)
+	r.data = r.data[8:]
+	return v
+}
+
+func (r *binaryReader) varint() int64 {
+	v, n := binary.Varint(r.data)
+	if n <= 0 {
+		r.fail()
+		return 0
+	}
+	r.data = r.data[n:]
+	return v
+}
+
+// Reads a count of elements, which can't exceed the remaining bytes as
+// every element takes at least one byte.
+func (r *binaryReader) length() int {
+	v := r.uvarint()
+	if v > uint64(len(r.data)) {
+		r.fail()
+		return 0
+	}
+	return int(v)
+}
+
+func (r *binaryReader) byte() byte {
+	if len(r.data) == 0 {
+		r.fail()
+		return 0
+	}
+	b := r.data[0]
+	r.data = r.data[1:]
+	return b
+}
+
+func (r *binaryReader) bytes() []byte {
+	n := r.length()
+	b := r.data[:n]
+	r.data = r.data[n:]
+	return b
+}
+
+func (r *binaryReader) string() string {
+	return string(r.bytes())
+}
+
+func (r *binaryReader) positions() []uint32 {
+	n := r.length()
+	if n == 0 {
+		return nil
+	}
+	out := make([]uint32, n)
+	prev := uint64(0)
+	for i := range out {
+		prev += r.uvarint()
+		if prev > 1<<32-1 {
+			r.fail()
+			return nil
+		}
+		out[i] = uint32(prev)
+	}
+	return out
+}
@@ -0,0 +1,82 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"encoding"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var _ encoding.BinaryMarshaler = &Map{}
+var _ encoding.BinaryUnmarshaler = &Map{}
+
+func TestMarshalBinary(t *testing.T) {
+	hash := New(3, nil)
+	hash.AddString("A", "B")
+	hash.AddWithWeight(&Node{ID: "C", Tags: map[string]string{"zone": "a"}, Meta: map[string]interface{}{"port": "11211"}}, 5)
+	hash.AddWithWeight(&Node{ID: "E", Tags: map[string]string{"zone": "a"}}, 1)
+	hash.AddWithTokens(&StringValue{"D"}, []uint32{300, 2, 1 << 31})
+	hash.AddVirtualNode("A", 42)
+
+	data, err := hash.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if strings.Count(string(data), "zone") != 1 {
+		t.Errorf("Expected label keys to be stored once, but got: %q", data)
+	}
+
+	restored := New(3, nil)
+	if err := restored.UnmarshalBinary(data); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	expected, got := hash.Snapshot(), restored.Snapshot()
+	expected.Epoch, got.Epoch = 0, 0
+	expected.Nodes[3].Tokens = []uint32{2, 300, 1 << 31}
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected %+v, but got: %+v", expected, got)
+	}
+
+	s := &Snapshot{}
+	if err := s.UnmarshalBinary(data); err != nil || s.Epoch != hash.Snapshot().Epoch {
+		t.Errorf("Expected epoch to be encoded, but got: %d, %v", s.Epoch, err)
+	}
+}
+
+func TestUnmarshalBinaryErrors(t *testing.T) {
+	hash := New(1, nil)
+	hash.AddWithWeight(&Node{ID: "A", Tags: map[string]string{"zone": "a"}, Meta: map[string]interface{}{"port": 1}}, 2)
+	hash.AddVirtualNode("A", 42)
+	data, _ := hash.MarshalBinary()
+
+	for i := 0; i < len(data); i++ {
+		if err := (&Snapshot{}).UnmarshalBinary(data[:i]); err == nil {
+			t.Errorf("Expected error for data truncated to %d bytes", i)
+		}
+	}
+	if err := (&Snapshot{}).UnmarshalBinary(append(data, 0)); err == nil {
+		t.Errorf("Expected error for trailing bytes")
+	}
+
+	corrupt := append([]byte(nil), data...)
+	corrupt[4] = 99
+	if err := (&Snapshot{}).UnmarshalBinary(corrupt); err == nil || err.Error() != "Unsupported binary snapshot version: 99" {
+		t.Errorf("Expected error for unknown version, but got: %v", err)
+	}
+}
@@ -0,0 +1,45 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+// Keeps track of the circuit breakers guarding each item. Lookups skip
+// items whose breaker is open and fall through to the next owner.
+// IsOpen is called during lookups with the hash locked for reading, so
+// it must not call back into the hash.
+type BreakerRegistry interface {
+	IsOpen(key string) bool
+}
+
+// A BreakerRegistry backed by a function.
+type BreakerRegistryFunc func(key string) bool
+
+func (f BreakerRegistryFunc) IsOpen(key string) bool {
+	return f(key)
+}
+
+// Sets the breaker registry consulted by Get and GetN. Passing nil
+// disables breaker checks.
+func (m *Map) SetBreakerRegistry(breakers BreakerRegistry) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.breakers = breakers
+}
+
+// Returns true if the item may be selected by lookups.
+func (m *Map) available(key string) bool {
+	return !m.down[key] && !m.isQuarantined(key) && (m.breakers == nil || !m.breakers.IsOpen(key))
+}
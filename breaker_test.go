@@ -0,0 +1,47 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBreakerRegistry(t *testing.T) {
+	hash := New(1, intHash)
+	hash.AddString("10", "20", "30")
+
+	open := map[string]bool{"10": true}
+	hash.SetBreakerRegistry(BreakerRegistryFunc(func(key string) bool { return open[key] }))
+
+	if res := hash.Get("5"); res != "20" {
+		t.Errorf("Entry 5 should fall through to 20, but instead got: %s", res)
+	}
+	if res := hash.GetN("5", 3, nil); !reflect.DeepEqual(res, []string{"20", "30"}) {
+		t.Errorf("Expected open breakers to be skipped, but got: %v", res)
+	}
+
+	open["20"], open["30"] = true, true
+	if res := hash.Get("5"); res != "" {
+		t.Errorf("Expected nothing when all breakers are open, but got: %s", res)
+	}
+
+	hash.SetBreakerRegistry(nil)
+	if res := hash.Get("5"); res != "10" {
+		t.Errorf("Entry 5 should map to 10 without breakers, but instead got: %s", res)
+	}
+}
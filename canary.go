@@ -0,0 +1,89 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"sync"
+)
+
+// Routes a configurable fraction of the keyspace to a candidate ring,
+// while the remaining keys are served by the current ring. Whether a key
+// is part of the canary is decided by its position in the hash space of
+// the current ring, so the decision is deterministic per key and
+// increasing the fraction only ever adds keys to the canary.
+type CanaryRing struct {
+	mu        sync.RWMutex
+	current   *Map
+	candidate *Map
+	threshold uint64
+}
+
+// Creates a canary ring that routes fraction (between 0 and 1) of the
+// keyspace to candidate.
+func NewCanaryRing(current, candidate *Map, fraction float64) *CanaryRing {
+	c := &CanaryRing{current: current, candidate: candidate}
+	c.SetFraction(fraction)
+	return c
+}
+
+// Updates the fraction of the keyspace routed to the candidate ring.
+// Values are clamped between 0 and 1.
+func (c *CanaryRing) SetFraction(fraction float64) {
+	if fraction < 0 {
+		fraction = 0
+	}
+	if fraction > 1 {
+		fraction = 1
+	}
+
+	c.mu.Lock()
+	c.threshold = uint64(fraction * ringSize)
+	c.mu.Unlock()
+}
+
+// Returns the fraction of the keyspace routed to the candidate ring.
+func (c *CanaryRing) Fraction() float64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return float64(c.threshold) / ringSize
+}
+
+// Returns true if the key is routed to the candidate ring.
+func (c *CanaryRing) InCanary(key string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return uint64(c.current.hashKey(key)) < c.threshold
+}
+
+// Returns the ring that serves the provided key.
+func (c *CanaryRing) RingFor(key string) *Map {
+	if c.InCanary(key) {
+		return c.candidate
+	}
+	return c.current
+}
+
+// Gets the closest item to the provided key in the ring serving it.
+func (c *CanaryRing) Get(key string) string {
+	return c.RingFor(key).Get(key)
+}
+
+// Gets the N closest items to the provided key in the ring serving it,
+// see Map.GetN.
+func (c *CanaryRing) GetN(key string, n int, accept func([]string, string) bool) []string {
+	return c.RingFor(key).GetN(key, n, accept)
+}
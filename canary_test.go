@@ -0,0 +1,50 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"testing"
+)
+
+func TestCanaryRing(t *testing.T) {
+	current := New(1, intHash)
+	current.AddString("4294967295")
+	candidate := New(1, intHash)
+	candidate.AddString("10")
+
+	c := NewCanaryRing(current, candidate, 0.5)
+	testCases := map[string]string{
+		"5":          "10",
+		"2147483647": "10",
+		"2147483648": "4294967295",
+		"4000000000": "4294967295",
+	}
+	for k, v := range testCases {
+		if res := c.Get(k); res != v {
+			t.Errorf("Asking for %s, should have yielded %s, but got: %s", k, v, res)
+		}
+	}
+
+	c.SetFraction(0)
+	if c.InCanary("0") {
+		t.Errorf("No key should be part of the canary at fraction 0")
+	}
+	c.SetFraction(2)
+	if !c.InCanary("4294967295") || c.Fraction() != 1 {
+		t.Errorf("Every key should be part of the canary at fraction 1")
+	}
+}
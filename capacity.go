@@ -0,0 +1,85 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"sync"
+)
+
+type usageTable struct {
+	mu    sync.RWMutex
+	usage map[string]float64
+}
+
+// Declares the capacity of an item, in the same units as the usage
+// reported through ReportUsage. A capacity of zero means unlimited.
+func (m *Map) SetCapacity(key string, capacity float64) error {
+	return m.applyInPlace(CapacityChange(key, capacity))
+}
+
+// Returns the declared capacity of an item.
+func (m *Map) Capacity(key string) float64 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if e, exists := m.entries[key]; exists {
+		return e.capacity
+	}
+	return 0
+}
+
+// Reports the current usage of an item.
+func (m *Map) ReportUsage(key string, usage float64) {
+	m.usage.mu.Lock()
+	defer m.usage.mu.Unlock()
+	if m.usage.usage == nil {
+		m.usage.usage = make(map[string]float64)
+	}
+	m.usage.usage[key] = usage
+}
+
+// Returns the last usage reported for an item.
+func (m *Map) Usage(key string) float64 {
+	m.usage.mu.RLock()
+	defer m.usage.mu.RUnlock()
+	return m.usage.usage[key]
+}
+
+// Gets the closest item to the provided key that is not at capacity,
+// along with the number of items that were skipped because they were
+// full. Returns an empty string if every item is full.
+func (m *Map) GetWithSpill(key string) (node string, spilled int) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	m.usage.mu.RLock()
+	defer m.usage.mu.RUnlock()
+
+	seen := map[string]bool{}
+	m.walk(m.hashKey(key), func(_ int, owner string) bool {
+		if seen[owner] || !m.available(owner) {
+			return true
+		}
+		seen[owner] = true
+
+		if c := m.entries[owner].capacity; c > 0 && m.usage.usage[owner] >= c {
+			spilled++
+			return true
+		}
+		node = owner
+		return false
+	})
+	return node, spilled
+}
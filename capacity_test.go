@@ -0,0 +1,49 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"testing"
+)
+
+func TestGetWithSpill(t *testing.T) {
+	hash := New(2, intHash)
+	hash.AddString("10", "20", "30")
+	hash.SetCapacity("10", 100)
+	hash.SetCapacity("20", 50)
+
+	if node, spilled := hash.GetWithSpill("5"); node != "10" || spilled != 0 {
+		t.Errorf("Entry 5 should map to 10 without spilling, but got %s and %d", node, spilled)
+	}
+
+	hash.ReportUsage("10", 100)
+	hash.ReportUsage("20", 60)
+	if node, spilled := hash.GetWithSpill("5"); node != "30" || spilled != 2 {
+		t.Errorf("Entry 5 should spill over to 30, but got %s and %d", node, spilled)
+	}
+
+	hash.ReportUsage("20", 10)
+	if node, spilled := hash.GetWithSpill("5"); node != "20" || spilled != 1 {
+		t.Errorf("Entry 5 should spill over to 20, but got %s and %d", node, spilled)
+	}
+	if hash.Capacity("20") != 50 || hash.Usage("20") != 10 {
+		t.Errorf("Unexpected capacity or usage for 20")
+	}
+	if err := hash.SetCapacity("40", 1); err == nil {
+		t.Errorf("Expected error, but it wasn't returned")
+	}
+}
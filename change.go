@@ -0,0 +1,369 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Describes what kind of membership change a Change represents.
+type ChangeOp int
+
+const (
+	ChangeAdd ChangeOp = iota
+	ChangeRemove
+	ChangeWeight
+	ChangeAddVirtualNode
+	ChangeRemoveVirtualNode
+	ChangeLabels
+	ChangeMetadata
+	ChangeCapacity
+	ChangeDown
+	ChangeUp
+	ChangeQuarantine
+)
+
+func (op ChangeOp) String() string {
+	switch op {
+	case ChangeAdd:
+		return "add"
+	case ChangeRemove:
+		return "remove"
+	case ChangeWeight:
+		return "weight"
+	case ChangeAddVirtualNode:
+		return "add-vnode"
+	case ChangeRemoveVirtualNode:
+		return "remove-vnode"
+	case ChangeLabels:
+		return "labels"
+	case ChangeMetadata:
+		return "metadata"
+	case ChangeCapacity:
+		return "capacity"
+	case ChangeDown:
+		return "down"
+	case ChangeUp:
+		return "up"
+	case ChangeQuarantine:
+		return "quarantine"
+	}
+	return fmt.Sprintf("ChangeOp(%d)", int(op))
+}
+
+// A single membership change that can be applied to the hash.
+type Change struct {
+	Op     ChangeOp
+	Key    string
+	Value  EntryValue // Only used when adding an item.
+	Weight int        // Used when adding an item or changing its weight.
+	Tokens []uint32   // Explicit positions of an item or virtual node, if any.
+	// The new labels, metadata or capacity of an item. Metadata is also
+	// used when adding an item, and then replaces that of the value.
+	Labels   map[string]string
+	Metadata map[string]interface{}
+	Capacity float64
+	// The deadline of a quarantine, the zero time lifts it.
+	Until time.Time
+}
+
+// An ordered set of changes that is applied atomically.
+type Txn []Change
+
+// Returns a change that adds an item with the given weight.
+func AddChange(value EntryValue, weight int) Change {
+	return Change{Op: ChangeAdd, Key: value.HashRingId(), Value: value, Weight: weight}
+}
+
+// Returns a change that adds an item at explicit positions on the ring.
+func AddTokensChange(value EntryValue, tokens []uint32) Change {
+	return Change{Op: ChangeAdd, Key: value.HashRingId(), Value: value, Weight: len(tokens), Tokens: tokens}
+}
+
+// Returns a change that removes the item with the given key.
+func RemoveChange(key string) Change {
+	return Change{Op: ChangeRemove, Key: key}
+}
+
+// Returns a change that updates the weight of an existing item.
+func WeightChange(key string, weight int) Change {
+	return Change{Op: ChangeWeight, Key: key, Weight: weight}
+}
+
+// Returns a change that replaces the labels of an existing item.
+func LabelsChange(key string, labels map[string]string) Change {
+	return Change{Op: ChangeLabels, Key: key, Labels: copyLabels(labels)}
+}
+
+// Returns a change that replaces the metadata of an existing item.
+func MetadataChange(key string, meta map[string]interface{}) Change {
+	return Change{Op: ChangeMetadata, Key: key, Metadata: copyMetadata(meta)}
+}
+
+// Returns a change that sets the capacity of an existing item.
+func CapacityChange(key string, capacity float64) Change {
+	return Change{Op: ChangeCapacity, Key: key, Capacity: capacity}
+}
+
+// Returns a change that marks an existing item as down.
+func DownChange(key string) Change {
+	return Change{Op: ChangeDown, Key: key}
+}
+
+// Returns a change that marks an item as up again.
+func UpChange(key string) Change {
+	return Change{Op: ChangeUp, Key: key}
+}
+
+// Returns a change that quarantines an item until the deadline, or lifts
+// its quarantine if until is the zero time.
+func QuarantineChange(key string, until time.Time) Change {
+	return Change{Op: ChangeQuarantine, Key: key, Until: until}
+}
+
+// Returns true if the change only affects whether an item is available,
+// rather than the members of the hash or their positions.
+func (c Change) availability() bool {
+	return c.Op == ChangeDown || c.Op == ChangeUp || c.Op == ChangeQuarantine
+}
+
+// A contiguous, inclusive range of positions on the ring.
+type HashRange struct {
+	Start uint32
+	End   uint32
+}
+
+// Returns the number of positions covered by the range.
+func (r HashRange) Size() uint64 {
+	return uint64(r.End) - uint64(r.Start) + 1
+}
+
+const ringSize = float64(1 << 32)
+
+// Applies all changes to the hash. Either all changes are applied, or
+// none of them are and the first error encountered is returned.
+func (m *Map) Apply(changes ...Change) error {
+	_, err := m.applyEpoch(changes)
+	return err
+}
+
+func (m *Map) applyEpoch(changes []Change) (uint64, error) {
+	return m.mutateEpoch(changes, func() error {
+		next := m.clone()
+		for _, c := range changes {
+			if err := next.apply(c); err != nil {
+				return err
+			}
+		}
+		m.swap(next)
+		return nil
+	})
+}
+
+// Returns the fraction of the keyspace (between 0 and 1) that would
+// change owner if the provided changes were applied. The hash itself
+// is not modified.
+func (m *Map) EstimateMovement(changes ...Change) (float64, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	next := m.clone()
+	for _, c := range changes {
+		if err := next.apply(c); err != nil {
+			return 0, err
+		}
+	}
+	return rangesFraction(changedRanges(m, next)), nil
+}
+
+func (m *Map) apply(c Change) error {
+	switch c.Op {
+	case ChangeAdd:
+		if c.Value == nil {
+			return fmt.Errorf("Change to add '%s' has no value", c.Key)
+		}
+		var err error
+		if c.Tokens != nil {
+			err = m.addTokens(c.Value, c.Tokens)
+		} else {
+			err = m.addWithWeight(c.Value, c.Weight)
+		}
+		if err == nil && c.Metadata != nil {
+			// The entry was just created, so it isn't shared with clones.
+			m.entries[c.Key].meta = copyMetadata(c.Metadata)
+		}
+		return err
+	case ChangeRemove:
+		if err := m.del(c.Key); err != nil {
+			return err
+		}
+		m.forgetDown(c.Key)
+		return nil
+	case ChangeWeight:
+		entry, exists := m.entries[c.Key]
+		if !exists {
+			return fmt.Errorf("No node with name '%s' found", c.Key)
+		}
+		if entry.explicit {
+			return fmt.Errorf("Node '%s' has explicit tokens, its weight cannot be changed", c.Key)
+		}
+		if err := m.del(c.Key); err != nil {
+			return err
+		}
+		if err := m.addWithWeight(entry.value, c.Weight); err != nil {
+			return err
+		}
+		updated := *entry
+		updated.weight = c.Weight
+		updated.id = m.entries[c.Key].id
+		updated.positions = m.entries[c.Key].positions
+		updated.manual = nil
+		updated.removed = nil
+		m.entries[c.Key] = &updated
+		for _, pos := range entry.removed {
+			if indexOf(updated.positions, pos) >= 0 {
+				if err := m.removeVirtualNode(c.Key, uint32(pos)); err != nil {
+					return err
+				}
+			}
+		}
+		for _, pos := range entry.manual {
+			if err := m.addVirtualNode(c.Key, uint32(pos)); err != nil {
+				return err
+			}
+		}
+		return nil
+	case ChangeAddVirtualNode, ChangeRemoveVirtualNode:
+		if len(c.Tokens) != 1 {
+			return fmt.Errorf("Change of virtual node of '%s' needs exactly one token", c.Key)
+		}
+		if c.Op == ChangeAddVirtualNode {
+			return m.addVirtualNode(c.Key, c.Tokens[0])
+		}
+		return m.removeVirtualNode(c.Key, c.Tokens[0])
+	case ChangeLabels, ChangeMetadata, ChangeCapacity:
+		e, exists := m.entries[c.Key]
+		if !exists {
+			return fmt.Errorf("No node with name '%s' found", c.Key)
+		}
+
+		// Entries may be shared with clones, so replace rather than modify.
+		updated := *e
+		switch c.Op {
+		case ChangeLabels:
+			updated.labels = copyLabels(c.Labels)
+		case ChangeMetadata:
+			updated.meta = copyMetadata(c.Metadata)
+		default:
+			updated.capacity = c.Capacity
+		}
+		m.entries[c.Key] = &updated
+		return nil
+	case ChangeDown:
+		if _, exists := m.entries[c.Key]; !exists {
+			return fmt.Errorf("No node with name '%s' found", c.Key)
+		}
+		m.setDown(c.Key)
+		return nil
+	case ChangeUp:
+		m.forgetDown(c.Key)
+		return nil
+	case ChangeQuarantine:
+		m.setQuarantine(c.Key, c.Until, time.Now())
+		return nil
+	}
+	return fmt.Errorf("Unknown change operation: %s", c.Op)
+}
+
+// Applies a single change that only updates an entry, which cannot fail
+// halfway, without cloning the hash.
+func (m *Map) applyInPlace(c Change) error {
+	return m.mutate([]Change{c}, func() error { return m.apply(c) })
+}
+
+// Returns the ranges of the ring that have a different owner in a than
+// they have in b. Both rings must be locked by the caller.
+func changedRanges(a, b *Map) []HashRange {
+	var out []HashRange
+	diffRanges(a, b, func(r HashRange, _, _ string) {
+		if n := len(out); n > 0 && uint64(out[n-1].End)+1 == uint64(r.Start) {
+			out[n-1].End = r.End
+			return
+		}
+		out = append(out, r)
+	})
+	return out
+}
+
+// Calls visit, in ring order, for every range that has a different owner
+// in a than it has in b. The owner within each visited range is the same
+// throughout. Both rings must be locked by the caller.
+func diffRanges(a, b *Map, visit func(r HashRange, from, to string)) {
+	owner := func(m *Map, hash int) string {
+		if m.isEmpty() {
+			return ""
+		}
+		return m.ownerOf(hash)
+	}
+
+	// Collect the positions after which the owner may change. Walking
+	// clockwise a replica owns the positions up to and including its own,
+	// walking counterclockwise it owns the positions starting at its own.
+	cuts := make([]int, 0, len(a.keys)+len(b.keys))
+	for _, m := range []*Map{a, b} {
+		for _, k := range m.keys {
+			if m.direction == Counterclockwise {
+				k--
+			}
+			if k >= 0 {
+				cuts = append(cuts, k)
+			}
+		}
+	}
+	sort.Ints(cuts)
+	if a.isEmpty() && b.isEmpty() {
+		return
+	}
+
+	check := func(r HashRange) {
+		if from, to := owner(a, int(r.Start)), owner(b, int(r.Start)); from != to {
+			visit(r, from, to)
+		}
+	}
+
+	prev := -1
+	for _, c := range cuts {
+		if c == prev {
+			continue
+		}
+		check(HashRange{uint32(prev + 1), uint32(c)})
+		prev = c
+	}
+	if prev < 1<<32-1 {
+		check(HashRange{uint32(prev + 1), 1<<32 - 1})
+	}
+}
+
+func rangesFraction(ranges []HashRange) float64 {
+	var total uint64
+	for _, r := range ranges {
+		total += r.Size()
+	}
+	return float64(total) / ringSize
+}
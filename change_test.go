@@ -0,0 +1,84 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"strconv"
+	"testing"
+)
+
+// Hash function that returns easier to reason about values. Assumes the
+// keys can be converted to an integer.
+func intHash(key []byte) uint32 {
+	i, err := strconv.Atoi(string(key))
+	if err != nil {
+		panic(err)
+	}
+	return uint32(i)
+}
+
+func TestApply(t *testing.T) {
+	hash := New(1, intHash)
+	hash.AddString("10", "20")
+
+	err := hash.Apply(
+		AddChange(&StringValue{"30"}, 1),
+		RemoveChange("10"),
+		WeightChange("20", 2),
+	)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if len(hash.hashMap) != 3 {
+		t.Errorf("Expected ring to have 3 elements, but it's got: %d", len(hash.hashMap))
+	}
+	if res := hash.Get("5"); res != "20" {
+		t.Errorf("Entry 5 should map to 20, but instead got: %s", res)
+	}
+
+	// A failing change should leave the hash untouched.
+	if err := hash.Apply(RemoveChange("30"), RemoveChange("10")); err == nil {
+		t.Errorf("Expected error, but it wasn't returned")
+	}
+	if res := hash.Get("25"); res != "30" {
+		t.Errorf("Entry 25 should map to 30, but instead got: %s", res)
+	}
+}
+
+func TestEstimateMovement(t *testing.T) {
+	hash := New(1, intHash)
+	if res, _ := hash.EstimateMovement(AddChange(&StringValue{"10"}, 1)); res != 1 {
+		t.Errorf("Adding to an empty ring should move everything, but moved: %f", res)
+	}
+
+	hash.AddString("0", "1073741824", "2147483648", "3221225472")
+	res, err := hash.EstimateMovement(RemoveChange("2147483648"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if res != 0.25 {
+		t.Errorf("Removing a quarter of the ring should move 0.25, but moved: %f", res)
+	}
+
+	res, _ = hash.EstimateMovement(RemoveChange("0"))
+	if res != 0.25 {
+		t.Errorf("Removing the wrapping item should move 0.25, but moved: %f", res)
+	}
+	if len(hash.entries) != 4 {
+		t.Errorf("Estimating movement should not modify the ring")
+	}
+}
@@ -0,0 +1,183 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// Creates a client for an item of the hash.
+type ClientFactory func(value EntryValue) (io.Closer, error)
+
+// Lazily creates a client per item of the hash, and closes the client
+// once its item is removed from the hash.
+type ClientRegistry struct {
+	m       *Map
+	factory ClientFactory
+	cancel  func()
+	mu      sync.Mutex
+	clients map[string]io.Closer
+	dialing map[string]*pendingClient
+	closed  bool
+	onError func(node string, err error)
+}
+
+// A client that is being created. Done is closed once it is.
+type pendingClient struct {
+	done   chan struct{}
+	client io.Closer
+	err    error
+}
+
+// Returned by a ClientRegistry after it was closed.
+var ErrRegistryClosed = errors.New("Client registry is closed")
+
+// Creates a registry that creates clients for items of the hash using
+// factory. Errors returned when closing clients are passed to onError,
+// which may be nil.
+func NewClientRegistry(m *Map, factory ClientFactory, onError func(node string, err error)) *ClientRegistry {
+	r := &ClientRegistry{
+		m:       m,
+		factory: factory,
+		clients: make(map[string]io.Closer),
+		dialing: make(map[string]*pendingClient),
+		onError: onError,
+	}
+	r.cancel = m.OnChange(r.handle)
+	return r
+}
+
+func (r *ClientRegistry) handle(event ChangeEvent) {
+	for _, c := range event.Changes {
+		if c.Op == ChangeRemove {
+			r.remove(c.Key)
+		}
+	}
+}
+
+func (r *ClientRegistry) remove(node string) {
+	r.mu.Lock()
+	client, exists := r.clients[node]
+	delete(r.clients, node)
+	r.mu.Unlock()
+
+	if exists {
+		if err := client.Close(); err != nil && r.onError != nil {
+			r.onError(node, err)
+		}
+	}
+}
+
+// Returns the client for the provided item, creating it if needed. The
+// factory is called without holding the lock, and concurrent calls for
+// the same item wait for the same client.
+func (r *ClientRegistry) Client(node string) (io.Closer, error) {
+	r.mu.Lock()
+	if r.closed {
+		r.mu.Unlock()
+		return nil, ErrRegistryClosed
+	}
+	if client, exists := r.clients[node]; exists {
+		r.mu.Unlock()
+		return client, nil
+	}
+	if p, exists := r.dialing[node]; exists {
+		r.mu.Unlock()
+		<-p.done
+		return p.client, p.err
+	}
+	p := &pendingClient{done: make(chan struct{})}
+	r.dialing[node] = p
+	r.mu.Unlock()
+
+	defer close(p.done)
+	p.client, p.err = r.create(node)
+	return p.client, p.err
+}
+
+func (r *ClientRegistry) create(node string) (io.Closer, error) {
+	value, exists := r.m.Value(node)
+	if !exists {
+		r.finish(node)
+		return nil, fmt.Errorf("No node with name '%s' found", node)
+	}
+	client, err := r.factory(value)
+	if err != nil {
+		r.finish(node)
+		return nil, err
+	}
+
+	// The item may have been removed, or the registry closed, meanwhile.
+	// Removals applied after this check close the client as usual.
+	r.mu.Lock()
+	delete(r.dialing, node)
+	_, exists = r.m.Value(node)
+	if !r.closed && exists {
+		r.clients[node] = client
+		r.mu.Unlock()
+		return client, nil
+	}
+	closed := r.closed
+	r.mu.Unlock()
+
+	if err := client.Close(); err != nil && r.onError != nil {
+		r.onError(node, err)
+	}
+	if closed {
+		return nil, ErrRegistryClosed
+	}
+	return nil, fmt.Errorf("No node with name '%s' found", node)
+}
+
+func (r *ClientRegistry) finish(node string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.dialing, node)
+}
+
+// Returns the owner of key along with its client.
+func (r *ClientRegistry) ForKey(key string) (string, io.Closer, error) {
+	node := r.m.Get(key)
+	if node == "" {
+		return "", nil, ErrEmptyRing
+	}
+	client, err := r.Client(node)
+	return node, client, err
+}
+
+// Stops following changes of the hash and closes all clients. Returns
+// the first error encountered while closing clients.
+func (r *ClientRegistry) Close() error {
+	r.cancel()
+
+	r.mu.Lock()
+	clients := r.clients
+	r.clients = make(map[string]io.Closer)
+	r.closed = true
+	r.mu.Unlock()
+
+	var first error
+	for _, client := range clients {
+		if err := client.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
@@ -0,0 +1,117 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"io"
+	"sync"
+	"testing"
+)
+
+type testClient struct {
+	node   string
+	closed bool
+}
+
+func (c *testClient) Close() error {
+	c.closed = true
+	return nil
+}
+
+func TestClientRegistry(t *testing.T) {
+	hash := New(1, intHash)
+	hash.AddString("10", "20")
+
+	created := 0
+	r := NewClientRegistry(hash, func(value EntryValue) (io.Closer, error) {
+		created++
+		return &testClient{node: value.HashRingId()}, nil
+	}, nil)
+
+	node, client, err := r.ForKey("5")
+	if err != nil || node != "10" || client.(*testClient).node != "10" {
+		t.Fatalf("Expected client for 10, but got %s and error: %v", node, err)
+	}
+	if again, _ := r.Client("10"); again != client || created != 1 {
+		t.Errorf("Expected client to be reused")
+	}
+	if _, err := r.Client("30"); err == nil {
+		t.Errorf("Expected error, but it wasn't returned")
+	}
+
+	hash.Del("10")
+	if !client.(*testClient).closed {
+		t.Errorf("Expected client to be closed when its node is removed")
+	}
+
+	other, _ := r.Client("20")
+	r.Close()
+	if !other.(*testClient).closed {
+		t.Errorf("Expected all clients to be closed")
+	}
+}
+
+func TestClientRegistrySlowFactory(t *testing.T) {
+	hash := New(1, intHash)
+	hash.AddString("10", "20")
+
+	release := make(chan struct{})
+	started := make(chan struct{})
+	var mu sync.Mutex
+	created := map[string]*testClient{}
+	r := NewClientRegistry(hash, func(value EntryValue) (io.Closer, error) {
+		if value.HashRingId() == "10" {
+			close(started)
+			<-release
+		}
+		client := &testClient{node: value.HashRingId()}
+		mu.Lock()
+		created[value.HashRingId()] = client
+		mu.Unlock()
+		return client, nil
+	}, nil)
+
+	results := make(chan error, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			_, err := r.Client("10")
+			results <- err
+		}()
+	}
+	<-started
+
+	// Other items are not blocked by the slow dial.
+	if client, err := r.Client("20"); err != nil || client.(*testClient).node != "20" {
+		t.Errorf("Expected client for 20, but got: %v", err)
+	}
+
+	r.Close()
+	close(release)
+	for i := 0; i < 2; i++ {
+		if err := <-results; err != ErrRegistryClosed {
+			t.Errorf("Expected ErrRegistryClosed for a client created during Close, but got: %v", err)
+		}
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(created) != 2 || !created["10"].closed {
+		t.Errorf("Expected one client per item, and the late one to be closed: %v", created)
+	}
+	if _, err := r.Client("20"); err != ErrRegistryClosed {
+		t.Errorf("Expected ErrRegistryClosed after Close, but got: %v", err)
+	}
+}
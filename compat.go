@@ -0,0 +1,76 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"fmt"
+)
+
+// A frozen version of the way keys are mapped to items. Improvements that
+// change the mapping ship as new modes, so a hash created in a mode keeps
+// mapping keys to the same items across upgrades of this package.
+type CompatMode int
+
+const (
+	// No mode is pinned: the mapping follows the options of the hash, and
+	// defaults may change between versions.
+	CompatNone CompatMode = iota
+
+	// Virtual node labels that consist of the index followed by the item
+	// name, clockwise lookups and successor walks for replicas, as
+	// specified in NewParity. Combined with CRC-32 hashing without a seed,
+	// as used by NewParity, keys map as in other implementations.
+	CompatV1
+)
+
+func (c CompatMode) String() string {
+	switch c {
+	case CompatNone:
+		return "none"
+	case CompatV1:
+		return "v1"
+	}
+	return fmt.Sprintf("CompatMode(%d)", int(c))
+}
+
+// Pins the construction of the ring to a compatibility mode. Options that
+// conflict with the mode are overridden, regardless of the order in which
+// options are provided, including double hashing, which derives virtual
+// nodes differently. The hash function and seed are kept as provided, so
+// adding the mode to an existing hash does not change the mapping of its
+// keys.
+func WithCompat(mode CompatMode) Option {
+	return func(m *Map) {
+		m.compat = mode
+	}
+}
+
+// Returns the compatibility mode of the hash.
+func (m *Map) Compat() CompatMode {
+	return m.compat
+}
+
+// Overrides the settings that conflict with the compatibility mode.
+func (m *Map) applyCompat() {
+	switch m.compat {
+	case CompatV1:
+		m.label = nil
+		m.direction = Clockwise
+		m.placement = nil
+		m.doubleHash = false
+	}
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"encoding/json"
+	"hash/crc32"
+	"os"
+	"strconv"
+	"testing"
+)
+
+func TestCompatV1(t *testing.T) {
+	hash := New(3, nil, WithCompat(CompatV1), WithDirection(Counterclockwise))
+	reference := NewParity(3)
+	for _, h := range []*Map{hash, reference} {
+		h.AddString("A", "B", "C")
+	}
+
+	if hash.Compat() != CompatV1 || hash.Compat().String() != "v1" || New(1, nil).Compat() != CompatNone {
+		t.Errorf("Unexpected compatibility mode: %s", hash.Compat())
+	}
+	for i := 0; i < 1000; i++ {
+		key := strconv.Itoa(i)
+		if res, expected := hash.Get(key), reference.Get(key); res != expected {
+			t.Fatalf("Expected %s to map to %s, but got: %s", key, expected, res)
+		}
+	}
+}
+
+func TestCompatDoubleHashing(t *testing.T) {
+	data, err := os.ReadFile("testdata/parity_vectors.json")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	var vectors VectorSet
+	if err := json.Unmarshal(data, &vectors); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	hash := New(0, crc32.ChecksumIEEE, WithDoubleHashing(), WithCompat(CompatV1))
+	for _, node := range vectors.Nodes {
+		hash.AddStringWithWeight(node.ID, node.Weight)
+	}
+	for _, v := range hash.VerifyVectors(&vectors) {
+		t.Errorf("Asking for %q yielded %s and %s with double hashing, which does not match the reference", v.Key, v.Owner, v.Replicas)
+	}
+}
+
+func TestCompatKeepsHash(t *testing.T) {
+	hash := New(4, FNV1a32, WithSeed("foo"))
+	pinned := New(4, FNV1a32, WithSeed("foo"), WithCompat(CompatV1))
+	for _, h := range []*Map{hash, pinned} {
+		h.AddString("A", "B", "C", "D")
+	}
+
+	for i := 0; i < 1000; i++ {
+		key := strconv.Itoa(i)
+		if res, expected := pinned.Get(key), hash.Get(key); res != expected {
+			t.Fatalf("Expected %s to keep mapping to %s, but got: %s", key, expected, res)
+		}
+	}
+}
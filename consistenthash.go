@@ -19,17 +19,44 @@ limitations under the License.
 package GoConsistentHash
 
 import (
+	"errors"
 	"fmt"
 	"hash/crc32"
 	"sort"
 	"strconv"
+	"sync"
+	"time"
 )
 
+// Returned when an operation requires at least one item in the hash.
+var ErrEmptyRing = errors.New("The hash ring is empty")
+
+// Returned when fewer items than requested are available for a lookup.
+var ErrNotEnoughNodes = errors.New("Not enough nodes available")
+
+// Hashes data onto the ring. The data is only valid during the call and
+// must not be retained.
 type Hash func(data []byte) uint32
 
 type entry struct {
+	id     int32 // Index of the name of the item in Map.names.
 	weight int
 	value  EntryValue
+	labels map[string]string
+	meta   map[string]interface{}
+
+	// Zero means the capacity is unlimited.
+	capacity float64
+
+	// The positions of the virtual nodes of the item, and whether these
+	// were provided explicitly rather than derived from the weight.
+	positions []int
+	explicit  bool
+
+	// Virtual nodes that were placed manually, see AddVirtualNode, and
+	// derived ones that were removed, see RemoveVirtualNode.
+	manual  []int
+	removed []int
 }
 
 type EntryValue interface {
@@ -45,31 +72,82 @@ func (e *StringValue) HashRingId() string {
 }
 
 type Map struct {
+	mu            sync.RWMutex
 	hash          Hash
 	defaultWeight int
-	keys          []int // Sorted
-	hashMap       map[int]string
+	keys          []int           // Sorted
+	hashMap       map[int]int32   // Position to index into names.
+	contenders    map[int][]int32 // Items that lost a position to its owner, copy-on-write.
+	names         []string        // Interned item names, "" for free slots.
+	free          []int32
 	entries       map[string]*entry
+	planned       map[*PlannedChange]struct{}
+	loads         LoadReporter
+	latencies     *LatencyTracker
+	breakers      BreakerRegistry
+	constraints   Constraints
+	placement     PlacementStrategy
+	replicaRand   *lockedRand
+	health        HealthChecker
+	down          map[string]bool      // Copy-on-write
+	quarantined   map[string]time.Time // Copy-on-write
+	quarantineEnd time.Time            // The latest deadline in quarantined.
+	direction     Direction
+	label         LabelFunc
+	doubleHash    bool
+	seeded        bool
+	seed          uint32
+	compat        CompatMode
+	logger        Logger
+	stats         *lookupStats
+	epoch         uint64
+	listeners     listeners
+	usage         usageTable
+	remaps        *remapHistory
 }
 
-func New(defaultWeight int, fn Hash) *Map {
+// Creates a new hash in which items get defaultWeight virtual nodes
+// unless specified otherwise, using fn (crc32 if nil) as hash function.
+func New(defaultWeight int, fn Hash, opts ...Option) *Map {
 	m := &Map{
 		defaultWeight: defaultWeight,
 		hash:          fn,
-		hashMap:       make(map[int]string),
+		hashMap:       make(map[int]int32),
 		entries:       make(map[string]*entry),
+		planned:       make(map[*PlannedChange]struct{}),
 	}
 	if m.hash == nil {
 		m.hash = crc32.ChecksumIEEE
 	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	m.applyCompat()
 	return m
 }
 
 // Returns true if there are no items available.
 func (m *Map) IsEmpty() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.isEmpty()
+}
+
+func (m *Map) isEmpty() bool {
 	return len(m.keys) == 0
 }
 
+// Returns the value of the item with the provided name.
+func (m *Map) Value(key string) (EntryValue, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	entry, exists := m.entries[key]
+	if !exists {
+		return nil, false
+	}
+	return entry.value, true
+}
+
 // Adds some strings to the hash.
 func (m *Map) AddString(keys ...string) error {
 	for _, key := range keys {
@@ -88,41 +166,135 @@ func (m *Map) AddStringWithWeight(key string, weight int) error {
 
 // Adds an item to the hash.
 func (m *Map) AddWithWeight(entryValue EntryValue, weight int) error {
+	return m.mutate([]Change{AddChange(entryValue, weight)}, func() error {
+		return m.addWithWeight(entryValue, weight)
+	})
+}
+
+func (m *Map) addWithWeight(entryValue EntryValue, weight int) error {
+	key := entryValue.HashRingId()
+	return m.addEntry(entryValue, weight, m.vnodePositions(key, weight), false)
+}
+
+func (m *Map) addEntry(entryValue EntryValue, weight int, positions []int, explicit bool) error {
 	key := entryValue.HashRingId()
 	if _, exists := m.entries[key]; exists {
 		return fmt.Errorf("A node with name '%s' already exists", key)
 	}
-	m.entries[key] = &entry{weight, entryValue}
+	id := m.intern(key)
+	m.entries[key] = &entry{
+		id:        id,
+		weight:    weight,
+		value:     entryValue,
+		labels:    labelsOf(entryValue),
+		meta:      metadataOf(entryValue),
+		positions: positions,
+		explicit:  explicit,
+	}
 
-	for i := 0; i < weight; i++ {
-		hash := int(m.hash([]byte(strconv.Itoa(i) + key)))
-		m.keys = append(m.keys, hash)
-		m.hashMap[hash] = key
+	for _, hash := range positions {
+		if m.claim(id, hash) {
+			m.keys = append(m.keys, hash)
+		}
 	}
 	sort.Ints(m.keys)
 	return nil
 }
 
+// Removes an item from the hash.
 func (m *Map) Del(key string) error {
+	return m.mutate([]Change{RemoveChange(key)}, func() error {
+		if err := m.del(key); err != nil {
+			return err
+		}
+		m.forgetDown(key)
+		return nil
+	})
+}
+
+func (m *Map) del(key string) error {
 	entry, exists := m.entries[key]
 	if !exists {
 		return fmt.Errorf("No node with name '%s' found", key)
 	}
 
-	for i := 0; i < entry.weight; i++ {
-		hash := int(m.hash([]byte(strconv.Itoa(i) + key)))
-		delete(m.hashMap, hash)
+	for _, hash := range entry.positions {
+		m.unclaim(entry.id, hash)
+	}
+
+	delete(m.entries, key)
+	m.release(entry.id)
+	return nil
+}
+
+// Claims a position on the ring for the item with the provided index, and
+// returns whether the position is new to the ring, in which case the
+// caller adds it to the keys. When virtual nodes of different items share
+// a position, the item whose name sorts first owns it, so that the ring
+// doesn't depend on the order in which items were added. The others are
+// kept as contenders, to take over when the owner leaves.
+func (m *Map) claim(id int32, pos int) bool {
+	owner, taken := m.hashMap[pos]
+	if !taken {
+		m.hashMap[pos] = id
+		return true
+	}
+	if owner == id {
+		return false
+	}
+
+	if m.names[id] < m.names[owner] {
+		m.hashMap[pos] = id
+		id = owner
+	}
+	if m.contenders == nil {
+		m.contenders = make(map[int][]int32)
+	}
+	m.contenders[pos] = append(append([]int32(nil), m.contenders[pos]...), id)
+	return false
+}
+
+// Releases a position claimed by the item with the provided index. The
+// position goes to the contender whose name sorts first, or is removed
+// from the ring if there is none.
+func (m *Map) unclaim(id int32, pos int) {
+	owner, taken := m.hashMap[pos]
+	if !taken {
+		return
+	}
+
+	contenders := m.contenders[pos]
+	if owner == id {
+		if len(contenders) == 0 {
+			delete(m.hashMap, pos)
+			if i := sort.SearchInts(m.keys, pos); i < len(m.keys) && m.keys[i] == pos {
+				m.keys = append(m.keys[:i], m.keys[i+1:]...)
+			}
+			return
+		}
 
-		for k, v := range m.keys {
-			if v == hash {
-				m.keys = append(m.keys[:k], m.keys[k+1:]...)
+		// Let the first contender take over, and remove it below.
+		first := 0
+		for i, c := range contenders {
+			if m.names[c] < m.names[contenders[first]] {
+				first = i
 			}
 		}
+		id = contenders[first]
+		m.hashMap[pos] = id
 	}
 
-	sort.Ints(m.keys)
-	delete(m.entries, key)
-	return nil
+	remaining := make([]int32, 0, len(contenders))
+	for _, c := range contenders {
+		if c != id {
+			remaining = append(remaining, c)
+		}
+	}
+	if len(remaining) == 0 {
+		delete(m.contenders, pos)
+		return
+	}
+	m.contenders[pos] = remaining
 }
 
 // Gets the N closest items in the hash to the provided key,
@@ -137,44 +309,346 @@ func (m *Map) Del(key string) error {
 //
 // The AcceptAny and AcceptUnique functions are provided as utility
 // functions that can be used as accept-callback.
+//
+// The accept function is called while the hash is locked for reading,
+// so it must not call back into the hash (e.g. Value, Labels or IsDown):
+// once a writer is waiting, such a call deadlocks. Anything it needs to
+// know about the items has to be taken beforehand, e.g. with LabelOf.
+// The same applies to every other method taking an accept function.
 func (m *Map) GetN(key string, n int, accept func([]string, string) bool) []string {
-	out := []string{}
-	if m.IsEmpty() || n < 1 {
-		return out
+	if m.stats != nil {
+		defer m.stats.getN.observeSince(time.Now())
 	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.getN(key, n, accept)
+}
 
-	if accept == nil {
-		accept = AcceptAny
+func (m *Map) getN(key string, n int, accept func([]string, string) bool) []string {
+	return m.getNHash(m.hashKey(key), n, accept)
+}
+
+func (m *Map) getNHash(hash, n int, accept func([]string, string) bool) []string {
+	return m.appendN([]string{}, hash, n, accept)
+}
+
+// Appends the N closest items to the provided hash to dst. The accept
+// function only gets to see the appended items.
+func (m *Map) appendN(dst []string, hash, n int, accept func([]string, string) bool) []string {
+	return m.place(dst, hash, n, accept, nil)
+}
+
+// Appends the replicas for hash to dst as selected by the placement
+// strategy of the hash, enforcing its constraints. If rejected is not
+// nil it is called with the index of every constraint that rejected a
+// candidate.
+func (m *Map) place(dst []string, hash, n int, accept func([]string, string) bool, rejected func(int)) []string {
+	return m.placeChecked(PlacementView{m: m}, dst, hash, n, placementCheck{m: m, accepts: accept, rejected: rejected})
+}
+
+// Appends the replicas for hash to dst like place, but with a check that
+// can also be consulted for the first candidate, and a view that can be
+// used to stop the walk.
+func (m *Map) placeChecked(view PlacementView, dst []string, hash, n int, check placementCheck) []string {
+	if m.isEmpty() || n < 1 {
+		return dst
 	}
 
-	hash := int(m.hash([]byte(key)))
-	hashKey := m.getKeyFromHash(hash)
-	out = append(out, m.hashMap[hashKey])
+	if check.accepts == nil {
+		check.accepts = AcceptAny
+	}
 
-	ringLength := len(m.hashMap)
-	for i := 1; len(out) < n && i < ringLength; i++ {
-		hashKey = m.getKeyFromHash(hashKey + 1)
-		res := m.hashMap[hashKey]
-		if accept(out, res) {
-			out = append(out, res)
+	if m.placement == nil {
+		// Calling the default strategy directly, with a closure that does
+		// not escape, keeps lookups free of allocations.
+		walked := 0
+		dst = RingWalkStrategy{}.Place(view, dst, hash, n, func(picked []string, found string) bool {
+			walked++
+			return check.accept(picked, found)
+		})
+		m.stats.observeWalk(walked)
+		return dst
+	}
+
+	walked := 0
+	dst = m.placement.Place(view, dst, hash, n, func(picked []string, found string) bool {
+		walked++
+		return check.accept(picked, found)
+	})
+	m.stats.observeWalk(walked)
+	return dst
+}
+
+// Combines the constraints of a hash with the accept function of a lookup.
+type placementCheck struct {
+	m        *Map
+	accepts  func([]string, string) bool
+	rejected func(int)
+	// Whether accepts is consulted for the first candidate as well.
+	first bool
+}
+
+func (c placementCheck) accept(picked []string, found string) bool {
+	if i := c.m.violated(picked, found); i >= 0 {
+		if c.rejected != nil {
+			c.rejected(i)
 		}
+		return false
 	}
+	return (len(picked) == 0 && !c.first) || c.accepts(picked, found)
+}
+
+// Gets the N closest items in the hash to the provided key, like GetN,
+// but passes the values of the items to the accept function. This allows
+// to filter on item metadata (e.g. zone or capacity) without having to
+// look up the items separately.
+//
+// The AcceptAnyValue and AcceptUniqueValue functions are provided as
+// utility functions that can be used as accept-callback.
+//
+// The accept function must not call back into the hash, see GetN.
+func (m *Map) GetNValues(key string, n int, accept func([]EntryValue, EntryValue) bool) []EntryValue {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if accept == nil {
+		accept = AcceptAnyValue
+	}
+
+	var values []EntryValue
+	nodes := m.place([]string{}, m.hashKey(key), n, func(picked []string, found string) bool {
+		values = values[:0]
+		for _, node := range picked {
+			values = append(values, m.entries[node].value)
+		}
+		return accept(values, m.entries[found].value)
+	}, nil)
 
+	out := make([]EntryValue, 0, len(nodes))
+	for _, node := range nodes {
+		out = append(out, m.entries[node].value)
+	}
 	return out
 }
 
+// Visits the owner of every position on the ring in ring order, starting
+// at the position that owns the provided hash, until visit returns false.
+func (m *Map) walk(hash int, visit func(pos int, owner string) bool) {
+	if m.isEmpty() {
+		return
+	}
+
+	pos := m.getKeyFromHash(hash)
+	ringLength := len(m.hashMap)
+	for i := 0; i < ringLength; i++ {
+		if i > 0 {
+			pos = m.nextKey(pos)
+		}
+		if !visit(pos, m.names[m.hashMap[pos]]) {
+			return
+		}
+	}
+}
+
 // Gets the closest item in the hash to the provided key.
 func (m *Map) Get(key string) string {
-	if m.IsEmpty() {
+	if m.stats != nil {
+		defer m.stats.get.observeSince(time.Now())
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.get(key)
+}
+
+// Gets the closest item to the provided key, like Get, but returns
+// ErrEmptyRing if there are no items, or ErrNotEnoughNodes if none of
+// them is available.
+func (m *Map) GetE(key string) (string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.isEmpty() {
+		return "", ErrEmptyRing
+	}
+	if res := m.get(key); res != "" {
+		return res, nil
+	}
+	return "", ErrNotEnoughNodes
+}
+
+// Gets the N closest items to the provided key, like GetN, but returns
+// ErrEmptyRing if there are no items, or the items found along with
+// ErrNotEnoughNodes if fewer than n items are available and accepted.
+//
+// The accept function must not call back into the hash, see GetN.
+func (m *Map) GetNE(key string, n int, accept func([]string, string) bool) ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.isEmpty() {
+		return []string{}, ErrEmptyRing
+	}
+	res := m.getN(key, n, accept)
+	if len(res) < n {
+		if limiting := m.limitingConstraints(m.hashKey(key), n, accept); len(limiting) > 0 {
+			return res, fmt.Errorf("%w: found %d of %d, limited by constraints: %s", ErrNotEnoughNodes, len(res), n, limiting)
+		}
+		return res, ErrNotEnoughNodes
+	}
+	return res, nil
+}
+
+func (m *Map) get(key string) string {
+	return m.getHash(m.hashKey(key))
+}
+
+func (m *Map) getHash(hash int) string {
+	if m.isEmpty() {
 		return ""
 	}
+	if m.breakers == nil && len(m.down) == 0 && !m.anyQuarantined() {
+		return m.ownerOf(hash)
+	}
+
+	out := ""
+	m.walk(hash, func(_ int, res string) bool {
+		if m.available(res) {
+			out = res
+		}
+		return out == ""
+	})
+	return out
+}
+
+// Gets the positions of the virtual nodes of an item with the provided
+// weight on the ring.
+func (m *Map) vnodePositions(key string, weight int) []int {
+	positions := make([]int, 0, max(weight, 0))
+	if m.doubleHash {
+		h1, h2 := m.doubleHashes(key)
+		for i := 0; i < weight; i++ {
+			positions = append(positions, int(h1+uint32(i)*h2))
+		}
+		return positions
+	}
+
+	if m.label != nil {
+		for i := 0; i < weight; i++ {
+			positions = append(positions, m.hashString(m.label(key, i)))
+		}
+		return positions
+	}
+
+	// Build the default labels in a scratch buffer rather than as strings,
+	// so adding an item doesn't allocate per virtual node.
+	buf := make([]byte, 0, len(key)+20)
+	for i := 0; i < weight; i++ {
+		buf = append(strconv.AppendInt(buf[:0], int64(i), 10), key...)
+		positions = append(positions, m.hashBytes(buf))
+	}
+	return positions
+}
+
+// Gets the position of the provided key on the ring.
+func (m *Map) hashKey(key string) int {
+	return m.hashString(key)
+}
+
+func (m *Map) hashString(s string) int {
+	// Copy into a pooled buffer, as converting to []byte would allocate
+	// on every lookup.
+	p := scratchPool.Get().(*[]byte)
+	b := append((*p)[:0], s...)
+	h := m.hashBytes(b)
+	*p = b
+	scratchPool.Put(p)
+	return h
+}
+
+func (m *Map) hashBytes(b []byte) int {
+	h := m.hash(b)
+	if m.seeded {
+		h = fmix32(h ^ m.seed)
+	}
+	return int(h)
+}
 
-	hash := int(m.hash([]byte(key)))
-	return m.hashMap[m.getKeyFromHash(hash)]
+// Gets the item that owns the provided position on the ring.
+func (m *Map) ownerOf(hash int) string {
+	return m.names[m.hashMap[m.getKeyFromHash(hash)]]
+}
+
+// Returns a copy of the ring that shares no mutable state with the
+// original. The caller must hold at least a read lock.
+func (m *Map) clone() *Map {
+	c := &Map{
+		hash:          m.hash,
+		defaultWeight: m.defaultWeight,
+		keys:          make([]int, len(m.keys)),
+		hashMap:       make(map[int]int32, len(m.hashMap)),
+		contenders:    make(map[int][]int32, len(m.contenders)),
+		names:         append([]string(nil), m.names...),
+		free:          append([]int32(nil), m.free...),
+		entries:       make(map[string]*entry, len(m.entries)),
+		loads:         m.loads,
+		latencies:     m.latencies,
+		breakers:      m.breakers,
+		constraints:   m.constraints,
+		placement:     m.placement,
+		replicaRand:   m.replicaRand,
+		health:        m.health,
+		down:          m.down,
+		quarantined:   m.quarantined,
+		quarantineEnd: m.quarantineEnd,
+		direction:     m.direction,
+		label:         m.label,
+		doubleHash:    m.doubleHash,
+		seeded:        m.seeded,
+		seed:          m.seed,
+		compat:        m.compat,
+		logger:        m.logger,
+		stats:         m.stats,
+	}
+	copy(c.keys, m.keys)
+	for k, v := range m.hashMap {
+		c.hashMap[k] = v
+	}
+	for k, v := range m.contenders {
+		c.contenders[k] = v
+	}
+	for k, v := range m.entries {
+		c.entries[k] = v
+	}
+	return c
+}
+
+// Replaces the ring state with the state of another ring, typically one
+// obtained through clone(). The caller must hold the write lock.
+func (m *Map) swap(other *Map) {
+	m.keys = other.keys
+	m.hashMap = other.hashMap
+	m.contenders = other.contenders
+	m.names = other.names
+	m.free = other.free
+	m.entries = other.entries
+	m.down = other.down
 }
 
 // Gets the key used in the hashmap based on the provided hash.
 func (m *Map) getKeyFromHash(hash int) int {
+	if m.direction == Counterclockwise {
+		// Binary search for the first replica past the hash, the one
+		// before it is the one we're looking for.
+		idx := sort.Search(len(m.keys), func(i int) bool { return m.keys[i] > hash })
+
+		// Means we have cycled back to the last replica.
+		if idx == 0 {
+			idx = len(m.keys)
+		}
+
+		return m.keys[idx-1]
+	}
+
 	// Binary search for appropriate replica.
 	idx := sort.Search(len(m.keys), func(i int) bool { return m.keys[i] >= hash })
 
@@ -186,6 +660,14 @@ func (m *Map) getKeyFromHash(hash int) int {
 	return m.keys[idx]
 }
 
+// Gets the key of the replica following the provided one in ring order.
+func (m *Map) nextKey(key int) int {
+	if m.direction == Counterclockwise {
+		return m.getKeyFromHash(key - 1)
+	}
+	return m.getKeyFromHash(key + 1)
+}
+
 // Accepts any items when used as accept argument in GetN.
 func AcceptAny([]string, string) bool { return true }
 
@@ -198,3 +680,31 @@ func AcceptUnique(stack []string, found string) bool {
 	}
 	return true
 }
+
+// Accepts an item as long as it was accepted fewer than k times, when
+// used as accept argument in GetN. This allows replicas to double up on
+// the same item only in small clusters.
+func AcceptAtMost(k int) func([]string, string) bool {
+	return func(stack []string, found string) bool {
+		count := 0
+		for _, v := range stack {
+			if v == found {
+				count++
+			}
+		}
+		return count < k
+	}
+}
+
+// Accepts any items when used as accept argument in GetNValues.
+func AcceptAnyValue([]EntryValue, EntryValue) bool { return true }
+
+// Accepts only unique items when used as accept argument in GetNValues.
+func AcceptUniqueValue(stack []EntryValue, found EntryValue) bool {
+	for _, v := range stack {
+		if v.HashRingId() == found.HashRingId() {
+			return false
+		}
+	}
+	return true
+}
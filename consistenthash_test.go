@@ -207,11 +207,69 @@ func TestDeleteFromRing(t *testing.T) {
 	}
 }
 
+func TestCollidingPositions(t *testing.T) {
+	// The labels "010" and "0010" of both items hash to position 10.
+	for _, order := range [][]string{{"10", "010"}, {"010", "10"}} {
+		hash := New(1, intHash)
+		hash.AddString(order...)
+
+		if len(hash.keys) != 1 {
+			t.Errorf("Expected the shared position to appear once, but got: %v", hash.keys)
+		}
+		if res := hash.Get("5"); res != "010" {
+			t.Errorf("Expected 010 to win the position after adding %v, but got: %s", order, res)
+		}
+		if err := hash.Validate(); err != nil {
+			t.Errorf("Unexpected error: %s", err)
+		}
+
+		hash.Del("010")
+		if res := hash.Get("5"); res != "10" || len(hash.keys) != 1 {
+			t.Errorf("Expected 10 to take over the position, but got: %s, %v", res, hash.keys)
+		}
+		if err := hash.Validate(); err != nil {
+			t.Errorf("Unexpected error: %s", err)
+		}
+
+		hash.Del("10")
+		if !hash.IsEmpty() || len(hash.hashMap) != 0 || len(hash.contenders) != 0 {
+			t.Errorf("Expected empty ring, but got: %v, %v", hash.keys, hash.contenders)
+		}
+	}
+
+	hash := New(1, intHash)
+	hash.AddString("010", "10", "20")
+	hash.Del("10")
+	if res := hash.Get("5"); res != "010" || len(hash.contenders) != 0 {
+		t.Errorf("Expected 010 to keep the position after removing 10, but got: %s, %v", res, hash.contenders)
+	}
+	if err := hash.Validate(); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+	}
+}
+
 func BenchmarkGet8(b *testing.B)   { benchmarkGet(b, 8) }
 func BenchmarkGet32(b *testing.B)  { benchmarkGet(b, 32) }
 func BenchmarkGet128(b *testing.B) { benchmarkGet(b, 128) }
 func BenchmarkGet512(b *testing.B) { benchmarkGet(b, 512) }
 
+func BenchmarkGetNZoneAware(b *testing.B)    { benchmarkGetN(b, ZoneAwareStrategy{Label: "zone"}) }
+func BenchmarkGetNProportional(b *testing.B) { benchmarkGetN(b, ProportionalStrategy{}) }
+
+func benchmarkGetN(b *testing.B, strategy PlacementStrategy) {
+	hash := New(50, nil)
+	for i := 0; i < 128; i++ {
+		hash.AddWithWeight(&Node{ID: fmt.Sprintf("shard-%d", i), Tags: map[string]string{"zone": strconv.Itoa(i % 3)}}, 50)
+	}
+	hash.SetPlacementStrategy(strategy)
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		hash.GetN(strconv.Itoa(i), 3, nil)
+	}
+}
+
 func benchmarkGet(b *testing.B, shards int) {
 
 	hash := New(50, nil)
@@ -229,3 +287,102 @@ func benchmarkGet(b *testing.B, shards int) {
 		hash.Get(buckets[i&(shards-1)])
 	}
 }
+
+func TestVnodePositionsAllocations(t *testing.T) {
+	hash := New(160, nil)
+	allocs := testing.AllocsPerRun(10, func() {
+		hash.vnodePositions("shard-1", 160)
+	})
+	if allocs > 2 {
+		t.Errorf("Expected at most 2 allocations for 160 virtual nodes, but got: %v", allocs)
+	}
+}
+
+func BenchmarkAddDel(b *testing.B) {
+	hash := New(160, nil)
+	hash.AddString("A", "B", "C")
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		hash.AddString("D")
+		hash.Del("D")
+	}
+}
+
+func TestGetNValues(t *testing.T) {
+	hash := New(2, intHash)
+	if res := hash.GetNValues("5", 1, nil); len(res) > 0 {
+		t.Errorf("Should not be able to get items from empty ring")
+	}
+
+	hash.AddWithWeight(&Node{ID: "10", Tags: map[string]string{"zone": "a"}}, 2)
+	hash.AddWithWeight(&Node{ID: "20", Tags: map[string]string{"zone": "a"}}, 2)
+	hash.AddWithWeight(&Node{ID: "30", Tags: map[string]string{"zone": "b"}}, 2)
+
+	// Only accept one item per zone.
+	res := hash.GetNValues("5", 2, func(stack []EntryValue, found EntryValue) bool {
+		for _, v := range stack {
+			if v.(*Node).Tags["zone"] == found.(*Node).Tags["zone"] {
+				return false
+			}
+		}
+		return true
+	})
+	if len(res) != 2 || res[0].HashRingId() != "10" || res[1].HashRingId() != "30" {
+		t.Errorf("Expected one item per zone, but got: %v", res)
+	}
+
+	if res := hash.GetNValues("5", 5, AcceptUniqueValue); len(res) != 3 {
+		t.Errorf("Expected 3 unique items, but got: %d", len(res))
+	}
+}
+
+func TestAcceptAtMost(t *testing.T) {
+	hash := New(3, intHash)
+	hash.AddString("10", "20")
+
+	res := hash.GetN("5", 4, AcceptAtMost(2))
+	if len(res) != 4 {
+		t.Fatalf("Expected 4 items, but got: %v", res)
+	}
+
+	counts := map[string]int{}
+	for _, v := range res {
+		counts[v]++
+	}
+	if counts["10"] != 2 || counts["20"] != 2 {
+		t.Errorf("Expected every item at most twice, but got: %v", res)
+	}
+
+	if res := hash.GetN("5", 4, AcceptAtMost(1)); len(res) != 2 {
+		t.Errorf("Expected 2 unique items, but got: %v", res)
+	}
+}
+
+func TestGetE(t *testing.T) {
+	hash := New(1, intHash)
+	if _, err := hash.GetE("15"); err != ErrEmptyRing {
+		t.Errorf("Expected ErrEmptyRing, but got: %v", err)
+	}
+	if res, err := hash.GetNE("15", 2, nil); err != ErrEmptyRing || len(res) != 0 {
+		t.Errorf("Expected ErrEmptyRing, but got: %v, %v", res, err)
+	}
+
+	hash.AddString("10", "20")
+	if res, err := hash.GetE("15"); res != "20" || err != nil {
+		t.Errorf("Expected 20, but got: %s, %v", res, err)
+	}
+	if res, err := hash.GetNE("15", 2, AcceptUnique); err != nil || len(res) != 2 {
+		t.Errorf("Expected 2 items, but got: %v, %v", res, err)
+	}
+	if res, err := hash.GetNE("15", 3, AcceptUnique); err != ErrNotEnoughNodes || len(res) != 2 {
+		t.Errorf("Expected ErrNotEnoughNodes along with 2 items, but got: %v, %v", res, err)
+	}
+
+	hash.MarkDown("10")
+	hash.MarkDown("20")
+	if _, err := hash.GetE("15"); err != ErrNotEnoughNodes {
+		t.Errorf("Expected ErrNotEnoughNodes, but got: %v", err)
+	}
+}
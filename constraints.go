@@ -0,0 +1,145 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Limits the number of replicas returned by a single lookup that share
+// an item, or that share the value of a label.
+type Constraint struct {
+	// The label whose values are limited, or empty to limit the number of
+	// replicas per item.
+	Label string
+	Max   int
+}
+
+// Returns a constraint that allows at most k replicas per item.
+func MaxReplicasPerNode(k int) Constraint {
+	return Constraint{Max: k}
+}
+
+// Returns a constraint that allows at most k replicas per value of label,
+// e.g. MaxReplicasPerLabel("zone", 1) to spread replicas over zones.
+// Items without the label are not limited.
+func MaxReplicasPerLabel(label string, k int) Constraint {
+	return Constraint{Label: label, Max: k}
+}
+
+func (c Constraint) String() string {
+	if c.Label == "" {
+		return fmt.Sprintf("at most %d per node", c.Max)
+	}
+	return fmt.Sprintf("at most %d per %s", c.Max, c.Label)
+}
+
+// A set of constraints that must all be satisfied.
+type Constraints []Constraint
+
+func (cs Constraints) String() string {
+	out := make([]string, len(cs))
+	for i, c := range cs {
+		out[i] = c.String()
+	}
+	return strings.Join(out, ", ")
+}
+
+// Sets the constraints that GetN and its variants (GetNE, GetNInto,
+// GetNPooled and GetNByHash) enforce on top of their accept function.
+// GetNE reports which constraints prevented it from finding enough
+// replicas. Passing no constraints removes them.
+func (m *Map) SetConstraints(constraints ...Constraint) error {
+	for _, c := range constraints {
+		if c.Max < 1 {
+			return fmt.Errorf("Invalid constraint, must allow at least 1 replica: %s", c)
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(constraints) == 0 {
+		m.constraints = nil
+		return nil
+	}
+	m.constraints = append(Constraints(nil), constraints...)
+	return nil
+}
+
+// Returns the constraints set on the hash.
+func (m *Map) Constraints() Constraints {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append(Constraints(nil), m.constraints...)
+}
+
+// Returns the index of the first constraint that picking found in
+// addition to picked would violate, or -1. The caller must hold at least
+// a read lock.
+func (m *Map) violated(picked []string, found string) int {
+	for i, c := range m.constraints {
+		if c.Label == "" {
+			count := 0
+			for _, v := range picked {
+				if v == found {
+					count++
+				}
+			}
+			if count >= c.Max {
+				return i
+			}
+			continue
+		}
+
+		value, exists := m.entries[found].labels[c.Label]
+		if !exists {
+			continue
+		}
+		count := 0
+		for _, v := range picked {
+			if other, exists := m.entries[v].labels[c.Label]; exists && other == value {
+				count++
+			}
+		}
+		if count >= c.Max {
+			return i
+		}
+	}
+	return -1
+}
+
+// Repeats a lookup and returns the constraints that rejected candidates.
+// The caller must hold at least a read lock.
+func (m *Map) limitingConstraints(hash, n int, accept func([]string, string) bool) Constraints {
+	if len(m.constraints) == 0 {
+		return nil
+	}
+
+	rejected := make(map[int]bool)
+	m.place(nil, hash, n, accept, func(i int) {
+		rejected[i] = true
+	})
+
+	var out Constraints
+	for i, c := range m.constraints {
+		if rejected[i] {
+			out = append(out, c)
+		}
+	}
+	return out
+}
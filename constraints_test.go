@@ -0,0 +1,64 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestConstraints(t *testing.T) {
+	hash := New(2, intHash)
+	for key, zone := range map[string]string{"10": "a", "20": "a", "30": "b", "40": "c"} {
+		hash.AddWithWeight(&Node{ID: key, Tags: map[string]string{"zone": zone}}, 2)
+	}
+	hash.AddString("50")
+
+	if err := hash.SetConstraints(MaxReplicasPerNode(0)); err == nil {
+		t.Errorf("Expected error for a constraint that allows no replicas")
+	}
+	if err := hash.SetConstraints(MaxReplicasPerNode(1), MaxReplicasPerLabel("zone", 1)); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if cs := hash.Constraints(); cs.String() != "at most 1 per node, at most 1 per zone" {
+		t.Errorf("Unexpected constraints: %s", cs)
+	}
+
+	// Positions are 10, 20, 30, 40, 50 and 110, 120, ... for the second
+	// virtual node. 20 is skipped for sharing zone a with 10.
+	expected := []string{"10", "30", "40", "50"}
+	if nodes := hash.GetN("5", 4, nil); !reflect.DeepEqual(nodes, expected) {
+		t.Errorf("Expected %v, but got: %v", expected, nodes)
+	}
+
+	nodes, err := hash.GetNE("5", 5, nil)
+	if !errors.Is(err, ErrNotEnoughNodes) || !reflect.DeepEqual(nodes, expected) {
+		t.Errorf("Expected ErrNotEnoughNodes along with %v, but got: %v, %v", expected, nodes, err)
+	}
+	if err != nil && err.Error() != "Not enough nodes available: found 4 of 5, limited by constraints: at most 1 per node, at most 1 per zone" {
+		t.Errorf("Unexpected error: %s", err)
+	}
+
+	hash.SetConstraints()
+	if nodes := hash.GetN("5", 6, nil); len(nodes) != 6 {
+		t.Errorf("Expected constraints to be removed, but got: %v", nodes)
+	}
+	if _, err := hash.GetNE("5", 20, AcceptUnique); err != ErrNotEnoughNodes {
+		t.Errorf("Expected plain ErrNotEnoughNodes without constraints, but got: %v", err)
+	}
+}
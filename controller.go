@@ -0,0 +1,121 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"time"
+)
+
+// Adjusts the weights of items to equalize the load reported by the load
+// reporter of the hash. Every step moves the weight of each item towards
+// weight * mean load / item load, by at most maxStep of its current
+// weight, and within the bounds of minWeight and maxWeight. Items whose
+// load is off the mean by more than the deadband change by at least 1,
+// so small weights converge, and weights never drop below 1. Items with
+// explicit tokens are left alone.
+type WeightController struct {
+	m         *Map
+	minWeight int
+	maxWeight int
+	maxStep   float64
+	deadband  float64
+}
+
+// Creates a weight controller for the hash. The maximum step is a
+// fraction of the current weight, e.g. 0.1 to change weights by at most
+// 10% per step.
+func NewWeightController(m *Map, minWeight, maxWeight int, maxStep float64) *WeightController {
+	return &WeightController{m: m, minWeight: minWeight, maxWeight: maxWeight, maxStep: maxStep, deadband: 0.05}
+}
+
+// Sets the deadband, as a fraction of the mean load: items whose load is
+// within it of the mean keep their weight. The default is 0.05.
+func (c *WeightController) SetDeadband(deadband float64) {
+	c.deadband = deadband
+}
+
+// Adjusts the weights once, and returns the changes that were applied.
+func (c *WeightController) Step() ([]Change, error) {
+	c.m.mu.RLock()
+	if c.m.loads == nil {
+		c.m.mu.RUnlock()
+		return nil, errors.New("No load reporter set")
+	}
+	keys := make([]string, 0, len(c.m.entries))
+	weights := make(map[string]int, len(c.m.entries))
+	loads := make(map[string]float64, len(c.m.entries))
+	total := 0.0
+	for key, e := range c.m.entries {
+		if e.explicit {
+			continue
+		}
+		keys = append(keys, key)
+		weights[key] = e.weight
+		loads[key] = c.m.loads.Load(key)
+		total += loads[key]
+	}
+	c.m.mu.RUnlock()
+
+	if len(keys) == 0 || total <= 0 {
+		return nil, nil
+	}
+	sort.Strings(keys)
+	mean := total / float64(len(keys))
+
+	var changes []Change
+	for _, key := range keys {
+		if math.Abs(loads[key]-mean) <= c.deadband*mean {
+			continue
+		}
+		ratio := 1 + c.maxStep
+		if loads[key] > 0 {
+			ratio = math.Max(1-c.maxStep, math.Min(1+c.maxStep, mean/loads[key]))
+		}
+		weight := int(math.Round(float64(weights[key]) * ratio))
+		// Rounding would keep small weights as they are forever.
+		switch {
+		case weight == weights[key] && loads[key] < mean:
+			weight++
+		case weight == weights[key]:
+			weight--
+		}
+		weight = max(1, c.minWeight, min(c.maxWeight, weight))
+		if weight != weights[key] {
+			changes = append(changes, WeightChange(key, weight))
+		}
+	}
+	if len(changes) == 0 {
+		return nil, nil
+	}
+	if err := c.m.Apply(changes...); err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
+
+// Calls Step every interval until ctx is done, reporting failed steps to
+// onError unless it is nil.
+func (c *WeightController) Run(ctx context.Context, interval time.Duration, onError func(error)) error {
+	return runEvery(ctx, interval, func() error {
+		_, err := c.Step()
+		return err
+	}, onError)
+}
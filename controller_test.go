@@ -0,0 +1,90 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWeightController(t *testing.T) {
+	hash := New(100, nil)
+	hash.AddString("A", "B", "C")
+	hash.AddWithTokens(&StringValue{"D"}, []uint32{1, 2, 3})
+
+	c := NewWeightController(hash, 50, 120, 0.1)
+	if _, err := c.Step(); err == nil {
+		t.Errorf("Expected error without a load reporter")
+	}
+
+	loads := map[string]float64{"A": 2, "B": 1, "C": 0, "D": 100}
+	hash.SetLoadReporter(LoadReporterFunc(func(key string) float64 { return loads[key] }))
+
+	// Mean load is 1: A is overloaded, C is idle.
+	changes, err := c.Step()
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	expected := []Change{WeightChange("A", 90), WeightChange("C", 110)}
+	if !reflect.DeepEqual(changes, expected) {
+		t.Errorf("Expected %v, but got: %v", expected, changes)
+	}
+
+	c.Step()
+	changes, _ = c.Step()
+	if expected := []Change{WeightChange("A", 73)}; !reflect.DeepEqual(changes, expected) {
+		t.Errorf("Expected weights to be bounded by the maximum weight, but got: %v", changes)
+	}
+	if len(hash.entries["C"].positions) != 120 {
+		t.Errorf("Expected C to be at the maximum weight, but got: %d", len(hash.entries["C"].positions))
+	}
+
+	loads = map[string]float64{"A": 1, "B": 1, "C": 1}
+	if changes, _ := c.Step(); changes != nil {
+		t.Errorf("Expected no changes for a balanced ring, but got: %v", changes)
+	}
+}
+
+func TestWeightControllerSmallWeights(t *testing.T) {
+	hash := New(1, nil)
+	hash.AddStringWithWeight("A", 1)
+	hash.AddStringWithWeight("B", 3)
+	hash.AddStringWithWeight("C", 2)
+
+	loads := map[string]float64{"A": 0.5, "B": 1.5, "C": 1.02}
+	hash.SetLoadReporter(LoadReporterFunc(func(key string) float64 { return loads[key] }))
+
+	// A 10% step rounds back to the same weight, so the weights move by 1
+	// instead, and never drop to 0. C is within the deadband.
+	c := NewWeightController(hash, 0, 10, 0.1)
+	changes, err := c.Step()
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	expected := []Change{WeightChange("A", 2), WeightChange("B", 2)}
+	if !reflect.DeepEqual(changes, expected) {
+		t.Errorf("Expected %v, but got: %v", expected, changes)
+	}
+
+	loads = map[string]float64{"A": 2, "B": 1, "C": 1}
+	for i := 0; i < 3; i++ {
+		c.Step()
+	}
+	if weight := hash.entries["A"].weight; weight != 1 {
+		t.Errorf("Expected the weight of A to stop at 1, but got: %d", weight)
+	}
+}
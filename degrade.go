@@ -0,0 +1,166 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// Scores the health of an item between 0 (failing) and 1 (healthy), e.g.
+// 0.5 when it responds slowly.
+type HealthScorer interface {
+	Score(ctx context.Context, value EntryValue) float64
+}
+
+// A HealthScorer backed by a function.
+type HealthScorerFunc func(ctx context.Context, value EntryValue) float64
+
+func (f HealthScorerFunc) Score(ctx context.Context, value EntryValue) float64 {
+	return f(ctx, value)
+}
+
+// Scales the weight of members by their health score, instead of
+// treating them as either up or down, so a struggling member receives
+// proportionally less traffic. A lower score takes effect right away,
+// while a higher score is approached by at most recoveryStep per check,
+// so traffic returns gradually. Members are never scaled below one
+// virtual node; mark them down for that. Members with explicit tokens
+// are left alone.
+type HealthDegrader struct {
+	m            *Map
+	scorer       HealthScorer
+	recoveryStep float64
+	mu           sync.Mutex
+	degraded     map[string]*degradedMember
+}
+
+type degradedMember struct {
+	base    int     // The weight of the member when it is healthy.
+	applied int     // The weight last set by the degrader.
+	factor  float64 // The fraction of base currently in effect.
+}
+
+// Creates a degrader for the hash. The recovery step is the fraction of
+// the full weight that is restored per check, e.g. 0.1 to take ten
+// healthy checks to recover from a score of 0.
+func NewHealthDegrader(m *Map, scorer HealthScorer, recoveryStep float64) *HealthDegrader {
+	return &HealthDegrader{m: m, scorer: scorer, recoveryStep: recoveryStep, degraded: make(map[string]*degradedMember)}
+}
+
+// Reports the health score of a member, and adjusts its weight.
+func (d *HealthDegrader) Report(key string, score float64) error {
+	if math.IsNaN(score) {
+		return fmt.Errorf("Invalid health score: %v", score)
+	}
+	score = math.Max(0, math.Min(1, score))
+
+	d.m.mu.RLock()
+	e, exists := d.m.entries[key]
+	weight, explicit := 0, false
+	if exists {
+		weight, explicit = e.weight, e.explicit
+	}
+	d.m.mu.RUnlock()
+	if !exists {
+		d.Forget(key)
+		return fmt.Errorf("No node with name '%s' found", key)
+	}
+	if explicit {
+		return nil
+	}
+
+	d.mu.Lock()
+	member, tracked := d.degraded[key]
+	if !tracked || member.applied != weight {
+		// The weight was changed by someone else, which becomes the new
+		// healthy weight.
+		member = &degradedMember{base: weight, applied: weight, factor: 1}
+		d.degraded[key] = member
+	}
+	if score < member.factor {
+		member.factor = score
+	} else {
+		member.factor = math.Min(score, member.factor+d.recoveryStep)
+	}
+	target := max(1, int(math.Round(float64(member.base)*member.factor)))
+	if member.factor >= 1 {
+		delete(d.degraded, key)
+		target = member.base
+	}
+	changed := target != member.applied
+	member.applied = target
+	d.mu.Unlock()
+
+	if !changed {
+		return nil
+	}
+	if err := d.m.Apply(WeightChange(key, target)); err != nil {
+		d.Forget(key)
+		return err
+	}
+	d.m.currentLogger().Info("Node weight adjusted to health", "node", key, "weight", target, "score", score)
+	return nil
+}
+
+// Returns the fraction of its healthy weight a member currently has.
+func (d *HealthDegrader) Factor(key string) float64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if member, tracked := d.degraded[key]; tracked {
+		return member.factor
+	}
+	return 1
+}
+
+// Stops tracking a member, without restoring its weight.
+func (d *HealthDegrader) Forget(key string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.degraded, key)
+}
+
+// Scores every member of the hash and adjusts their weights.
+func (d *HealthDegrader) Check(ctx context.Context) error {
+	d.m.mu.RLock()
+	values := make([]EntryValue, 0, len(d.m.entries))
+	for _, e := range d.m.entries {
+		values = append(values, e.value)
+	}
+	d.m.mu.RUnlock()
+
+	var errs []error
+	for _, value := range values {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := d.Report(value.HashRingId(), d.scorer.Score(ctx, value)); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Calls Check every interval until ctx is done. Members that could not
+// be adjusted are reported to onError, if set.
+func (d *HealthDegrader) Run(ctx context.Context, interval time.Duration, onError func(error)) error {
+	return runEvery(ctx, interval, func() error { return d.Check(ctx) }, onError)
+}
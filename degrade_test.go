@@ -0,0 +1,74 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"context"
+	"testing"
+)
+
+func TestHealthDegrader(t *testing.T) {
+	hash := New(10, nil)
+	hash.AddString("A", "B")
+	hash.AddWithTokens(&StringValue{"C"}, []uint32{1, 2})
+
+	scores := map[string]float64{"A": 1, "B": 0.5, "C": 0}
+	d := NewHealthDegrader(hash, HealthScorerFunc(func(_ context.Context, value EntryValue) float64 {
+		return scores[value.HashRingId()]
+	}), 0.2)
+	weight := func(key string) int {
+		tokens, _ := hash.Tokens(key)
+		return len(tokens)
+	}
+
+	if err := d.Check(context.Background()); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if weight("A") != 10 || weight("B") != 5 || d.Factor("B") != 0.5 || weight("C") != 2 {
+		t.Errorf("Expected B to be degraded to half its weight, but got: %d, %d, %d", weight("A"), weight("B"), weight("C"))
+	}
+
+	// A score of 0 leaves a single virtual node.
+	d.Report("B", 0)
+	if weight("B") != 1 {
+		t.Errorf("Expected B to keep one virtual node, but got: %d", weight("B"))
+	}
+
+	// Recovery is gradual.
+	scores["B"] = 1
+	for _, expected := range []int{2, 4, 6, 8, 10, 10} {
+		d.Check(context.Background())
+		if weight("B") != expected {
+			t.Errorf("Expected B to recover to weight %d, but got: %d", expected, weight("B"))
+		}
+	}
+	if d.Factor("B") != 1 {
+		t.Errorf("Expected B to be fully recovered, but got: %v", d.Factor("B"))
+	}
+
+	// A weight change by someone else becomes the new healthy weight.
+	d.Report("A", 0.5)
+	hash.Apply(WeightChange("A", 20))
+	d.Report("A", 0.5)
+	if weight("A") != 10 {
+		t.Errorf("Expected A to be degraded from its new weight, but got: %d", weight("A"))
+	}
+
+	if err := d.Report("D", 1); err == nil {
+		t.Errorf("Expected error for unknown item")
+	}
+}
@@ -0,0 +1,112 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"sync"
+)
+
+// Holds an old and a new ring during a resharding window. Lookups return
+// the assignment of both rings, so applications can write to both and
+// read-repair from the old owner, until the new ring is promoted.
+type DualRing struct {
+	mu   sync.RWMutex
+	old  *Map
+	next *Map
+}
+
+// Creates a dual ring that migrates from old to next.
+func NewDualRing(old, next *Map) *DualRing {
+	return &DualRing{old: old, next: next}
+}
+
+// Gets the closest item to the provided key in the old ring.
+func (d *DualRing) Get(key string) string {
+	return d.Old().Get(key)
+}
+
+// Gets the closest item to the provided key in both the old and the new
+// ring. Once the new ring is promoted both values are the same.
+func (d *DualRing) GetBoth(key string) (old, next string) {
+	d.mu.RLock()
+	oldRing, nextRing := d.old, d.next
+	d.mu.RUnlock()
+
+	old = oldRing.Get(key)
+	if nextRing == nil {
+		return old, old
+	}
+	return old, nextRing.Get(key)
+}
+
+// Gets the N closest items in both the old and the new ring, see
+// Map.GetN.
+func (d *DualRing) GetNBoth(key string, n int, accept func([]string, string) bool) (old, next []string) {
+	d.mu.RLock()
+	oldRing, nextRing := d.old, d.next
+	d.mu.RUnlock()
+
+	old = oldRing.GetN(key, n, accept)
+	if nextRing == nil {
+		return old, old
+	}
+	return old, nextRing.GetN(key, n, accept)
+}
+
+// Returns true if the key has a different owner in the new ring.
+func (d *DualRing) Moved(key string) bool {
+	old, next := d.GetBoth(key)
+	return old != next
+}
+
+// Returns the ring that is currently authoritative.
+func (d *DualRing) Old() *Map {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.old
+}
+
+// Returns the ring being migrated to, or nil if it was already promoted.
+func (d *DualRing) Next() *Map {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.next
+}
+
+// Atomically makes the new ring the authoritative one and ends the
+// migration. Returns false if there was no migration in progress.
+func (d *DualRing) Promote() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.next == nil {
+		return false
+	}
+	d.old, d.next = d.next, nil
+	return true
+}
+
+// Starts a new migration towards next. Returns false if a migration is
+// already in progress.
+func (d *DualRing) Migrate(next *Map) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.next != nil {
+		return false
+	}
+	d.next = next
+	return true
+}
@@ -0,0 +1,49 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"testing"
+)
+
+func TestDualRing(t *testing.T) {
+	old := New(1, intHash)
+	old.AddString("10", "30")
+	next := New(1, intHash)
+	next.AddString("10", "20", "30")
+
+	d := NewDualRing(old, next)
+	if o, n := d.GetBoth("15"); o != "30" || n != "20" {
+		t.Errorf("Entry 15 should map to 30 and 20, but instead got: %s and %s", o, n)
+	}
+	if !d.Moved("15") || d.Moved("5") {
+		t.Errorf("Only entry 15 should have moved")
+	}
+	if d.Migrate(New(1, nil)) {
+		t.Errorf("Should not start a migration while one is in progress")
+	}
+
+	if !d.Promote() {
+		t.Fatalf("Expected new ring to be promoted")
+	}
+	if o, n := d.GetBoth("15"); o != "20" || n != "20" {
+		t.Errorf("Entry 15 should map to 20 after promotion, but instead got: %s and %s", o, n)
+	}
+	if d.Next() != nil || d.Promote() {
+		t.Errorf("Expected no migration to be in progress")
+	}
+}
@@ -0,0 +1,59 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// Writes the ring as a table of arcs in ring order, with the position of
+// the virtual node that owns each arc, the first and last position of the
+// arc, its owner and its share of the ring. Owners that are marked down
+// are flagged.
+func (m *Map) Dump(w io.Writer) error {
+	var sb strings.Builder
+	tw := tabwriter.NewWriter(&sb, 0, 4, 2, ' ', 0)
+
+	m.mu.RLock()
+	fmt.Fprintf(tw, "# %d nodes, %d virtual nodes\n", len(m.entries), len(m.hashMap))
+	fmt.Fprintf(tw, "POSITION\tSTART\tEND\tOWNER\tSHARE\n")
+	m.arcs(func(owner string, start uint32, size uint64) {
+		pos, end := start+uint32(size-1), start+uint32(size-1)
+		if m.direction == Counterclockwise {
+			pos = start
+		}
+		if m.down[owner] {
+			owner += " (down)"
+		}
+		fmt.Fprintf(tw, "%d\t%d\t%d\t%s\t%.2f%%\n", pos, start, end, owner, float64(size)/ringSize*100)
+	})
+	m.mu.RUnlock()
+	tw.Flush()
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// Returns the ring as a table of arcs, see Dump.
+func (m *Map) String() string {
+	var sb strings.Builder
+	m.Dump(&sb)
+	return sb.String()
+}
@@ -0,0 +1,47 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"testing"
+)
+
+func TestDump(t *testing.T) {
+	hash := New(1, intHash)
+	hash.AddString("10")
+	hash.AddWithTokens(&StringValue{"B"}, []uint32{1 << 31})
+	hash.MarkDown("B")
+
+	expected := `# 2 nodes, 2 virtual nodes
+POSITION    START       END         OWNER     SHARE
+10          2147483649  10          10        50.00%
+2147483648  11          2147483648  B (down)  50.00%
+`
+	if s := hash.String(); s != expected {
+		t.Errorf("Expected:\n%s\nbut got:\n%s", expected, s)
+	}
+
+	hash = New(1, intHash, WithDirection(Counterclockwise))
+	hash.AddString("10")
+	expected = `# 1 nodes, 1 virtual nodes
+POSITION  START  END  OWNER  SHARE
+10        10     9    10     100.00%
+`
+	if s := hash.String(); s != expected {
+		t.Errorf("Expected:\n%s\nbut got:\n%s", expected, s)
+	}
+}
@@ -0,0 +1,157 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"errors"
+	"sync"
+)
+
+// Describes a successful modification of the hash.
+type ChangeEvent struct {
+	// The epoch of the hash after the changes were applied.
+	Epoch   uint64
+	Changes []Change
+	// The effect of the changes on the ring, if remaps are tracked.
+	Remap *RemapStat
+}
+
+type listener struct {
+	id int
+	fn func(ChangeEvent)
+}
+
+type listeners struct {
+	mu        sync.Mutex
+	cond      *sync.Cond
+	next      int
+	list      []listener
+	delivered uint64
+}
+
+// Registers a function that is called after every modification of the
+// hash, in the order the modifications were made. The function may read
+// from the hash and cancel itself, but must not modify it. The returned
+// function removes the listener again.
+func (m *Map) OnChange(fn func(ChangeEvent)) (cancel func()) {
+	l := &m.listeners
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// The list is copied on write, so events can be delivered from a
+	// copy without holding the lock.
+	l.next++
+	id := l.next
+	l.list = append(l.list[:len(l.list):len(l.list)], listener{id, fn})
+
+	return func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		for i, v := range l.list {
+			if v.id == id {
+				l.list = append(l.list[:i:i], l.list[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// Returns the epoch of the hash, which is incremented by every
+// modification. Restore also raises it past the epoch of the snapshot.
+func (m *Map) Epoch() uint64 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.epoch
+}
+
+// Runs fn with the write lock held and, if it succeeds, bumps the epoch
+// and notifies listeners of the changes.
+func (m *Map) mutate(changes []Change, fn func() error) error {
+	_, err := m.mutateEpoch(changes, fn)
+	return err
+}
+
+// Like mutate, but also returns the epoch of the hash after the changes.
+func (m *Map) mutateEpoch(changes []Change, fn func() error) (uint64, error) {
+	return m.mutatePlanned(func() ([]Change, error) { return changes, fn() })
+}
+
+// Returned by the function passed to mutatePlanned if it left the hash
+// as it was.
+var errUnchanged = errors.New("Unchanged")
+
+// Like mutateEpoch, but fn returns the changes it made, for changes that
+// are planned with the write lock held. If fn returns errUnchanged, the
+// epoch is not bumped and no error is returned.
+func (m *Map) mutatePlanned(fn func() ([]Change, error)) (uint64, error) {
+	m.mu.Lock()
+	var prev *Map
+	if m.remaps != nil {
+		prev = m.clone()
+	}
+	// fn may raise the epoch (see Restore), so listeners wait for the
+	// epoch the hash was at rather than for the one before the event.
+	after := m.epoch
+	changes, err := fn()
+	if err != nil {
+		epoch := m.epoch
+		m.mu.Unlock()
+		if err == errUnchanged {
+			return epoch, nil
+		}
+		return 0, err
+	}
+	m.epoch++
+	event := ChangeEvent{Epoch: m.epoch, Changes: changes}
+	if prev != nil {
+		event.Remap = m.remaps.record(prev, m, event)
+	}
+	logger := m.log()
+	m.mu.Unlock()
+
+	logChanges(logger, event.Epoch, changes)
+
+	m.listeners.deliver(after, event)
+	return event.Epoch, nil
+}
+
+// Calls the listeners with the event once the event of epoch after has
+// been delivered, so listeners see the changes in the same order as
+// they were made. The listeners are called without holding the lock, so
+// they may cancel themselves, and the next epoch gets its turn even if
+// one of them panics.
+func (l *listeners) deliver(after uint64, event ChangeEvent) {
+	l.mu.Lock()
+	if l.cond == nil {
+		l.cond = sync.NewCond(&l.mu)
+	}
+	for l.delivered != after {
+		l.cond.Wait()
+	}
+	list := l.list
+	l.mu.Unlock()
+
+	defer func() {
+		l.mu.Lock()
+		l.delivered = event.Epoch
+		l.cond.Broadcast()
+		l.mu.Unlock()
+	}()
+	for _, v := range list {
+		v.fn(event)
+	}
+}
@@ -0,0 +1,84 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"testing"
+)
+
+func TestOnChange(t *testing.T) {
+	hash := New(1, intHash)
+
+	var events []ChangeEvent
+	cancel := hash.OnChange(func(event ChangeEvent) {
+		events = append(events, event)
+	})
+
+	hash.AddString("10")
+	hash.Apply(AddChange(&StringValue{"20"}, 1), RemoveChange("10"))
+	hash.Del("30")
+
+	if len(events) != 2 {
+		t.Fatalf("Expected 2 events, but got: %v", events)
+	}
+	if events[0].Epoch != 1 || events[1].Epoch != 2 || hash.Epoch() != 2 {
+		t.Errorf("Expected events for epoch 1 and 2, but got: %v", events)
+	}
+	if len(events[1].Changes) != 2 || events[1].Changes[1].Op != ChangeRemove {
+		t.Errorf("Expected event to contain the applied changes, but got: %v", events[1])
+	}
+
+	cancel()
+	hash.AddString("30")
+	if len(events) != 2 {
+		t.Errorf("Expected no events after cancelling")
+	}
+}
+
+func TestOnChangeCancelFromListener(t *testing.T) {
+	hash := New(1, intHash)
+
+	calls := 0
+	var cancel func()
+	cancel = hash.OnChange(func(ChangeEvent) {
+		calls++
+		cancel()
+	})
+	hash.AddString("10")
+	hash.AddString("20")
+	if calls != 1 {
+		t.Errorf("Expected listener to be called once, but got: %d", calls)
+	}
+}
+
+func TestOnChangePanickingListener(t *testing.T) {
+	hash := New(1, intHash)
+
+	cancel := hash.OnChange(func(ChangeEvent) { panic("listener") })
+	func() {
+		defer func() { recover() }()
+		hash.AddString("10")
+	}()
+	cancel()
+
+	var epochs []uint64
+	hash.OnChange(func(event ChangeEvent) { epochs = append(epochs, event.Epoch) })
+	hash.AddString("20")
+	if len(epochs) != 1 || epochs[0] != 2 {
+		t.Errorf("Expected the next event to be delivered, but got: %v", epochs)
+	}
+}
@@ -0,0 +1,115 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"fmt"
+)
+
+// A single candidate considered while selecting replicas.
+type ExplainStep struct {
+	Position uint32 // The position of the virtual node of the candidate.
+	Node     string
+	Accepted bool
+	Reason   string
+}
+
+// Describes how the replicas for a key were selected.
+type Explanation struct {
+	Key      string
+	Hash     uint32
+	Replicas []string
+	// The candidates in the order they were considered.
+	Steps []ExplainStep
+}
+
+// Selects replicas like GetN, and returns every candidate that was
+// considered along with whether and why it was accepted or rejected.
+// This helps to find out why a key ended up on unexpected replicas.
+//
+// The accept function must not call back into the hash, see GetN.
+func (m *Map) ExplainGetN(key string, n int, accept func([]string, string) bool) *Explanation {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	hash := m.hashKey(key)
+	e := &Explanation{Key: key, Hash: uint32(hash), Replicas: []string{}}
+	if m.isEmpty() || n < 1 {
+		return e
+	}
+	if accept == nil {
+		accept = AcceptAny
+	}
+
+	trace := &explainTrace{explanation: e, first: make(map[string]int)}
+	check := func(picked []string, found string) bool {
+		accepted, reason := true, "First replica"
+		if i := m.violated(picked, found); i >= 0 {
+			accepted, reason = false, fmt.Sprintf("Violates constraint: %s", m.constraints[i])
+		} else if len(picked) > 0 {
+			accepted = accept(picked, found)
+			reason = "Accepted"
+			if !accepted {
+				reason = "Rejected by accept function"
+			}
+		}
+		trace.step(found, accepted, reason)
+		return accepted
+	}
+
+	strategy := m.placement
+	if strategy == nil {
+		strategy = RingWalkStrategy{}
+	}
+	e.Replicas = strategy.Place(PlacementView{m: m, trace: trace}, e.Replicas, hash, n, check)
+	return e
+}
+
+// Records the candidates of a lookup for ExplainGetN.
+type explainTrace struct {
+	explanation *Explanation
+	// The position and owner visited last, and the first position at
+	// which every owner was visited.
+	current int
+	owner   string
+	first   map[string]int
+}
+
+func (t *explainTrace) visit(m *Map, pos int, owner string) {
+	t.current, t.owner = pos, owner
+	if _, seen := t.first[owner]; !seen {
+		t.first[owner] = pos
+	}
+
+	if m.down[owner] {
+		t.step(owner, false, "Marked down")
+	} else if m.isQuarantined(owner) {
+		t.step(owner, false, "Quarantined")
+	} else if !m.available(owner) {
+		t.step(owner, false, "Circuit breaker open")
+	}
+}
+
+func (t *explainTrace) step(node string, accepted bool, reason string) {
+	// Strategies that first collect candidates and then sort them pass
+	// them to accept after the walk, so fall back to the first position.
+	pos := t.current
+	if node != t.owner {
+		pos = t.first[node]
+	}
+	t.explanation.Steps = append(t.explanation.Steps, ExplainStep{Position: uint32(pos), Node: node, Accepted: accepted, Reason: reason})
+}
@@ -0,0 +1,62 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExplainGetN(t *testing.T) {
+	hash := New(1, intHash)
+	hash.AddWithWeight(&Node{ID: "10", Tags: map[string]string{"zone": "a"}}, 2)
+	hash.AddWithWeight(&Node{ID: "20", Tags: map[string]string{"zone": "a"}}, 1)
+	hash.AddWithWeight(&Node{ID: "30", Tags: map[string]string{"zone": "b"}}, 1)
+	hash.AddWithWeight(&Node{ID: "40", Tags: map[string]string{"zone": "c"}}, 1)
+	hash.SetConstraints(MaxReplicasPerLabel("zone", 1))
+	hash.MarkDown("30")
+
+	e := hash.ExplainGetN("5", 2, AcceptUnique)
+	expected := []ExplainStep{
+		{Position: 10, Node: "10", Accepted: true, Reason: "First replica"},
+		{Position: 20, Node: "20", Accepted: false, Reason: "Violates constraint: at most 1 per zone"},
+		{Position: 30, Node: "30", Accepted: false, Reason: "Marked down"},
+		{Position: 40, Node: "40", Accepted: true, Reason: "Accepted"},
+	}
+	if !reflect.DeepEqual(e.Steps, expected) {
+		t.Errorf("Expected %+v, but got: %+v", expected, e.Steps)
+	}
+	if replicas := hash.GetN("5", 2, AcceptUnique); e.Hash != 5 || !reflect.DeepEqual(e.Replicas, replicas) {
+		t.Errorf("Expected replicas %v, but got: %v", replicas, e.Replicas)
+	}
+
+	hash.SetConstraints()
+	hash.MarkUp("30")
+	e = hash.ExplainGetN("35", 3, AcceptUnique)
+	if len(e.Steps) != 4 || e.Steps[1].Position != 110 || e.Steps[2].Position != 10 || e.Steps[2].Reason != "Rejected by accept function" {
+		t.Errorf("Expected the second virtual node of 10 to be rejected, but got: %+v", e.Steps)
+	}
+
+	hash.SetPlacementStrategy(ZoneAwareStrategy{Label: "zone"})
+	e = hash.ExplainGetN("5", 4, nil)
+	if last := e.Steps[len(e.Steps)-1]; last.Node != "20" || last.Position != 20 || !last.Accepted {
+		t.Errorf("Expected 20 to be accepted last at its own position, but got: %+v", e.Steps)
+	}
+	if replicas := hash.GetN("5", 4, nil); !reflect.DeepEqual(e.Replicas, replicas) {
+		t.Errorf("Expected replicas %v, but got: %v", replicas, e.Replicas)
+	}
+}
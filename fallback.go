@@ -0,0 +1,45 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"errors"
+)
+
+// Calls try with successive distinct owners of the provided key, in ring
+// order, until it succeeds. If every owner fails the errors returned by
+// try are joined and returned. This encapsulates the common pattern of
+// retrying a request on the next replica.
+func (m *Map) GetWithFallback(key string, try func(node string) error) error {
+	m.mu.RLock()
+	candidates := m.getN(key, len(m.entries), AcceptUnique)
+	m.mu.RUnlock()
+
+	if len(candidates) == 0 {
+		return ErrEmptyRing
+	}
+
+	errs := make([]error, 0, len(candidates))
+	for _, node := range candidates {
+		err := try(node)
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,57 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestGetWithFallback(t *testing.T) {
+	hash := New(2, intHash)
+	if err := hash.GetWithFallback("5", func(string) error { return nil }); err != ErrEmptyRing {
+		t.Errorf("Expected ErrEmptyRing, but got: %v", err)
+	}
+
+	hash.AddString("10", "20", "30")
+
+	var tried []string
+	err := hash.GetWithFallback("5", func(node string) error {
+		tried = append(tried, node)
+		if node != "30" {
+			return errors.New("unavailable")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Errorf("Unexpected error: %s", err)
+	}
+	if !reflect.DeepEqual(tried, []string{"10", "20", "30"}) {
+		t.Errorf("Expected distinct owners to be tried in ring order, but got: %v", tried)
+	}
+
+	failure := errors.New("unavailable")
+	tried = nil
+	err = hash.GetWithFallback("5", func(node string) error {
+		tried = append(tried, node)
+		return failure
+	})
+	if !errors.Is(err, failure) || len(tried) != 3 {
+		t.Errorf("Expected all 3 owners to fail, but tried %v and got: %v", tried, err)
+	}
+}
@@ -0,0 +1,136 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"sort"
+	"sync"
+)
+
+// An owner of a key within a federation.
+type FederatedOwner struct {
+	DC    string
+	Owner string
+}
+
+// A federated topology of one ring per datacenter. Besides lookups in
+// the local ring, it provides a global view that behaves like a single
+// ring holding the members of all datacenters. For the global view to be
+// consistent, all rings must use the same hash function and options.
+type Federation struct {
+	local string
+	mu    sync.RWMutex
+	rings map[string]*Map
+}
+
+// Returns a federation in which local is the datacenter of the caller.
+func NewFederation(local string) *Federation {
+	return &Federation{local: local, rings: make(map[string]*Map)}
+}
+
+// Sets the ring of a datacenter. Passing a nil ring removes the
+// datacenter from the federation.
+func (f *Federation) SetRing(dc string, m *Map) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if m == nil {
+		delete(f.rings, dc)
+		return
+	}
+	f.rings[dc] = m
+}
+
+// Returns the ring of a datacenter, or nil if it is unknown.
+func (f *Federation) Ring(dc string) *Map {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.rings[dc]
+}
+
+// Returns the names of all datacenters, sorted.
+func (f *Federation) DCs() []string {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	out := make([]string, 0, len(f.rings))
+	for dc := range f.rings {
+		out = append(out, dc)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Gets the owner of key in the ring of the local datacenter.
+func (f *Federation) LocalOwner(key string) string {
+	if m := f.Ring(f.local); m != nil {
+		return m.Get(key)
+	}
+	return ""
+}
+
+// Gets the owner of key in the global view, which is the member of any
+// datacenter that is closest to the key. Returns an empty owner if all
+// rings are empty.
+func (f *Federation) GlobalOwner(key string) FederatedOwner {
+	var (
+		out  FederatedOwner
+		best uint32
+	)
+	for _, dc := range f.DCs() {
+		m := f.Ring(dc)
+		if m == nil {
+			continue
+		}
+
+		m.mu.RLock()
+		hash := m.hashKey(key)
+		m.walk(hash, func(pos int, owner string) bool {
+			if !m.available(owner) {
+				return true
+			}
+			distance := uint32(pos - hash)
+			if m.direction == Counterclockwise {
+				distance = uint32(hash - pos)
+			}
+			if out.Owner == "" || distance < best {
+				out, best = FederatedOwner{dc, owner}, distance
+			}
+			return false
+		})
+		m.mu.RUnlock()
+	}
+	return out
+}
+
+// Gets one owner of key in every datacenter, the local datacenter first
+// and the others sorted by name. Datacenters with empty rings are
+// omitted.
+func (f *Federation) OwnerPerDC(key string) []FederatedOwner {
+	var out []FederatedOwner
+	if owner := f.LocalOwner(key); owner != "" {
+		out = append(out, FederatedOwner{f.local, owner})
+	}
+	for _, dc := range f.DCs() {
+		if dc == f.local {
+			continue
+		}
+		if owner := f.Ring(dc).Get(key); owner != "" {
+			out = append(out, FederatedOwner{dc, owner})
+		}
+	}
+	return out
+}
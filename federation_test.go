@@ -0,0 +1,72 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFederation(t *testing.T) {
+	east := New(1, intHash)
+	east.AddString("10", "30")
+	west := New(1, intHash)
+	west.AddString("20", "40")
+
+	f := NewFederation("east")
+	if owner := f.GlobalOwner("5"); owner.Owner != "" {
+		t.Errorf("Expected no global owner for an empty federation, but got: %v", owner)
+	}
+	f.SetRing("east", east)
+	f.SetRing("west", west)
+	f.SetRing("north", New(1, intHash))
+
+	if dcs := f.DCs(); !reflect.DeepEqual(dcs, []string{"east", "north", "west"}) {
+		t.Errorf("Unexpected datacenters: %v", dcs)
+	}
+
+	// Keys are the default labels: "010" hashes to 10, "020" to 20, etc.
+	testCases := []struct {
+		key    string
+		local  string
+		global FederatedOwner
+	}{
+		{"5", "10", FederatedOwner{"east", "10"}},
+		{"15", "30", FederatedOwner{"west", "20"}},
+		{"25", "30", FederatedOwner{"east", "30"}},
+		{"35", "10", FederatedOwner{"west", "40"}},
+		{"45", "10", FederatedOwner{"east", "10"}},
+	}
+	for _, tc := range testCases {
+		if owner := f.LocalOwner(tc.key); owner != tc.local {
+			t.Errorf("Expected local owner of %s to be %s, but got: %s", tc.key, tc.local, owner)
+		}
+		if owner := f.GlobalOwner(tc.key); owner != tc.global {
+			t.Errorf("Expected global owner of %s to be %v, but got: %v", tc.key, tc.global, owner)
+		}
+	}
+
+	expected := []FederatedOwner{{"east", "30"}, {"west", "20"}}
+	if owners := f.OwnerPerDC("15"); !reflect.DeepEqual(owners, expected) {
+		t.Errorf("Expected owners %v, but got: %v", expected, owners)
+	}
+
+	f.SetRing("west", nil)
+	if owner := f.GlobalOwner("15"); owner != (FederatedOwner{"east", "30"}) {
+		t.Errorf("Expected removed datacenter to be ignored, but got: %v", owner)
+	}
+}
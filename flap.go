@@ -0,0 +1,219 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// Dampens members that flap. Membership and health transitions are
+// reported to the damper instead of the hash; once a member has made
+// threshold transitions within window it is dampened: transitions that
+// take it out of service (Leave, MarkDown) are still applied right away,
+// but those that return it to service (Join, MarkUp) are held back until
+// the member has made no transitions for a full window. Settle applies
+// the transitions that were held back. This prevents an unstable
+// instance from reshuffling the keyspace over and over.
+type FlapDamper struct {
+	m         *Map
+	window    time.Duration
+	threshold int
+	now       func() time.Time
+	mu        sync.Mutex
+	flaps     map[string][]time.Time
+	dampened  map[string]bool
+	pending   map[string]heldTransition
+}
+
+// A transition that returns a member to service, held back while the
+// member is dampened.
+type heldTransition struct {
+	value  EntryValue // Nil if the member only needs to be marked up.
+	weight int
+}
+
+// Creates a damper for the hash.
+func NewFlapDamper(m *Map, window time.Duration, threshold int) *FlapDamper {
+	return &FlapDamper{
+		m:         m,
+		window:    window,
+		threshold: threshold,
+		now:       time.Now,
+		flaps:     make(map[string][]time.Time),
+		dampened:  make(map[string]bool),
+		pending:   make(map[string]heldTransition),
+	}
+}
+
+// Adds a member to the hash, unless it is dampened. Fails with
+// ErrQuarantined if the member is quarantined.
+func (d *FlapDamper) Join(value EntryValue, weight int) error {
+	key := value.HashRingId()
+	if _, quarantined := d.m.Quarantined(key); quarantined {
+		return ErrQuarantined
+	}
+	if d.record(key) {
+		d.hold(key, heldTransition{value: value, weight: weight})
+		return nil
+	}
+	return d.m.AddWithWeight(value, weight)
+}
+
+// Removes a member from the hash. A join that was held back is dropped.
+func (d *FlapDamper) Leave(key string) error {
+	d.record(key)
+	d.mu.Lock()
+	_, held := d.pending[key]
+	delete(d.pending, key)
+	d.mu.Unlock()
+
+	if err := d.m.Del(key); err != nil && !held {
+		return err
+	}
+	return nil
+}
+
+// Marks a member as down. A held back MarkUp is dropped.
+func (d *FlapDamper) MarkDown(key string) error {
+	d.record(key)
+	d.mu.Lock()
+	if t, held := d.pending[key]; held && t.value == nil {
+		delete(d.pending, key)
+	}
+	d.mu.Unlock()
+	return d.m.MarkDown(key)
+}
+
+// Marks a member as up, unless it is dampened.
+func (d *FlapDamper) MarkUp(key string) {
+	if d.record(key) {
+		d.hold(key, heldTransition{})
+		return
+	}
+	d.m.MarkUp(key)
+}
+
+// Returns true if the member is dampened.
+func (d *FlapDamper) Dampened(key string) bool {
+	now := d.now()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.isDampened(key, now)
+}
+
+// Applies the held back transitions of members that are no longer
+// dampened, and returns how many were applied. Joins of quarantined
+// members stay held back.
+func (d *FlapDamper) Settle() (int, error) {
+	now := d.now()
+	d.mu.Lock()
+	settled := make(map[string]heldTransition)
+	for key, t := range d.pending {
+		if t.value != nil {
+			if _, quarantined := d.m.Quarantined(key); quarantined {
+				continue
+			}
+		}
+		if !d.isDampened(key, now) {
+			settled[key] = t
+			delete(d.pending, key)
+		}
+	}
+	d.mu.Unlock()
+
+	var errs []error
+	logger := d.m.currentLogger()
+	for key, t := range settled {
+		logger.Info("Dampened node settled", "node", key)
+		if t.value == nil {
+			d.m.MarkUp(key)
+			continue
+		}
+		if err := d.m.AddWithWeight(t.value, t.weight); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return len(settled), errors.Join(errs...)
+}
+
+// Calls Settle every interval until ctx is done. Transitions that fail
+// to apply are reported to onError, if set.
+func (d *FlapDamper) Run(ctx context.Context, interval time.Duration, onError func(error)) error {
+	return runEvery(ctx, interval, func() error {
+		_, err := d.Settle()
+		return err
+	}, onError)
+}
+
+// Records a transition of the member, and returns whether it is
+// dampened.
+func (d *FlapDamper) record(key string) bool {
+	now := d.now()
+	d.mu.Lock()
+	flaps := append(d.prune(key, now), now)
+	d.flaps[key] = flaps
+	started := len(flaps) >= d.threshold && !d.dampened[key]
+	if started {
+		d.dampened[key] = true
+	}
+	dampened := d.isDampened(key, now)
+	d.mu.Unlock()
+
+	if started {
+		d.m.currentLogger().Warn("Node dampened after flapping", "node", key, "transitions", len(flaps), "window", d.window)
+	}
+	return dampened
+}
+
+func (d *FlapDamper) hold(key string, t heldTransition) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if prev, held := d.pending[key]; held && t.value == nil {
+		// A MarkUp doesn't replace a held back join.
+		t = prev
+	}
+	d.pending[key] = t
+}
+
+// Returns true if the member is dampened and made a transition within
+// the last window. The caller must hold the lock.
+func (d *FlapDamper) isDampened(key string, now time.Time) bool {
+	if !d.dampened[key] {
+		return false
+	}
+	flaps := d.flaps[key]
+	if len(flaps) > 0 && now.Sub(flaps[len(flaps)-1]) < d.window {
+		return true
+	}
+	delete(d.dampened, key)
+	delete(d.flaps, key)
+	return false
+}
+
+// Drops the transitions of the member that fall outside the window. The
+// caller must hold the lock.
+func (d *FlapDamper) prune(key string, now time.Time) []time.Time {
+	flaps := d.flaps[key]
+	i := 0
+	for i < len(flaps) && now.Sub(flaps[i]) >= d.window {
+		i++
+	}
+	return append([]time.Time(nil), flaps[i:]...)
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFlapDamper(t *testing.T) {
+	hash := New(1, intHash)
+	hash.AddString("10")
+	d := NewFlapDamper(hash, time.Minute, 3)
+	now := time.Unix(1000, 0)
+	d.now = func() time.Time { return now }
+
+	node := &StringValue{"20"}
+	if err := d.Join(node, 1); err != nil || hash.Get("15") != "20" {
+		t.Fatalf("Expected 20 to join, but got: %s, %v", hash.Get("15"), err)
+	}
+	now = now.Add(time.Second)
+	d.Leave("20")
+	now = now.Add(time.Second)
+
+	// The third transition dampens the member, so it is held back.
+	if err := d.Join(node, 1); err != nil || hash.Get("15") != "10" || !d.Dampened("20") {
+		t.Errorf("Expected join of dampened member to be held back, but got: %s, %v", hash.Get("15"), err)
+	}
+	if n, err := d.Settle(); n != 0 || err != nil {
+		t.Errorf("Expected nothing to settle yet, but got: %d, %v", n, err)
+	}
+
+	// Leaving drops the held back join, and rejoining resets the clock.
+	now = now.Add(50 * time.Second)
+	if err := d.Leave("20"); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+	}
+	now = now.Add(50 * time.Second)
+	d.Join(node, 1)
+	now = now.Add(50 * time.Second)
+	if n, _ := d.Settle(); n != 0 || hash.Get("15") != "10" {
+		t.Errorf("Expected member to be held back until stable, but got: %d, %s", n, hash.Get("15"))
+	}
+
+	now = now.Add(10 * time.Second)
+	if n, err := d.Settle(); n != 1 || err != nil || hash.Get("15") != "20" || d.Dampened("20") {
+		t.Errorf("Expected member to join after a quiet window, but got: %d, %v, %s", n, err, hash.Get("15"))
+	}
+}
+
+func TestFlapDamperHealth(t *testing.T) {
+	hash := New(1, intHash)
+	hash.AddString("10", "20")
+	d := NewFlapDamper(hash, time.Minute, 2)
+	now := time.Unix(1000, 0)
+	d.now = func() time.Time { return now }
+
+	d.MarkDown("20")
+	d.MarkUp("20")
+	if !hash.IsDown("20") {
+		t.Errorf("Expected MarkUp of dampened member to be held back")
+	}
+	d.MarkDown("20")
+	d.MarkUp("20")
+
+	now = now.Add(time.Minute)
+	if n, _ := d.Settle(); n != 1 || hash.IsDown("20") {
+		t.Errorf("Expected member to be marked up after a quiet window, but got: %d", n)
+	}
+
+	// Transitions leave the window, so occasional ones don't dampen.
+	now = now.Add(time.Hour)
+	d.MarkDown("20")
+	now = now.Add(2 * time.Minute)
+	d.MarkUp("20")
+	if hash.IsDown("20") || d.Dampened("20") {
+		t.Errorf("Expected occasional transitions not to dampen the member")
+	}
+}
@@ -0,0 +1,56 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"fmt"
+	"math"
+)
+
+// Returns the number of virtual nodes an item with the provided relative
+// weight gets, which is the weight multiplied by the default weight of
+// the hash and rounded to the nearest integer. Any positive weight
+// results in at least one virtual node.
+func (m *Map) VirtualNodesFor(weight float64) (int, error) {
+	if math.IsNaN(weight) || math.IsInf(weight, 0) || weight <= 0 {
+		return 0, fmt.Errorf("Invalid weight: %v", weight)
+	}
+	n := math.Round(weight * float64(m.defaultWeight))
+	if n > math.MaxInt32 {
+		return 0, fmt.Errorf("Invalid weight, too many virtual nodes: %v", weight)
+	}
+	return max(int(n), 1), nil
+}
+
+// Adds an item with a relative weight, e.g. 1.5 for an item with one and
+// a half times the capacity of an item with the default weight.
+func (m *Map) AddWithFloatWeight(entryValue EntryValue, weight float64) error {
+	n, err := m.VirtualNodesFor(weight)
+	if err != nil {
+		return err
+	}
+	return m.AddWithWeight(entryValue, n)
+}
+
+// Changes the weight of an existing item to a relative weight.
+func (m *Map) SetFloatWeight(key string, weight float64) error {
+	n, err := m.VirtualNodesFor(weight)
+	if err != nil {
+		return err
+	}
+	return m.Apply(WeightChange(key, n))
+}
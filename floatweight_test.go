@@ -0,0 +1,67 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"math"
+	"testing"
+)
+
+func TestVirtualNodesFor(t *testing.T) {
+	hash := New(100, nil)
+	testCases := []struct {
+		weight   float64
+		expected int
+	}{
+		{1, 100},
+		{1.5, 150},
+		{0.255, 26},
+		{0.001, 1},
+	}
+	for _, tc := range testCases {
+		if n, err := hash.VirtualNodesFor(tc.weight); err != nil || n != tc.expected {
+			t.Errorf("Expected %d virtual nodes for weight %v, but got: %d, %v", tc.expected, tc.weight, n, err)
+		}
+	}
+
+	for _, weight := range []float64{0, -1, math.NaN(), math.Inf(1), 1e10} {
+		if _, err := hash.VirtualNodesFor(weight); err == nil {
+			t.Errorf("Expected error for weight %v", weight)
+		}
+	}
+}
+
+func TestFloatWeights(t *testing.T) {
+	hash := New(100, nil)
+	hash.AddWithFloatWeight(&StringValue{"A"}, 1)
+	hash.AddWithFloatWeight(&StringValue{"B"}, 1.5)
+	if err := hash.AddWithFloatWeight(&StringValue{"C"}, 0); err == nil {
+		t.Errorf("Expected error for a zero weight")
+	}
+
+	if n := len(hash.entries["B"].positions); n != 150 {
+		t.Errorf("Expected 150 virtual nodes for B, but got: %d", n)
+	}
+
+	hash.SetFloatWeight("B", 0.5)
+	if n := len(hash.entries["B"].positions); n != 50 {
+		t.Errorf("Expected 50 virtual nodes for B, but got: %d", n)
+	}
+	if err := hash.SetFloatWeight("C", 1); err == nil {
+		t.Errorf("Expected error for an unknown item")
+	}
+}
@@ -0,0 +1,37 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"hash/fnv"
+)
+
+// Hashes data using 32-bit FNV-1a.
+func FNV1a32(data []byte) uint32 {
+	h := fnv.New32a()
+	h.Write(data)
+	return h.Sum32()
+}
+
+// Hashes data using 64-bit FNV-1a, folded to 32 bits by XOR-ing its
+// upper and lower half.
+func FNV1a64(data []byte) uint32 {
+	h := fnv.New64a()
+	h.Write(data)
+	sum := h.Sum64()
+	return uint32(sum) ^ uint32(sum>>32)
+}
@@ -0,0 +1,37 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"testing"
+)
+
+func TestFNV1a(t *testing.T) {
+	if res := FNV1a32([]byte("a")); res != 0xe40c292c {
+		t.Errorf("FNV1a32(a) should be 0xe40c292c, but got: %#x", res)
+	}
+	// The 64-bit hash of "a" is 0xaf63dc4c8601ec8c.
+	if res := FNV1a64([]byte("a")); res != 0xaf63dc4c^0x8601ec8c {
+		t.Errorf("FNV1a64(a) should be folded from 0xaf63dc4c8601ec8c, but got: %#x", res)
+	}
+
+	hash := New(50, FNV1a32)
+	hash.AddString("A", "B", "C")
+	if res := hash.Get("foo"); res == "" {
+		t.Errorf("Expected FNV1a32 to be usable as hash function")
+	}
+}
@@ -0,0 +1,56 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+// Buckets the provided keys per owning item in a single pass, which can
+// be used to fan out a multi-get to each shard only once. Returns
+// ErrEmptyRing if there are no items, or ErrNotEnoughNodes if a key has
+// no available owner.
+func (m *Map) GroupByNode(keys []string) (map[string][]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.isEmpty() {
+		return nil, ErrEmptyRing
+	}
+	out := make(map[string][]string)
+	for _, key := range keys {
+		owner := m.get(key)
+		if owner == "" {
+			return nil, ErrNotEnoughNodes
+		}
+		out[owner] = append(out[owner], key)
+	}
+	return out, nil
+}
+
+// Buckets the provided keys per item for each of the N items returned
+// by GetN, so every key appears in the bucket of each of its replicas.
+//
+// The accept function must not call back into the hash, see GetN.
+func (m *Map) GroupByNodeN(keys []string, n int, accept func([]string, string) bool) map[string][]string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	out := make(map[string][]string)
+	for _, key := range keys {
+		for _, owner := range m.getN(key, n, accept) {
+			out[owner] = append(out[owner], key)
+		}
+	}
+	return out
+}
@@ -0,0 +1,60 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGroupByNode(t *testing.T) {
+	hash := New(1, intHash)
+	if res, err := hash.GroupByNode([]string{"1"}); err != ErrEmptyRing {
+		t.Errorf("Expected ErrEmptyRing for an empty ring, but got: %v, %v", res, err)
+	}
+
+	hash.AddString("10", "20", "30")
+	res, err := hash.GroupByNode([]string{"1", "11", "12", "21", "31"})
+	if err != nil {
+		t.Fatalf("Expected keys to be grouped, but got: %v", err)
+	}
+	expected := map[string][]string{
+		"10": {"1", "31"},
+		"20": {"11", "12"},
+		"30": {"21"},
+	}
+	if !reflect.DeepEqual(res, expected) {
+		t.Errorf("Expected %v, but got: %v", expected, res)
+	}
+
+	res = hash.GroupByNodeN([]string{"1", "21"}, 2, AcceptUnique)
+	expected = map[string][]string{
+		"10": {"1", "21"},
+		"20": {"1"},
+		"30": {"21"},
+	}
+	if !reflect.DeepEqual(res, expected) {
+		t.Errorf("Expected %v, but got: %v", expected, res)
+	}
+
+	for _, key := range []string{"10", "20", "30"} {
+		hash.MarkDown(key)
+	}
+	if res, err := hash.GroupByNode([]string{"1"}); err != ErrNotEnoughNodes {
+		t.Errorf("Expected ErrNotEnoughNodes with every item down, but got: %v, %v", res, err)
+	}
+}
@@ -0,0 +1,134 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"sort"
+	"sync"
+)
+
+// A replica of a key. Replicas with a non-empty HintFor are temporary
+// holders of the data of an item that is unavailable.
+type HintedReplica struct {
+	Node    string
+	HintFor string
+}
+
+// Returns true if the replica only holds data until HintFor recovers.
+func (r HintedReplica) Temporary() bool {
+	return r.HintFor != ""
+}
+
+// Gets the first n distinct items of key as replicas. Every unavailable
+// item among them is replaced by the next available item on the ring
+// that is not a replica yet, flagged as temporary hint holder. If there
+// are not enough available items, replicas without a hint holder are
+// omitted.
+func (m *Map) GetNWithHints(key string, n int) []HintedReplica {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	out := []HintedReplica{}
+	if n < 1 {
+		return out
+	}
+
+	all := m.preferenceList(m.hashKey(key), len(m.entries))
+	preferred, rest := all[:min(n, len(all))], all[min(n, len(all)):]
+	for _, node := range preferred {
+		if m.available(node) {
+			out = append(out, HintedReplica{Node: node})
+			continue
+		}
+		for len(rest) > 0 && !m.available(rest[0]) {
+			rest = rest[1:]
+		}
+		if len(rest) > 0 {
+			out = append(out, HintedReplica{Node: rest[0], HintFor: node})
+			rest = rest[1:]
+		}
+	}
+	return out
+}
+
+// Data of a key that a holder keeps on behalf of its target.
+type Hint struct {
+	Key    string
+	Holder string
+	Target string
+}
+
+// Keeps track of the hints handed out by GetN, so they can be handed
+// back to their targets once these recover.
+type HintedHandoff struct {
+	m     *Map
+	mu    sync.Mutex
+	hints map[string]map[Hint]struct{}
+}
+
+// Creates a hinted handoff tracker for the provided hash.
+func NewHintedHandoff(m *Map) *HintedHandoff {
+	return &HintedHandoff{m: m, hints: make(map[string]map[Hint]struct{})}
+}
+
+// Gets the replicas to write key to, like GetNWithHints, and records a
+// hint for every temporary replica.
+func (h *HintedHandoff) GetN(key string, n int) []HintedReplica {
+	replicas := h.m.GetNWithHints(key, n)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, r := range replicas {
+		if !r.Temporary() {
+			continue
+		}
+		if h.hints[r.HintFor] == nil {
+			h.hints[r.HintFor] = make(map[Hint]struct{})
+		}
+		h.hints[r.HintFor][Hint{Key: key, Holder: r.Node, Target: r.HintFor}] = struct{}{}
+	}
+	return replicas
+}
+
+// Returns the hints owed to target, sorted by key and holder.
+func (h *HintedHandoff) Hints(target string) []Hint {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	out := make([]Hint, 0, len(h.hints[target]))
+	for hint := range h.hints[target] {
+		out = append(out, hint)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Key != out[j].Key {
+			return out[i].Key < out[j].Key
+		}
+		return out[i].Holder < out[j].Holder
+	})
+	return out
+}
+
+// Forgets a hint once its data has been handed back to its target.
+func (h *HintedHandoff) Delivered(hint Hint) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	delete(h.hints[hint.Target], hint)
+	if len(h.hints[hint.Target]) == 0 {
+		delete(h.hints, hint.Target)
+	}
+}
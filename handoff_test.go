@@ -0,0 +1,75 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGetNWithHints(t *testing.T) {
+	hash := New(1, intHash)
+	hash.AddString("10", "20", "30", "40", "50")
+
+	expected := []HintedReplica{{Node: "20"}, {Node: "30"}, {Node: "40"}}
+	if replicas := hash.GetNWithHints("15", 3); !reflect.DeepEqual(replicas, expected) {
+		t.Errorf("Expected %v, but got: %v", expected, replicas)
+	}
+
+	hash.MarkDown("20")
+	hash.MarkDown("50")
+	expected = []HintedReplica{{Node: "10", HintFor: "20"}, {Node: "30"}, {Node: "40"}}
+	replicas := hash.GetNWithHints("15", 3)
+	if !reflect.DeepEqual(replicas, expected) {
+		t.Errorf("Expected %v, but got: %v", expected, replicas)
+	}
+	if !replicas[0].Temporary() || replicas[1].Temporary() {
+		t.Errorf("Expected only the first replica to be temporary")
+	}
+
+	// Not enough available items to hold all hints.
+	hash.MarkDown("30")
+	expected = []HintedReplica{{Node: "10", HintFor: "20"}, {Node: "40"}}
+	if replicas := hash.GetNWithHints("15", 4); !reflect.DeepEqual(replicas, expected) {
+		t.Errorf("Expected %v, but got: %v", expected, replicas)
+	}
+}
+
+func TestHintedHandoff(t *testing.T) {
+	hash := New(1, intHash)
+	hash.AddString("10", "20", "30", "40")
+	h := NewHintedHandoff(hash)
+
+	hash.MarkDown("20")
+	h.GetN("15", 2)
+	h.GetN("18", 2)
+	h.GetN("25", 2)
+
+	expected := []Hint{{Key: "15", Holder: "40", Target: "20"}, {Key: "18", Holder: "40", Target: "20"}}
+	hints := h.Hints("20")
+	if !reflect.DeepEqual(hints, expected) {
+		t.Errorf("Expected %v, but got: %v", expected, hints)
+	}
+
+	hash.MarkUp("20")
+	for _, hint := range hints {
+		h.Delivered(hint)
+	}
+	if hints := h.Hints("20"); len(hints) != 0 {
+		t.Errorf("Expected no hints after delivery, but got: %v", hints)
+	}
+}
@@ -0,0 +1,177 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+)
+
+// A position in a 128-bit hash space.
+type Position128 struct {
+	Hi uint64
+	Lo uint64
+}
+
+// Returns true if p is smaller than other.
+func (p Position128) Less(other Position128) bool {
+	return p.Hi < other.Hi || p.Hi == other.Hi && p.Lo < other.Lo
+}
+
+type Hash128 func(data []byte) Position128
+
+// Hashes data using 128-bit Murmur3 (x64_128) with a seed of zero.
+func Murmur3Hash128(data []byte) Position128 {
+	h1, h2 := murmur3x64_128(data, 0)
+	return Position128{h1, h2}
+}
+
+type vnode128 struct {
+	pos   Position128
+	owner string
+}
+
+// A ring hash using a 128-bit hash space, for very large rings that need
+// strict collision guarantees. It follows the same semantics as Map.
+type Map128 struct {
+	mu            sync.RWMutex
+	hash          Hash128
+	defaultWeight int
+	vnodes        []vnode128 // Sorted
+	entries       map[string]*entry
+}
+
+// Creates a 128-bit ring hash using fn (128-bit Murmur3 if nil) as hash
+// function.
+func New128(defaultWeight int, fn Hash128) *Map128 {
+	m := &Map128{
+		defaultWeight: defaultWeight,
+		hash:          fn,
+		entries:       make(map[string]*entry),
+	}
+	if m.hash == nil {
+		m.hash = Murmur3Hash128
+	}
+	return m
+}
+
+// Returns true if there are no items available.
+func (m *Map128) IsEmpty() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.vnodes) == 0
+}
+
+// Adds some strings to the hash.
+func (m *Map128) AddString(keys ...string) error {
+	for _, key := range keys {
+		if err := m.AddWithWeight(&StringValue{key}, m.defaultWeight); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Adds an item to the hash.
+func (m *Map128) AddWithWeight(entryValue EntryValue, weight int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := entryValue.HashRingId()
+	if _, exists := m.entries[key]; exists {
+		return fmt.Errorf("A node with name '%s' already exists", key)
+	}
+	m.entries[key] = &entry{weight: weight, value: entryValue}
+
+	for i := 0; i < weight; i++ {
+		m.vnodes = append(m.vnodes, vnode128{m.hash([]byte(strconv.Itoa(i) + key)), key})
+	}
+	// Colliding positions are owned by the item with the smallest name,
+	// like in Map, regardless of the order items were added in.
+	sort.Slice(m.vnodes, func(i, j int) bool {
+		a, b := m.vnodes[i], m.vnodes[j]
+		if a.pos != b.pos {
+			return a.pos.Less(b.pos)
+		}
+		return a.owner < b.owner
+	})
+	return nil
+}
+
+// Removes an item from the hash.
+func (m *Map128) Del(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, exists := m.entries[key]; !exists {
+		return fmt.Errorf("No node with name '%s' found", key)
+	}
+	vnodes := m.vnodes[:0]
+	for _, v := range m.vnodes {
+		if v.owner != key {
+			vnodes = append(vnodes, v)
+		}
+	}
+	m.vnodes = vnodes
+	delete(m.entries, key)
+	return nil
+}
+
+// Gets the closest item in the hash to the provided key.
+func (m *Map128) Get(key string) string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if len(m.vnodes) == 0 {
+		return ""
+	}
+	return m.vnodes[m.index(m.hash([]byte(key)))].owner
+}
+
+// Gets the N closest items in the hash to the provided key, see Map.GetN.
+func (m *Map128) GetN(key string, n int, accept func([]string, string) bool) []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	out := []string{}
+	if len(m.vnodes) == 0 || n < 1 {
+		return out
+	}
+	if accept == nil {
+		accept = AcceptAny
+	}
+
+	idx := m.index(m.hash([]byte(key)))
+	out = append(out, m.vnodes[idx].owner)
+	for i := 1; len(out) < n && i < len(m.vnodes); i++ {
+		res := m.vnodes[(idx+i)%len(m.vnodes)].owner
+		if accept(out, res) {
+			out = append(out, res)
+		}
+	}
+	return out
+}
+
+// Gets the index of the virtual node owning the provided position.
+func (m *Map128) index(pos Position128) int {
+	idx := sort.Search(len(m.vnodes), func(i int) bool { return !m.vnodes[i].pos.Less(pos) })
+	if idx == len(m.vnodes) {
+		idx = 0
+	}
+	return idx
+}
@@ -0,0 +1,85 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestMap128(t *testing.T) {
+	// Hashes integers into the upper half of the position.
+	hash := New128(1, func(key []byte) Position128 {
+		i, err := strconv.Atoi(string(key))
+		if err != nil {
+			panic(err)
+		}
+		return Position128{uint64(i), 0}
+	})
+	if hash.Get("1") != "" || len(hash.GetN("1", 1, nil)) > 0 {
+		t.Errorf("Should not be able to get items from empty ring")
+	}
+
+	hash.AddString("10", "20", "30")
+	testCases := map[string]string{
+		"5":  "10",
+		"10": "10",
+		"15": "20",
+		"35": "10",
+	}
+	for k, v := range testCases {
+		if res := hash.Get(k); res != v {
+			t.Errorf("Asking for %s, should have yielded %s, but got: %s", k, v, res)
+		}
+	}
+	if res := hash.GetN("25", 3, nil); !reflect.DeepEqual(res, []string{"30", "10", "20"}) {
+		t.Errorf("Unexpected replicas: %v", res)
+	}
+
+	hash.Del("30")
+	if res := hash.Get("25"); res != "10" {
+		t.Errorf("Entry 25 should map to 10, but instead got: %s", res)
+	}
+	if err := hash.Del("30"); err == nil {
+		t.Errorf("Expected error, but it wasn't returned")
+	}
+}
+
+func TestMap128Murmur3(t *testing.T) {
+	hash := New128(50, nil)
+	hash.AddString("A", "B", "C")
+	if res := hash.GetN("foo", 3, AcceptUnique); len(res) != 3 {
+		t.Errorf("Expected 3 unique items, but got: %v", res)
+	}
+}
+
+func TestMap128CollidingPositions(t *testing.T) {
+	// Maps every key to the same position.
+	constant := func([]byte) Position128 { return Position128{1, 1} }
+
+	for _, order := range [][]string{{"A", "B", "C"}, {"C", "B", "A"}, {"B", "C", "A"}} {
+		hash := New128(2, constant)
+		hash.AddString(order...)
+		if res := hash.Get("key"); res != "A" {
+			t.Errorf("Expected the smallest name to win a collision after adding %v, but got: %s", order, res)
+		}
+		if res := hash.GetN("key", 3, AcceptUnique); !reflect.DeepEqual(res, []string{"A", "B", "C"}) {
+			t.Errorf("Expected colliding items in name order after adding %v, but got: %v", order, res)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"sort"
+)
+
+// Marks an item as down. Lookups skip items that are down and fall
+// through to the next owner, but the item keeps its positions on the
+// ring so its keys return to it once it is marked up again.
+//
+// Like membership changes, marking an item down or up bumps the epoch and
+// notifies the listeners of the hash, unless it already was in that state.
+func (m *Map) MarkDown(key string) error {
+	_, err := m.markDown(key)
+	return err
+}
+
+// Marks an item as down, and returns whether it was up before.
+func (m *Map) markDown(key string) (bool, error) {
+	c := DownChange(key)
+	changed := false
+	_, err := m.mutatePlanned(func() ([]Change, error) {
+		if m.down[key] {
+			return nil, errUnchanged
+		}
+		if err := m.apply(c); err != nil {
+			return nil, err
+		}
+		changed = true
+		return []Change{c}, nil
+	})
+	return changed, err
+}
+
+// Marks an item that was marked down as up again.
+func (m *Map) MarkUp(key string) {
+	m.markUp(key)
+}
+
+// Marks an item as up again, and returns whether it was down before.
+func (m *Map) markUp(key string) bool {
+	changed := false
+	m.mutatePlanned(func() ([]Change, error) {
+		if changed = m.forgetDown(key); !changed {
+			return nil, errUnchanged
+		}
+		return []Change{UpChange(key)}, nil
+	})
+	return changed
+}
+
+// Returns true if the item is marked down.
+func (m *Map) IsDown(key string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.down[key]
+}
+
+// Returns the items that are marked down, sorted.
+func (m *Map) Down() []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	out := make([]string, 0, len(m.down))
+	for key := range m.down {
+		out = append(out, key)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Sets the down state of an item. The caller must hold the write lock.
+func (m *Map) setDown(key string) {
+	if m.down[key] {
+		return
+	}
+
+	down := make(map[string]bool, len(m.down)+1)
+	for k := range m.down {
+		down[k] = true
+	}
+	down[key] = true
+	m.down = down
+}
+
+// Clears the down state of an item, and returns whether it was down. The
+// caller must hold the write lock.
+func (m *Map) forgetDown(key string) bool {
+	if !m.down[key] {
+		return false
+	}
+
+	down := make(map[string]bool, len(m.down))
+	for k := range m.down {
+		if k != key {
+			down[k] = true
+		}
+	}
+	m.down = down
+	return true
+}
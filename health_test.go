@@ -0,0 +1,95 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMarkDown(t *testing.T) {
+	hash := New(1, intHash)
+	hash.AddString("10", "20", "30")
+
+	if err := hash.MarkDown("40"); err == nil {
+		t.Errorf("Expected error when marking an unknown node down")
+	}
+
+	hash.MarkDown("20")
+	if !hash.IsDown("20") || hash.IsDown("10") {
+		t.Errorf("Expected only 20 to be down, but got: %v", hash.Down())
+	}
+	if node := hash.Get("15"); node != "30" {
+		t.Errorf("Expected lookups to skip 20, but got: %s", node)
+	}
+	if nodes := hash.GetN("15", 2, AcceptUnique); !reflect.DeepEqual(nodes, []string{"30", "10"}) {
+		t.Errorf("Expected GetN to skip 20, but got: %v", nodes)
+	}
+
+	// Weight changes keep the state, removal clears it.
+	hash.Apply(WeightChange("20", 2))
+	if !hash.IsDown("20") {
+		t.Errorf("Expected 20 to still be down after a weight change")
+	}
+	hash.Del("20")
+	hash.AddString("20")
+	if hash.IsDown("20") {
+		t.Errorf("Expected down state to be cleared on removal")
+	}
+
+	hash.MarkDown("30")
+	hash.MarkUp("30")
+	if node := hash.Get("25"); node != "30" || len(hash.Down()) != 0 {
+		t.Errorf("Expected 30 to be up again, but got: %s, %v", node, hash.Down())
+	}
+}
+
+func TestAvailabilityEvents(t *testing.T) {
+	hash := New(1, intHash)
+	hash.AddString("10", "20")
+
+	var ops []string
+	hash.OnChange(func(e ChangeEvent) {
+		for _, c := range e.Changes {
+			ops = append(ops, c.Op.String()+" "+c.Key)
+		}
+	})
+	jobs := NewJobOwnership(hash, "10", nil)
+	defer jobs.Close()
+	jobs.Register("5")
+
+	hash.MarkDown("20")
+	hash.MarkDown("20")
+	hash.MarkUp("20")
+	hash.MarkUp("20")
+	hash.Quarantine("10", time.Now().Add(time.Hour))
+	if jobs.Owns("5") {
+		t.Errorf("Expected job ownership to follow the quarantine of 10")
+	}
+	hash.Unquarantine("10")
+	hash.Unquarantine("10")
+	hash.Apply(DownChange("10"), UpChange("10"))
+
+	expected := []string{"down 20", "up 20", "quarantine 10", "quarantine 10", "down 10", "up 10"}
+	if !reflect.DeepEqual(ops, expected) {
+		t.Errorf("Expected events %v, but got: %v", expected, ops)
+	}
+	if epoch := hash.Epoch(); epoch != 7 {
+		t.Errorf("Expected epoch 7, but got: %d", epoch)
+	}
+}
@@ -0,0 +1,173 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"hash/crc32"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Counts key lookups within a fixed window and reports keys that are
+// requested at least threshold times within that window as hot.
+type HotKeyTracker struct {
+	mu        sync.Mutex
+	threshold int
+	window    time.Duration
+	start     time.Time
+	counts    map[string]int
+}
+
+// Creates a tracker that considers keys hot once they're observed
+// threshold times within window.
+func NewHotKeyTracker(threshold int, window time.Duration) *HotKeyTracker {
+	return &HotKeyTracker{
+		threshold: threshold,
+		window:    window,
+		counts:    make(map[string]int),
+	}
+}
+
+// Records a lookup of key and returns true if the key is hot.
+func (t *HotKeyTracker) Observe(key string) bool {
+	now := time.Now()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if now.Sub(t.start) >= t.window {
+		t.start = now
+		t.counts = make(map[string]int)
+	}
+	t.counts[key]++
+	return t.counts[key] >= t.threshold
+}
+
+// Spreads hot keys over multiple items, by looking up salted sub-keys
+// ("key#0" up to "key#<k-1>") instead of the key itself. Keys that are
+// not hot are looked up as usual.
+type HotKeyRing struct {
+	m       *Map
+	spread  int
+	tracker *HotKeyTracker
+	mu      sync.Mutex
+	hot     map[string]uint32
+}
+
+// Creates a hot key ring that spreads hot keys over k items. If tracker
+// is not nil, keys it reports as hot are marked as such automatically.
+func NewHotKeyRing(m *Map, k int, tracker *HotKeyTracker) *HotKeyRing {
+	return &HotKeyRing{
+		m:       m,
+		spread:  k,
+		tracker: tracker,
+		hot:     make(map[string]uint32),
+	}
+}
+
+// Marks keys as hot.
+func (h *HotKeyRing) MarkHot(keys ...string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, key := range keys {
+		if _, exists := h.hot[key]; !exists {
+			h.hot[key] = 0
+		}
+	}
+}
+
+// Marks keys as no longer being hot.
+func (h *HotKeyRing) Unmark(keys ...string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, key := range keys {
+		delete(h.hot, key)
+	}
+}
+
+// Returns true if the key is currently marked as hot.
+func (h *HotKeyRing) IsHot(key string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, hot := h.hot[key]
+	return hot
+}
+
+// Returns the distinct items a key is spread over. For keys that are not
+// hot this is just the owner of the key. Items that are down are left
+// out, so the list is empty if none is available.
+func (h *HotKeyRing) Owners(key string) []string {
+	if !h.IsHot(key) {
+		if owner := h.m.Get(key); owner != "" {
+			return []string{owner}
+		}
+		return []string{}
+	}
+	return h.owners(key)
+}
+
+func (h *HotKeyRing) owners(key string) []string {
+	h.m.mu.RLock()
+	defer h.m.mu.RUnlock()
+
+	out := []string{}
+	for i := 0; i < h.spread; i++ {
+		if owner := h.m.get(key + "#" + strconv.Itoa(i)); owner != "" && AcceptUnique(out, owner) {
+			out = append(out, owner)
+		}
+	}
+	return out
+}
+
+// Gets the item to use for the provided key. Lookups for hot keys cycle
+// through the items the key is spread over. Like GetE, returns an error
+// if no item is available.
+func (h *HotKeyRing) Get(key string) (string, error) {
+	if h.tracker != nil && h.tracker.Observe(key) {
+		h.MarkHot(key)
+	}
+
+	h.mu.Lock()
+	n, hot := h.hot[key]
+	if hot {
+		h.hot[key] = n + 1
+	}
+	h.mu.Unlock()
+
+	if !hot {
+		return h.m.GetE(key)
+	}
+	owners := h.owners(key)
+	if len(owners) == 0 {
+		return "", ErrNotEnoughNodes
+	}
+	return owners[int(n%uint32(len(owners)))], nil
+}
+
+// Gets the item to use for the provided key on behalf of a specific
+// client. Every client deterministically uses the same item for a key.
+// Like GetE, returns an error if no item is available.
+func (h *HotKeyRing) GetFor(key, client string) (string, error) {
+	if !h.IsHot(key) {
+		return h.m.GetE(key)
+	}
+	owners := h.owners(key)
+	if len(owners) == 0 {
+		return "", ErrNotEnoughNodes
+	}
+	return owners[int(crc32.ChecksumIEEE([]byte(client))%uint32(len(owners)))], nil
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHotKeyRing(t *testing.T) {
+	hash := New(50, nil)
+	hash.AddString("A", "B", "C", "D", "E")
+
+	h := NewHotKeyRing(hash, 3, nil)
+	if res, _ := h.Get("celebrity"); res != hash.Get("celebrity") {
+		t.Errorf("Keys that are not hot should map to their owner, but got: %s", res)
+	}
+
+	h.MarkHot("celebrity")
+	owners := h.Owners("celebrity")
+	if len(owners) < 2 {
+		t.Fatalf("Expected hot key to be spread over multiple items, but got: %v", owners)
+	}
+
+	seen := map[string]bool{}
+	for i := 0; i < len(owners); i++ {
+		owner, _ := h.Get("celebrity")
+		seen[owner] = true
+	}
+	if len(seen) != len(owners) {
+		t.Errorf("Expected lookups to cycle through %v, but got: %v", owners, seen)
+	}
+	first, _ := h.GetFor("celebrity", "client-1")
+	if second, _ := h.GetFor("celebrity", "client-1"); first != second {
+		t.Errorf("Expected lookups for the same client to be deterministic")
+	}
+
+	h.Unmark("celebrity")
+	if owners := h.Owners("celebrity"); len(owners) != 1 {
+		t.Errorf("Expected unmarked key to have a single owner, but got: %v", owners)
+	}
+
+	h.MarkHot("celebrity")
+	for _, key := range []string{"A", "B", "C", "D", "E"} {
+		hash.MarkDown(key)
+	}
+	if owners := h.Owners("celebrity"); len(owners) != 0 {
+		t.Errorf("Expected no owners with every item down, but got: %v", owners)
+	}
+	if res, err := h.Get("celebrity"); err != ErrNotEnoughNodes {
+		t.Errorf("Expected ErrNotEnoughNodes with every item down, but got: %s, %v", res, err)
+	}
+	if res, err := h.GetFor("celebrity", "client-1"); err != ErrNotEnoughNodes {
+		t.Errorf("Expected ErrNotEnoughNodes with every item down, but got: %s, %v", res, err)
+	}
+}
+
+func TestHotKeyTracker(t *testing.T) {
+	hash := New(50, nil)
+	hash.AddString("A", "B", "C")
+
+	h := NewHotKeyRing(hash, 3, NewHotKeyTracker(3, time.Minute))
+	h.Get("celebrity")
+	h.Get("celebrity")
+	if h.IsHot("celebrity") {
+		t.Errorf("Key should not be hot before reaching the threshold")
+	}
+	h.Get("celebrity")
+	if !h.IsHot("celebrity") {
+		t.Errorf("Key should be hot after reaching the threshold")
+	}
+}
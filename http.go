@@ -0,0 +1,206 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type memberRequest struct {
+	Weight int               `json:"weight"`
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+type ownersResponse struct {
+	Key    string   `json:"key"`
+	Owners []string `json:"owners"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// Returns a handler that exposes management of the hash over HTTP with
+// JSON bodies:
+//
+//	GET    /members              lists all members
+//	GET    /members/{id}         returns a single member
+//	PUT    /members/{id}         adds a member ({"weight": 3, "labels": {...}})
+//	DELETE /members/{id}         removes a member
+//	PUT    /members/{id}/weight  changes the weight of a member ({"weight": 3})
+//	GET    /owners/{key}         returns the owners of a key (?replicas=n)
+//	GET    /topology             exports the topology as a snapshot
+//
+// To mount it under an existing mux, strip the prefix:
+//
+//	mux.Handle("/ring/", http.StripPrefix("/ring", NewHTTPHandler(m)))
+func NewHTTPHandler(m *Map) http.Handler {
+	return &httpHandler{m: m, admin: NewAdminService(m)}
+}
+
+type httpHandler struct {
+	m     *Map
+	admin *AdminService
+}
+
+func (h *httpHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Keys often contain slashes themselves, so everything after the
+	// prefix is the key.
+	if key := strings.TrimPrefix(r.URL.Path, "/owners/"); key != r.URL.Path && key != "" {
+		h.route(w, r, map[string]http.HandlerFunc{
+			"GET": func(w http.ResponseWriter, r *http.Request) { h.getOwners(w, r, key) },
+		})
+		return
+	}
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	switch {
+	case len(parts) == 1 && parts[0] == "members":
+		h.route(w, r, map[string]http.HandlerFunc{"GET": h.listMembers})
+	case len(parts) == 2 && parts[0] == "members":
+		h.route(w, r, map[string]http.HandlerFunc{
+			"GET":    func(w http.ResponseWriter, r *http.Request) { h.getMember(w, r, parts[1]) },
+			"PUT":    func(w http.ResponseWriter, r *http.Request) { h.addMember(w, r, parts[1]) },
+			"DELETE": func(w http.ResponseWriter, r *http.Request) { h.removeMember(w, r, parts[1]) },
+		})
+	case len(parts) == 3 && parts[0] == "members" && parts[2] == "weight":
+		h.route(w, r, map[string]http.HandlerFunc{
+			"PUT": func(w http.ResponseWriter, r *http.Request) { h.setWeight(w, r, parts[1]) },
+		})
+	case len(parts) == 1 && parts[0] == "topology":
+		h.route(w, r, map[string]http.HandlerFunc{"GET": h.getTopology})
+	default:
+		writeError(w, http.StatusNotFound, "Not found: "+r.URL.Path)
+	}
+}
+
+func (h *httpHandler) route(w http.ResponseWriter, r *http.Request, methods map[string]http.HandlerFunc) {
+	if fn, exists := methods[r.Method]; exists {
+		fn(w, r)
+		return
+	}
+
+	allowed := make([]string, 0, len(methods))
+	for method := range methods {
+		allowed = append(allowed, method)
+	}
+	sort.Strings(allowed)
+	w.Header().Set("Allow", strings.Join(allowed, ", "))
+	writeError(w, http.StatusMethodNotAllowed, "Method not allowed: "+r.Method)
+}
+
+func (h *httpHandler) listMembers(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, h.m.Snapshot().Nodes)
+}
+
+func (h *httpHandler) getMember(w http.ResponseWriter, r *http.Request, id string) {
+	for _, node := range h.m.Snapshot().Nodes {
+		if node.ID == id {
+			writeJSON(w, http.StatusOK, node)
+			return
+		}
+	}
+	writeError(w, http.StatusNotFound, "No node with name '"+id+"' found")
+}
+
+func (h *httpHandler) addMember(w http.ResponseWriter, r *http.Request, id string) {
+	var req memberRequest
+	if !readJSON(w, r, &req) {
+		return
+	}
+	if _, exists := h.m.Value(id); exists {
+		writeError(w, http.StatusConflict, "Node '"+id+"' already exists")
+		return
+	}
+	if _, err := h.admin.AddNode(r.Context(), id, req.Weight, req.Labels); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (h *httpHandler) removeMember(w http.ResponseWriter, r *http.Request, id string) {
+	if _, err := h.admin.RemoveNode(r.Context(), id); err != nil {
+		writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *httpHandler) setWeight(w http.ResponseWriter, r *http.Request, id string) {
+	var req memberRequest
+	if !readJSON(w, r, &req) {
+		return
+	}
+	if _, exists := h.m.Value(id); !exists {
+		writeError(w, http.StatusNotFound, "No node with name '"+id+"' found")
+		return
+	}
+	if req.Weight < 1 {
+		writeError(w, http.StatusBadRequest, "Weight must be at least 1")
+		return
+	}
+	if _, err := h.admin.SetWeight(r.Context(), id, req.Weight); err != nil {
+		writeError(w, http.StatusConflict, err.Error())
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *httpHandler) getOwners(w http.ResponseWriter, r *http.Request, key string) {
+	replicas := 1
+	if s := r.URL.Query().Get("replicas"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 {
+			writeError(w, http.StatusBadRequest, "Invalid number of replicas: "+s)
+			return
+		}
+		replicas = n
+	}
+	owners, err := h.admin.GetOwner(r.Context(), key, replicas)
+	if err != nil {
+		writeError(w, http.StatusServiceUnavailable, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, ownersResponse{Key: key, Owners: owners})
+}
+
+func (h *httpHandler) getTopology(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, h.m.Snapshot())
+}
+
+func readJSON(w http.ResponseWriter, r *http.Request, v interface{}) bool {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid request body: "+err.Error())
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, errorResponse{Error: msg})
+}
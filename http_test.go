@@ -0,0 +1,103 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestHTTPHandler(t *testing.T) {
+	hash := New(3, intHash)
+	mux := http.NewServeMux()
+	mux.Handle("/ring/", http.StripPrefix("/ring", NewHTTPHandler(hash)))
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return rec
+	}
+
+	testCases := []struct {
+		method, path, body string
+		status             int
+	}{
+		{"GET", "/ring/owners/15", "", http.StatusServiceUnavailable},
+		{"PUT", "/ring/members/10", `{"weight": 3, "labels": {"zone": "a"}}`, http.StatusCreated},
+		{"PUT", "/ring/members/30", `{"labels": {"zone": "a"}}`, http.StatusBadRequest},
+		{"PUT", "/ring/members/10", `{}`, http.StatusConflict},
+		{"PUT", "/ring/members/20", `{"weight": 1}`, http.StatusCreated},
+		{"PUT", "/ring/members/30", `not json`, http.StatusBadRequest},
+		{"PUT", "/ring/members/30", `{"weight": -3}`, http.StatusBadRequest},
+		{"PUT", "/ring/members/20/weight", `{"weight": 2}`, http.StatusNoContent},
+		{"PUT", "/ring/members/20/weight", `{"weight": 0}`, http.StatusBadRequest},
+		{"PUT", "/ring/members/30/weight", `{"weight": 2}`, http.StatusNotFound},
+		{"GET", "/ring/members/10", "", http.StatusOK},
+		{"GET", "/ring/members/30", "", http.StatusNotFound},
+		{"GET", "/ring/owners/15?replicas=x", "", http.StatusBadRequest},
+		{"DELETE", "/ring/members/30", "", http.StatusNotFound},
+		{"POST", "/ring/members", "", http.StatusMethodNotAllowed},
+	}
+	for _, tc := range testCases {
+		if rec := do(tc.method, tc.path, tc.body); rec.Code != tc.status {
+			t.Errorf("Expected status %d for %s %s, but got: %d (%s)", tc.status, tc.method, tc.path, rec.Code, rec.Body)
+		}
+	}
+
+	rec := do("GET", "/ring/owners/15?replicas=2", "")
+	var owners ownersResponse
+	json.Unmarshal(rec.Body.Bytes(), &owners)
+	if strings.Join(owners.Owners, ",") != "20,10" {
+		t.Errorf("Expected owners 20,10, but got: %s", rec.Body)
+	}
+
+	rec = do("GET", "/ring/members", "")
+	var members []SnapshotNode
+	json.Unmarshal(rec.Body.Bytes(), &members)
+	if len(members) != 2 || members[0].Labels["zone"] != "a" || members[1].Weight != 2 {
+		t.Errorf("Unexpected members: %s", rec.Body)
+	}
+
+	if rec := do("DELETE", "/ring/members/10", ""); rec.Code != http.StatusNoContent {
+		t.Errorf("Expected status 204, but got: %d", rec.Code)
+	}
+
+	rec = do("GET", "/ring/topology", "")
+	s, err := ReadSnapshot(strings.NewReader("GoConsistentHash snapshot v2\n" + rec.Body.String()))
+	if err != nil || len(s.Nodes) != 1 || s.Epoch != 4 {
+		t.Errorf("Unexpected topology: %s (%v)", rec.Body, err)
+	}
+}
+
+func TestHTTPHandlerKeyWithSlashes(t *testing.T) {
+	hash := New(3, nil)
+	hash.AddString("A", "B")
+	mux := http.NewServeMux()
+	mux.Handle("/ring/", http.StripPrefix("/ring", NewHTTPHandler(hash)))
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest("GET", "/ring/owners/user/42?replicas=2", nil))
+	var owners ownersResponse
+	json.Unmarshal(rec.Body.Bytes(), &owners)
+	if rec.Code != http.StatusOK || owners.Key != "user/42" || !reflect.DeepEqual(owners.Owners, hash.GetN("user/42", 2, AcceptUnique)) {
+		t.Errorf("Expected the owners of key user/42, but got: %d %s", rec.Code, rec.Body)
+	}
+}
@@ -0,0 +1,39 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+// Stores the name of a new item and returns its index. Positions on the
+// ring refer to items by index rather than by name, which keeps the
+// position map free of pointers and thus cheap for the garbage collector
+// to deal with. The caller must hold the write lock.
+func (m *Map) intern(key string) int32 {
+	if n := len(m.free); n > 0 {
+		id := m.free[n-1]
+		m.free = m.free[:n-1]
+		m.names[id] = key
+		return id
+	}
+	m.names = append(m.names, key)
+	return int32(len(m.names) - 1)
+}
+
+// Frees the index of a removed item for reuse. The caller must hold the
+// write lock.
+func (m *Map) release(id int32) {
+	m.names[id] = ""
+	m.free = append(m.free, id)
+}
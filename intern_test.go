@@ -0,0 +1,48 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"testing"
+)
+
+func TestInterning(t *testing.T) {
+	hash := New(3, intHash)
+	hash.AddString("10", "20", "30")
+	if len(hash.names) != 3 {
+		t.Errorf("Expected 3 interned names, but got: %v", hash.names)
+	}
+
+	hash.Del("20")
+	hash.AddString("40")
+	if len(hash.names) != 3 || hash.names[hash.entries["40"].id] != "40" {
+		t.Errorf("Expected the index of 20 to be reused, but got: %v", hash.names)
+	}
+
+	hash.Apply(WeightChange("10", 5))
+	if id := hash.entries["10"].id; hash.names[id] != "10" {
+		t.Errorf("Expected 10 to keep a valid index after a weight change, but got: %s", hash.names[id])
+	}
+	for pos, id := range hash.hashMap {
+		if hash.names[id] == "" {
+			t.Errorf("Expected position %d to refer to an item", pos)
+		}
+	}
+	if node := hash.Get("15"); node != "30" {
+		t.Errorf("Expected 30, but got: %s", node)
+	}
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"iter"
+)
+
+// Returns an iterator over the distinct items in ring order, starting at
+// the owner of the provided key. Callers can stop iterating as soon as
+// they found a suitable candidate, instead of guessing n for GetN.
+//
+// The candidates are determined when iteration starts; changes made to
+// the hash while iterating are not reflected.
+func (m *Map) Successors(key string) iter.Seq[EntryValue] {
+	return func(yield func(EntryValue) bool) {
+		m.mu.RLock()
+		candidates := make([]EntryValue, 0, len(m.entries))
+		seen := make(map[string]bool, len(m.entries))
+		m.walk(m.hashKey(key), func(_ int, owner string) bool {
+			if !seen[owner] {
+				seen[owner] = true
+				if m.available(owner) {
+					candidates = append(candidates, m.entries[owner].value)
+				}
+			}
+			return len(seen) < len(m.entries)
+		})
+		m.mu.RUnlock()
+
+		for _, v := range candidates {
+			if !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+// Returns an iterator over every position on the ring and its owner, in
+// ring order. The hash is read-locked while iterating, so the loop body
+// must not modify the hash.
+func (m *Map) All() iter.Seq2[uint32, string] {
+	return func(yield func(uint32, string) bool) {
+		m.mu.RLock()
+		defer m.mu.RUnlock()
+
+		for i, pos := range m.keys {
+			if i > 0 && m.keys[i-1] == pos {
+				continue
+			}
+			if !yield(uint32(pos), m.names[m.hashMap[pos]]) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,68 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSuccessors(t *testing.T) {
+	hash := New(2, intHash)
+	for range hash.Successors("5") {
+		t.Errorf("Expected no successors in an empty ring")
+	}
+
+	hash.AddString("10", "20", "30")
+
+	var res []string
+	for v := range hash.Successors("15") {
+		res = append(res, v.HashRingId())
+	}
+	if !reflect.DeepEqual(res, []string{"20", "30", "10"}) {
+		t.Errorf("Expected distinct successors in ring order, but got: %v", res)
+	}
+
+	res = nil
+	for v := range hash.Successors("15") {
+		res = append(res, v.HashRingId())
+		if len(res) == 2 {
+			break
+		}
+	}
+	if len(res) != 2 {
+		t.Errorf("Expected iteration to stop early, but got: %v", res)
+	}
+}
+
+func TestAll(t *testing.T) {
+	hash := New(2, intHash)
+	hash.AddString("20", "10")
+
+	var positions []uint32
+	var owners []string
+	for pos, owner := range hash.All() {
+		positions = append(positions, pos)
+		owners = append(owners, owner)
+	}
+	if !reflect.DeepEqual(positions, []uint32{10, 20, 110, 120}) {
+		t.Errorf("Expected positions in ring order, but got: %v", positions)
+	}
+	if !reflect.DeepEqual(owners, []string{"10", "20", "10", "20"}) {
+		t.Errorf("Expected owners of each position, but got: %v", owners)
+	}
+}
@@ -0,0 +1,139 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"sort"
+	"sync"
+)
+
+// Decides which scheduled jobs the local instance should run. Every
+// instance of a service registers itself on a shared ring and only runs
+// the jobs whose IDs map to it. When membership changes, jobs are handed
+// off between instances and the local instance is notified of every job
+// it acquires or releases.
+type JobOwnership struct {
+	m         *Map
+	self      string
+	onHandoff func(job string, acquired bool)
+	cancel    func()
+	mu        sync.Mutex
+	owned     map[string]bool
+}
+
+// Creates a job ownership tracker for the instance named self. The
+// onHandoff callback is called whenever the local instance acquires or
+// releases a job. When called due to a membership change it must not
+// modify the hash.
+func NewJobOwnership(m *Map, self string, onHandoff func(job string, acquired bool)) *JobOwnership {
+	o := &JobOwnership{
+		m:         m,
+		self:      self,
+		onHandoff: onHandoff,
+		owned:     make(map[string]bool),
+	}
+	o.cancel = m.OnChange(func(ChangeEvent) { o.refresh(nil) })
+	return o
+}
+
+// Registers jobs, so their ownership is tracked.
+func (o *JobOwnership) Register(jobs ...string) {
+	o.refresh(jobs)
+}
+
+// Stops tracking jobs. Jobs owned by the local instance are released.
+func (o *JobOwnership) Unregister(jobs ...string) {
+	var released []string
+	o.mu.Lock()
+	for _, job := range jobs {
+		if owned, exists := o.owned[job]; exists {
+			if owned {
+				released = append(released, job)
+			}
+			delete(o.owned, job)
+		}
+	}
+	o.mu.Unlock()
+
+	for _, job := range released {
+		o.notify(job, false)
+	}
+}
+
+// Returns true if the local instance should run the job.
+func (o *JobOwnership) Owns(job string) bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.owned[job]
+}
+
+// Returns the registered jobs the local instance should run, sorted.
+func (o *JobOwnership) Owned() []string {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	out := []string{}
+	for job, owned := range o.owned {
+		if owned {
+			out = append(out, job)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Recomputes ownership of all registered jobs plus the provided new ones.
+func (o *JobOwnership) refresh(jobs []string) {
+	type handoff struct {
+		job      string
+		acquired bool
+	}
+	var handoffs []handoff
+
+	o.mu.Lock()
+	for _, job := range jobs {
+		if _, exists := o.owned[job]; !exists {
+			o.owned[job] = false
+		}
+	}
+
+	o.m.mu.RLock()
+	for job, owned := range o.owned {
+		if owns := o.m.get(job) == o.self; owns != owned {
+			o.owned[job] = owns
+			handoffs = append(handoffs, handoff{job, owns})
+		}
+	}
+	o.m.mu.RUnlock()
+	o.mu.Unlock()
+
+	sort.Slice(handoffs, func(i, j int) bool { return handoffs[i].job < handoffs[j].job })
+	for _, h := range handoffs {
+		o.notify(h.job, h.acquired)
+	}
+}
+
+func (o *JobOwnership) notify(job string, acquired bool) {
+	if o.onHandoff != nil {
+		o.onHandoff(job, acquired)
+	}
+}
+
+// Stops following membership changes of the hash.
+func (o *JobOwnership) Close() {
+	o.cancel()
+}
@@ -0,0 +1,54 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestJobOwnership(t *testing.T) {
+	hash := New(1, intHash)
+	hash.AddString("10", "30")
+
+	handoffs := map[string]bool{}
+	o := NewJobOwnership(hash, "30", func(job string, acquired bool) {
+		handoffs[job] = acquired
+	})
+	defer o.Close()
+
+	o.Register("5", "15", "25")
+	if res := o.Owned(); !reflect.DeepEqual(res, []string{"15", "25"}) {
+		t.Errorf("Expected to own jobs 15 and 25, but got: %v", res)
+	}
+	if !handoffs["15"] || !handoffs["25"] {
+		t.Errorf("Expected acquired hand-offs for 15 and 25, but got: %v", handoffs)
+	}
+
+	hash.AddString("20")
+	if o.Owns("15") || !o.Owns("25") {
+		t.Errorf("Expected job 15 to be handed off to 20, but own: %v", o.Owned())
+	}
+	if acquired, exists := handoffs["15"]; !exists || acquired {
+		t.Errorf("Expected released hand-off for 15, but got: %v", handoffs)
+	}
+
+	o.Unregister("25")
+	if len(o.Owned()) != 0 || handoffs["25"] {
+		t.Errorf("Expected unregistered job to be released, but got: %v", handoffs)
+	}
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+// Maps message keys to Kafka partitions using a ring of the partitions,
+// so adding partitions only moves the keys that the new partitions take
+// over (rather than nearly all keys, as with modulo hashing). It does not
+// depend on a Kafka client; to use it with sarama, wrap it as follows:
+//
+//	type partitioner struct{ p *KafkaPartitioner }
+//
+//	func (p partitioner) Partition(msg *sarama.ProducerMessage, n int32) (int32, error) {
+//		if msg.Key == nil {
+//			return p.p.Partition(nil, n)
+//		}
+//		key, err := msg.Key.Encode()
+//		if err != nil {
+//			return -1, err
+//		}
+//		return p.p.Partition(key, n)
+//	}
+//
+//	func (p partitioner) RequiresConsistency() bool { return p.p.RequiresConsistency() }
+//
+// and set config.Producer.Partitioner to a func(topic string)
+// sarama.Partitioner returning it. Adapting it to franz-go's
+// kgo.TopicPartitioner works the same way.
+type KafkaPartitioner struct {
+	vnodes  int
+	weights map[int32]float64
+	mu      sync.Mutex
+	rings   map[int32]*Map
+	next    uint32
+}
+
+// Creates a partitioner in which every partition gets vnodes virtual
+// nodes multiplied by its weight. Partitions without a weight have a
+// weight of 1; weights may be nil.
+func NewKafkaPartitioner(vnodes int, weights map[int32]float64) *KafkaPartitioner {
+	copied := make(map[int32]float64, len(weights))
+	for p, w := range weights {
+		copied[p] = w
+	}
+	return &KafkaPartitioner{vnodes: vnodes, weights: copied, rings: make(map[int32]*Map)}
+}
+
+// Returns the partition of a message with the provided key, out of
+// numPartitions partitions. Messages without a key are spread over the
+// partitions round-robin.
+func (p *KafkaPartitioner) Partition(key []byte, numPartitions int32) (int32, error) {
+	if numPartitions < 1 {
+		return -1, fmt.Errorf("Invalid number of partitions: %d", numPartitions)
+	}
+	if key == nil {
+		return int32(atomic.AddUint32(&p.next, 1) % uint32(numPartitions)), nil
+	}
+
+	m, err := p.ring(numPartitions)
+	if err != nil {
+		return -1, err
+	}
+	m.mu.RLock()
+	owner := m.getHash(m.hashBytes(key))
+	m.mu.RUnlock()
+
+	partition, err := strconv.ParseInt(owner, 10, 32)
+	return int32(partition), err
+}
+
+// Reports that keys must always map to the same partition.
+func (p *KafkaPartitioner) RequiresConsistency() bool {
+	return true
+}
+
+func (p *KafkaPartitioner) ring(numPartitions int32) (*Map, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if m, exists := p.rings[numPartitions]; exists {
+		return m, nil
+	}
+
+	m := New(p.vnodes, nil)
+	for i := int32(0); i < numPartitions; i++ {
+		weight, exists := p.weights[i]
+		if !exists {
+			weight = 1
+		}
+		if err := m.AddWithFloatWeight(&StringValue{strconv.Itoa(int(i))}, weight); err != nil {
+			return nil, err
+		}
+	}
+	p.rings[numPartitions] = m
+	return m, nil
+}
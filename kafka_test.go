@@ -0,0 +1,70 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestKafkaPartitioner(t *testing.T) {
+	p := NewKafkaPartitioner(100, map[int32]float64{0: 3})
+	if !p.RequiresConsistency() {
+		t.Errorf("Expected partitioner to require consistency")
+	}
+	if _, err := p.Partition([]byte("foo"), 0); err == nil {
+		t.Errorf("Expected error for zero partitions")
+	}
+
+	counts := make(map[int32]int)
+	moved := 0
+	for i := 0; i < 4000; i++ {
+		key := []byte(strconv.Itoa(i))
+		partition, err := p.Partition(key, 4)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if again, _ := p.Partition(key, 4); again != partition {
+			t.Fatalf("Expected key %s to map consistently", key)
+		}
+		counts[partition]++
+
+		if grown, _ := p.Partition(key, 5); grown != partition {
+			if grown != 4 {
+				t.Errorf("Expected keys to only move to the new partition, but %s moved to %d", key, grown)
+			}
+			moved++
+		}
+	}
+
+	// Partition 0 has 3 of the 6 shares.
+	if counts[0] < 1700 || counts[0] > 2300 {
+		t.Errorf("Expected partition 0 to get about half the keys, but got: %v", counts)
+	}
+	if moved < 300 || moved > 1000 {
+		t.Errorf("Expected about 1/7 of the keys to move to the new partition, but %d did", moved)
+	}
+
+	seen := make(map[int32]bool)
+	for i := 0; i < 4; i++ {
+		partition, _ := p.Partition(nil, 4)
+		seen[partition] = true
+	}
+	if len(seen) != 4 {
+		t.Errorf("Expected messages without key to be spread round-robin, but got: %v", seen)
+	}
+}
@@ -0,0 +1,120 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// An EntryValue that carries labels, which are attached to the item
+// when it is added to the hash.
+type LabeledValue interface {
+	EntryValue
+	Labels() map[string]string
+}
+
+// Selects items by their labels. An item matches if it has every label
+// of the selector with the same value.
+type Selector map[string]string
+
+// Parses a selector of the form "key=value,key=value".
+func ParseSelector(s string) (Selector, error) {
+	out := Selector{}
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 || kv[0] == "" {
+			return nil, fmt.Errorf("Invalid selector term '%s'", part)
+		}
+		out[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+	}
+	return out, nil
+}
+
+// Returns true if the labels match the selector.
+func (s Selector) Matches(labels map[string]string) bool {
+	for k, v := range s {
+		if l, exists := labels[k]; !exists || l != v {
+			return false
+		}
+	}
+	return true
+}
+
+func (s Selector) String() string {
+	terms := make([]string, 0, len(s))
+	for k, v := range s {
+		terms = append(terms, k+"="+v)
+	}
+	sort.Strings(terms)
+	return strings.Join(terms, ",")
+}
+
+func labelsOf(value EntryValue) map[string]string {
+	labeled, ok := value.(LabeledValue)
+	if !ok {
+		return nil
+	}
+	return copyLabels(labeled.Labels())
+}
+
+func copyLabels(labels map[string]string) map[string]string {
+	if labels == nil {
+		return nil
+	}
+	out := make(map[string]string, len(labels))
+	for k, v := range labels {
+		out[k] = v
+	}
+	return out
+}
+
+// Replaces the labels of an item.
+func (m *Map) SetLabels(key string, labels map[string]string) error {
+	return m.applyInPlace(LabelsChange(key, labels))
+}
+
+// Returns a copy of the labels of an item.
+func (m *Map) Labels(key string) map[string]string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if e, exists := m.entries[key]; exists {
+		return copyLabels(e.labels)
+	}
+	return nil
+}
+
+// Gets the closest item to the provided key whose labels match the
+// selector, or an empty string if there is none.
+func (m *Map) GetTagged(key string, selector Selector) string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	out := ""
+	m.walk(m.hashKey(key), func(_ int, owner string) bool {
+		if m.available(owner) && selector.Matches(m.entries[owner].labels) {
+			out = owner
+		}
+		return out == ""
+	})
+	return out
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"testing"
+)
+
+type labeledValue struct {
+	StringValue
+	labels map[string]string
+}
+
+func (v *labeledValue) Labels() map[string]string {
+	return v.labels
+}
+
+func TestGetTagged(t *testing.T) {
+	hash := New(1, intHash)
+	hash.AddWithWeight(&labeledValue{StringValue{"10"}, map[string]string{"region": "sg"}}, 1)
+	hash.AddString("20", "30")
+	hash.SetLabels("30", map[string]string{"region": "sg", "ssd": "true"})
+
+	selector, err := ParseSelector("ssd=true, region=sg")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if selector.String() != "region=sg,ssd=true" {
+		t.Errorf("Unexpected selector: %s", selector)
+	}
+
+	if res := hash.GetTagged("5", Selector{"region": "sg"}); res != "10" {
+		t.Errorf("Entry 5 should map to 10, but instead got: %s", res)
+	}
+	if res := hash.GetTagged("5", selector); res != "30" {
+		t.Errorf("Entry 5 should map to 30, but instead got: %s", res)
+	}
+	if res := hash.GetTagged("5", Selector{"region": "us"}); res != "" {
+		t.Errorf("Expected no matching item, but got: %s", res)
+	}
+
+	// Labels survive weight changes.
+	hash.Apply(WeightChange("30", 2))
+	if res := hash.Labels("30"); res["ssd"] != "true" {
+		t.Errorf("Expected labels to be preserved, but got: %v", res)
+	}
+
+	if _, err := ParseSelector("ssd"); err == nil {
+		t.Errorf("Expected error, but it wasn't returned")
+	}
+}
+
+func TestSetLabelsNotifiesListeners(t *testing.T) {
+	hash := New(1, intHash)
+	hash.AddString("10")
+
+	var events []ChangeEvent
+	hash.OnChange(func(event ChangeEvent) { events = append(events, event) })
+
+	hash.SetLabels("10", map[string]string{"zone": "a"})
+	hash.SetMetadata("10", map[string]interface{}{"port": 80})
+	hash.SetCapacity("10", 5)
+	if err := hash.SetLabels("20", nil); err == nil {
+		t.Errorf("Expected error for unknown item")
+	}
+
+	if len(events) != 3 || hash.Epoch() != 4 {
+		t.Fatalf("Expected 3 events, but got: %v", events)
+	}
+	for i, op := range []ChangeOp{ChangeLabels, ChangeMetadata, ChangeCapacity} {
+		if c := events[i].Changes[0]; c.Op != op || c.Key != "10" {
+			t.Errorf("Expected %s change of 10, but got: %v", op, c)
+		}
+	}
+	if events[0].Changes[0].Labels["zone"] != "a" || hash.Labels("10")["zone"] != "a" {
+		t.Errorf("Expected labels to be updated, but got: %v", hash.Labels("10"))
+	}
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Tracks an exponentially weighted moving average of the latency of
+// each item, based on observations reported by the caller.
+type LatencyTracker struct {
+	mu    sync.RWMutex
+	alpha float64
+	ewma  map[string]float64
+}
+
+const defaultLatencyAlpha = 0.3
+
+// Creates a latency tracker. Alpha (between 0 and 1) is the weight given
+// to every new observation; if it's out of range 0.3 is used.
+func NewLatencyTracker(alpha float64) *LatencyTracker {
+	if alpha <= 0 || alpha > 1 {
+		alpha = defaultLatencyAlpha
+	}
+	return &LatencyTracker{alpha: alpha, ewma: make(map[string]float64)}
+}
+
+// Records the latency of a request to an item.
+func (t *LatencyTracker) Observe(key string, latency time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	prev, exists := t.ewma[key]
+	if !exists {
+		t.ewma[key] = float64(latency)
+		return
+	}
+	t.ewma[key] = t.alpha*float64(latency) + (1-t.alpha)*prev
+}
+
+// Returns the average latency of an item, and whether any latency was
+// observed for it at all.
+func (t *LatencyTracker) Latency(key string) (time.Duration, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	l, exists := t.ewma[key]
+	return time.Duration(l), exists
+}
+
+// Returns the average latency of an item in seconds, so the tracker can
+// be used as a LoadReporter.
+func (t *LatencyTracker) Load(key string) float64 {
+	l, _ := t.Latency(key)
+	return l.Seconds()
+}
+
+// Forgets all observations of an item.
+func (t *LatencyTracker) Forget(key string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.ewma, key)
+}
+
+// Sorts items by ascending latency. Items without observations are
+// considered fastest, so they receive traffic and get measured.
+func (t *LatencyTracker) Sort(items []string) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	sort.SliceStable(items, func(i, j int) bool {
+		return t.ewma[items[i]] < t.ewma[items[j]]
+	})
+}
+
+// Sets the latency tracker used by GetNByLatency.
+func (m *Map) SetLatencyTracker(t *LatencyTracker) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.latencies = t
+}
+
+// Gets the same items as GetN, but ordered by ascending latency rather
+// than by ring order. This is meant for read paths that can use any
+// replica. Without a latency tracker it behaves like GetN.
+//
+// The accept function must not call back into the hash, see GetN.
+func (m *Map) GetNByLatency(key string, n int, accept func([]string, string) bool) []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	out := m.getN(key, n, accept)
+	if m.latencies != nil {
+		m.latencies.Sort(out)
+	}
+	return out
+}
@@ -0,0 +1,60 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestLatencyTracker(t *testing.T) {
+	tracker := NewLatencyTracker(0.5)
+	if _, exists := tracker.Latency("A"); exists {
+		t.Errorf("Expected no latency before any observation")
+	}
+
+	tracker.Observe("A", 10*time.Millisecond)
+	tracker.Observe("A", 20*time.Millisecond)
+	if l, _ := tracker.Latency("A"); l != 15*time.Millisecond {
+		t.Errorf("Expected average latency of 15ms, but got: %s", l)
+	}
+
+	tracker.Forget("A")
+	if _, exists := tracker.Latency("A"); exists {
+		t.Errorf("Expected latency to be forgotten")
+	}
+}
+
+func TestGetNByLatency(t *testing.T) {
+	hash := New(1, intHash)
+	hash.AddString("10", "20", "30")
+
+	if res := hash.GetNByLatency("5", 3, nil); !reflect.DeepEqual(res, []string{"10", "20", "30"}) {
+		t.Errorf("Without tracker expected ring order, but got: %v", res)
+	}
+
+	tracker := NewLatencyTracker(0)
+	tracker.Observe("10", 30*time.Millisecond)
+	tracker.Observe("20", 10*time.Millisecond)
+	tracker.Observe("30", 20*time.Millisecond)
+	hash.SetLatencyTracker(tracker)
+
+	if res := hash.GetNByLatency("5", 2, nil); !reflect.DeepEqual(res, []string{"20", "10"}) {
+		t.Errorf("Expected replicas ordered by latency, but got: %v", res)
+	}
+}
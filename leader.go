@@ -0,0 +1,96 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"sync"
+)
+
+// Describes a range of the ring whose leadership was acquired or lost by
+// the local node.
+type LeadershipChange struct {
+	Range    HashRange
+	Acquired bool
+}
+
+// Treats the first owner of every range of the ring as the leader for
+// the keys in that range, for active/passive processing per key. The
+// local node is notified about every range it gains or loses.
+type Leadership struct {
+	m        *Map
+	self     string
+	onChange func([]LeadershipChange)
+	cancel   func()
+	mu       sync.Mutex
+	prev     *Map
+}
+
+// Creates a leadership tracker for the node named self. The onChange
+// callback is called after every membership change that affects the
+// ranges led by self; it must not modify the hash.
+func NewLeadership(m *Map, self string, onChange func([]LeadershipChange)) *Leadership {
+	l := &Leadership{m: m, self: self, onChange: onChange}
+
+	// Register first, so no change between taking the snapshot and
+	// registering the listener can get lost.
+	l.mu.Lock()
+	l.cancel = m.OnChange(func(ChangeEvent) { l.refresh() })
+	m.mu.RLock()
+	l.prev = m.clone()
+	m.mu.RUnlock()
+	l.mu.Unlock()
+	return l
+}
+
+// Returns true if node is the leader for key.
+func (l *Leadership) IsLeader(node, key string) bool {
+	owner := l.m.Get(key)
+	return owner != "" && owner == node
+}
+
+// Returns the ranges currently led by the local node.
+func (l *Leadership) Ranges() []HashRange {
+	l.m.mu.RLock()
+	defer l.m.mu.RUnlock()
+	return l.m.rangesOf(l.self)
+}
+
+func (l *Leadership) refresh() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.m.mu.RLock()
+	next := l.m.clone()
+	l.m.mu.RUnlock()
+
+	var changes []LeadershipChange
+	diffRanges(l.prev, next, func(r HashRange, from, to string) {
+		if from == l.self || to == l.self {
+			changes = append(changes, LeadershipChange{r, to == l.self})
+		}
+	})
+	l.prev = next
+
+	if len(changes) > 0 && l.onChange != nil {
+		l.onChange(changes)
+	}
+}
+
+// Stops following membership changes of the hash.
+func (l *Leadership) Close() {
+	l.cancel()
+}
@@ -0,0 +1,61 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLeadership(t *testing.T) {
+	hash := New(1, intHash)
+	hash.AddString("10", "30")
+
+	var changes []LeadershipChange
+	l := NewLeadership(hash, "30", func(c []LeadershipChange) {
+		changes = append(changes, c...)
+	})
+	defer l.Close()
+
+	if !l.IsLeader("30", "15") || l.IsLeader("10", "15") {
+		t.Errorf("Expected 30 to lead key 15")
+	}
+	if res := l.Ranges(); !reflect.DeepEqual(res, []HashRange{{11, 30}}) {
+		t.Errorf("Expected 30 to lead 11-30, but got: %v", res)
+	}
+
+	hash.AddString("20")
+	expected := []LeadershipChange{{HashRange{11, 20}, false}}
+	if !reflect.DeepEqual(changes, expected) {
+		t.Errorf("Expected %v, but got: %v", expected, changes)
+	}
+
+	changes = nil
+	hash.Del("10")
+	if len(changes) != 0 {
+		t.Errorf("Removing 10 should hand its ranges to 20, but got: %v", changes)
+	}
+
+	hash.Del("20")
+	expected = []LeadershipChange{
+		{HashRange{0, 20}, true},
+		{HashRange{31, 1<<32 - 1}, true},
+	}
+	if !reflect.DeepEqual(changes, expected) {
+		t.Errorf("Expected %v, but got: %v", expected, changes)
+	}
+}
@@ -0,0 +1,93 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// Returned by Leases.Get when the owner of a key holds no valid lease.
+var ErrLeaseExpired = errors.New("Lease of the owner has expired")
+
+// Leases on the ranges owned by the items of a hash. Owners must renew
+// their lease before it expires; ranges of owners without a valid lease
+// are considered unowned. Depending on the configuration this either
+// fails lookups (Get) or makes them fall through to the next owner with a
+// valid lease (by setting the leases as breaker registry of the hash).
+// This prevents an owner that is partitioned away from the rest of the
+// cluster from being written to after others took over its ranges.
+type Leases struct {
+	m        *Map
+	duration time.Duration
+	now      func() time.Time
+	mu       sync.Mutex
+	expires  map[string]time.Time
+}
+
+// Creates leases for the items of the hash that are valid for duration
+// after every renewal.
+func NewLeases(m *Map, duration time.Duration) *Leases {
+	return &Leases{m: m, duration: duration, now: time.Now, expires: make(map[string]time.Time)}
+}
+
+// Renews the lease of an item and returns when it expires.
+func (l *Leases) Renew(node string) time.Time {
+	expires := l.now().Add(l.duration)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.expires[node] = expires
+	return expires
+}
+
+// Revokes the lease of an item, e.g. when it shuts down gracefully.
+func (l *Leases) Revoke(node string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.expires, node)
+}
+
+// Returns true if the item holds a valid lease.
+func (l *Leases) Valid(node string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	expires, exists := l.expires[node]
+	return exists && l.now().Before(expires)
+}
+
+// Implements BreakerRegistry, treating items without a valid lease as
+// open. Use it as breaker registry of the hash to let lookups fall
+// through to the next owner with a valid lease.
+func (l *Leases) IsOpen(node string) bool {
+	return !l.Valid(node)
+}
+
+// Gets the owner of key, or ErrLeaseExpired along with the owner if the
+// owner holds no valid lease.
+func (l *Leases) Get(key string) (string, error) {
+	owner := l.m.Get(key)
+	if owner == "" {
+		return "", ErrEmptyRing
+	}
+	if !l.Valid(owner) {
+		return owner, ErrLeaseExpired
+	}
+	return owner, nil
+}
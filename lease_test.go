@@ -0,0 +1,65 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLeases(t *testing.T) {
+	hash := New(1, intHash)
+	hash.AddString("10", "20")
+
+	now := time.Unix(1000, 0)
+	leases := NewLeases(hash, 10*time.Second)
+	leases.now = func() time.Time { return now }
+
+	if _, err := leases.Get("15"); err != ErrLeaseExpired {
+		t.Errorf("Expected ErrLeaseExpired without a lease, but got: %v", err)
+	}
+
+	if expires := leases.Renew("20"); !expires.Equal(now.Add(10 * time.Second)) {
+		t.Errorf("Unexpected expiry: %s", expires)
+	}
+	leases.Renew("10")
+	if owner, err := leases.Get("15"); owner != "20" || err != nil {
+		t.Errorf("Expected 20 without error, but got: %s, %v", owner, err)
+	}
+
+	now = now.Add(5 * time.Second)
+	leases.Renew("10")
+	now = now.Add(5 * time.Second)
+	if owner, err := leases.Get("15"); owner != "20" || err != ErrLeaseExpired {
+		t.Errorf("Expected expired lease of 20, but got: %s, %v", owner, err)
+	}
+
+	// Fall through to the next owner with a valid lease.
+	hash.SetBreakerRegistry(leases)
+	if owner := hash.Get("15"); owner != "10" {
+		t.Errorf("Expected lookup to fall through to 10, but got: %s", owner)
+	}
+
+	leases.Revoke("10")
+	if owner := hash.Get("15"); owner != "" {
+		t.Errorf("Expected no owner without valid leases, but got: %s", owner)
+	}
+
+	if _, err := NewLeases(New(1, nil), time.Second).Get("15"); err != ErrEmptyRing {
+		t.Errorf("Expected ErrEmptyRing, but got: %v", err)
+	}
+}
@@ -0,0 +1,59 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+// Reports the current load of an item, in arbitrary but consistent
+// units (e.g. in-flight requests or CPU utilization). Load may be
+// called during lookups with the hash locked for reading, so it must not
+// call back into the hash.
+type LoadReporter interface {
+	Load(key string) float64
+}
+
+// A LoadReporter backed by a function.
+type LoadReporterFunc func(key string) float64
+
+func (f LoadReporterFunc) Load(key string) float64 {
+	return f(key)
+}
+
+// Sets the reporter used to retrieve the load of items.
+func (m *Map) SetLoadReporter(loads LoadReporter) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.loads = loads
+}
+
+// Gets the less loaded of the two closest distinct items to the provided
+// key ("power of two choices"). This trades a little affinity for better
+// tail latency under skew. Without a load reporter it behaves like Get.
+func (m *Map) GetBalanced(key string) string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	candidates := m.getN(key, 2, AcceptUnique)
+	if len(candidates) == 0 {
+		return ""
+	}
+	if len(candidates) == 1 || m.loads == nil {
+		return candidates[0]
+	}
+	if m.loads.Load(candidates[1]) < m.loads.Load(candidates[0]) {
+		return candidates[1]
+	}
+	return candidates[0]
+}
@@ -0,0 +1,44 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"testing"
+)
+
+func TestGetBalanced(t *testing.T) {
+	hash := New(1, intHash)
+	if res := hash.GetBalanced("5"); res != "" {
+		t.Errorf("Expected nothing from an empty ring, but got: %s", res)
+	}
+
+	hash.AddString("10", "20", "30")
+	if res := hash.GetBalanced("5"); res != "10" {
+		t.Errorf("Without load reporter entry 5 should map to 10, but instead got: %s", res)
+	}
+
+	loads := map[string]float64{"10": 5, "20": 1, "30": 0}
+	hash.SetLoadReporter(LoadReporterFunc(func(key string) float64 { return loads[key] }))
+	if res := hash.GetBalanced("5"); res != "20" {
+		t.Errorf("Entry 5 should map to less loaded 20, but instead got: %s", res)
+	}
+
+	loads["20"] = 5
+	if res := hash.GetBalanced("5"); res != "10" {
+		t.Errorf("Ties should favour the first owner, but instead got: %s", res)
+	}
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+// Receives lifecycle events of a hash: membership changes, health
+// transitions and rebalancing. Arguments are alternating keys and values.
+// *slog.Logger implements this interface.
+type Logger interface {
+	Info(msg string, args ...interface{})
+	Warn(msg string, args ...interface{})
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Info(string, ...interface{}) {}
+func (nopLogger) Warn(string, ...interface{}) {}
+
+// Sets the logger that receives the lifecycle events of the hash, e.g.
+// WithLogger(slog.Default()). By default events are not logged.
+func WithLogger(logger Logger) Option {
+	return func(m *Map) {
+		m.logger = logger
+	}
+}
+
+// Sets the logger that receives the lifecycle events of the hash, or
+// disables logging if nil.
+func (m *Map) SetLogger(logger Logger) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.logger = logger
+}
+
+// Returns the logger of the hash. The caller must hold at least a read
+// lock.
+func (m *Map) log() Logger {
+	if m.logger == nil {
+		return nopLogger{}
+	}
+	return m.logger
+}
+
+// Returns the logger of the hash, for callers that don't hold the lock.
+func (m *Map) currentLogger() Logger {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.log()
+}
+
+// Logs the changes that made up an epoch.
+func logChanges(logger Logger, epoch uint64, changes []Change) {
+	for _, c := range changes {
+		switch {
+		case c.Op == ChangeDown:
+			logger.Warn("Node marked down", "epoch", epoch, "node", c.Key)
+			continue
+		case c.Op == ChangeUp:
+			logger.Info("Node marked up", "epoch", epoch, "node", c.Key)
+			continue
+		case c.Op == ChangeQuarantine && c.Until.IsZero():
+			logger.Info("Node quarantine lifted", "epoch", epoch, "node", c.Key)
+			continue
+		case c.Op == ChangeQuarantine:
+			logger.Warn("Node quarantined", "epoch", epoch, "node", c.Key, "until", c.Until)
+			continue
+		}
+
+		args := []interface{}{"epoch", epoch, "op", c.Op.String(), "node", c.Key}
+		switch c.Op {
+		case ChangeAdd, ChangeWeight:
+			args = append(args, "weight", c.Weight)
+		case ChangeAddVirtualNode, ChangeRemoveVirtualNode:
+			args = append(args, "tokens", c.Tokens)
+		case ChangeLabels:
+			args = append(args, "labels", c.Labels)
+		case ChangeMetadata:
+			args = append(args, "metadata", c.Metadata)
+		case ChangeCapacity:
+			args = append(args, "capacity", c.Capacity)
+		}
+		logger.Info("Ring membership changed", args...)
+	}
+}
@@ -0,0 +1,54 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+)
+
+func TestLogger(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{
+		ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
+
+	hash := New(1, intHash, WithLogger(logger))
+	hash.AddString("10")
+	hash.AddVirtualNode("10", 50)
+	hash.MarkDown("10")
+	hash.MarkDown("10")
+	hash.MarkUp("10")
+	hash.MarkUp("10")
+	hash.SetLogger(nil)
+	hash.Del("10")
+
+	expected := `level=INFO msg="Ring membership changed" epoch=1 op=add node=10 weight=1
+level=INFO msg="Ring membership changed" epoch=2 op=add-vnode node=10 tokens=[50]
+level=WARN msg="Node marked down" epoch=3 node=10
+level=INFO msg="Node marked up" epoch=4 node=10
+`
+	if buf.String() != expected {
+		t.Errorf("Expected:\n%s\nbut got:\n%s", expected, buf.String())
+	}
+}
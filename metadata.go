@@ -0,0 +1,96 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+// An EntryValue that carries arbitrary metadata (e.g. host, port or TLS
+// settings), which is attached to the item when it is added to the hash.
+type MetadataValue interface {
+	EntryValue
+	Metadata() map[string]interface{}
+}
+
+// A general purpose item that carries labels and metadata.
+type Node struct {
+	ID   string
+	Tags map[string]string
+	Meta map[string]interface{}
+}
+
+func (n *Node) HashRingId() string               { return n.ID }
+func (n *Node) Labels() map[string]string        { return n.Tags }
+func (n *Node) Metadata() map[string]interface{} { return n.Meta }
+
+func metadataOf(value EntryValue) map[string]interface{} {
+	v, ok := value.(MetadataValue)
+	if !ok {
+		return nil
+	}
+	return copyMetadata(v.Metadata())
+}
+
+func copyMetadata(meta map[string]interface{}) map[string]interface{} {
+	if meta == nil {
+		return nil
+	}
+	out := make(map[string]interface{}, len(meta))
+	for k, v := range meta {
+		out[k] = v
+	}
+	return out
+}
+
+// Adds an item to the hash along with metadata. The metadata is merged
+// with any metadata provided by the value itself, and takes precedence
+// over it.
+func (m *Map) AddWithMetadata(entryValue EntryValue, weight int, meta map[string]interface{}) error {
+	c := AddChange(entryValue, weight)
+	c.Metadata = metadataOf(entryValue)
+	for k, v := range meta {
+		if c.Metadata == nil {
+			c.Metadata = make(map[string]interface{}, len(meta))
+		}
+		c.Metadata[k] = v
+	}
+	return m.applyInPlace(c)
+}
+
+// Replaces the metadata of an item.
+func (m *Map) SetMetadata(key string, meta map[string]interface{}) error {
+	return m.applyInPlace(MetadataChange(key, meta))
+}
+
+// Returns a copy of the metadata of an item.
+func (m *Map) Metadata(key string) map[string]interface{} {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if e, exists := m.entries[key]; exists {
+		return copyMetadata(e.meta)
+	}
+	return nil
+}
+
+// Returns a single metadata value of an item.
+func (m *Map) MetadataValue(key, name string) (interface{}, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	e, exists := m.entries[key]
+	if !exists {
+		return nil, false
+	}
+	v, exists := e.meta[name]
+	return v, exists
+}
@@ -0,0 +1,65 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"testing"
+)
+
+func TestMetadata(t *testing.T) {
+	hash := New(1, intHash)
+	hash.AddWithWeight(&Node{ID: "10", Meta: map[string]interface{}{"port": 11211}}, 1)
+	hash.AddWithMetadata(&StringValue{"20"}, 1, map[string]interface{}{"host": "cache-2", "tls": true})
+
+	if port, _ := hash.MetadataValue("10", "port"); port != 11211 {
+		t.Errorf("Expected port of 10 to be 11211, but got: %v", port)
+	}
+	if meta := hash.Metadata(hash.Get("15")); meta["host"] != "cache-2" || meta["tls"] != true {
+		t.Errorf("Expected metadata of 20, but got: %v", meta)
+	}
+
+	hash.SetMetadata("20", map[string]interface{}{"host": "cache-3"})
+	hash.Apply(WeightChange("20", 2))
+	if host, _ := hash.MetadataValue("20", "host"); host != "cache-3" {
+		t.Errorf("Expected updated metadata to be preserved, but got: %v", host)
+	}
+	if _, exists := hash.MetadataValue("30", "host"); exists {
+		t.Errorf("Expected no metadata for unknown item")
+	}
+	if err := hash.AddWithMetadata(&StringValue{"20"}, 1, nil); err == nil {
+		t.Errorf("Expected error, but it wasn't returned")
+	}
+}
+
+func TestAddWithMetadataMerges(t *testing.T) {
+	hash := New(1, intHash)
+	var events []ChangeEvent
+	hash.OnChange(func(event ChangeEvent) { events = append(events, event) })
+
+	hash.AddWithMetadata(&Node{ID: "10", Meta: map[string]interface{}{"host": "a", "port": 80}}, 1, nil)
+	hash.AddWithMetadata(&Node{ID: "20", Meta: map[string]interface{}{"host": "b", "port": 80}}, 1, map[string]interface{}{"port": 81})
+
+	if meta := hash.Metadata("10"); meta["host"] != "a" || meta["port"] != 80 {
+		t.Errorf("Expected the metadata of the value to be kept, but got: %v", meta)
+	}
+	if meta := hash.Metadata("20"); meta["host"] != "b" || meta["port"] != 81 {
+		t.Errorf("Expected the metadata to be merged, but got: %v", meta)
+	}
+	if len(events) != 2 || events[1].Changes[0].Metadata["port"] != 81 {
+		t.Errorf("Expected the change to carry the metadata, but got: %v", events)
+	}
+}
@@ -0,0 +1,143 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"bufio"
+	"io"
+	"sort"
+)
+
+// A source of keys, such as a *bufio.Scanner.
+type KeySource interface {
+	Scan() bool
+	Text() string
+	Err() error
+}
+
+type sliceSource struct {
+	keys []string
+	pos  int
+}
+
+func (s *sliceSource) Scan() bool {
+	if s.pos >= len(s.keys) {
+		return false
+	}
+	s.pos++
+	return true
+}
+
+func (s *sliceSource) Text() string { return s.keys[s.pos-1] }
+func (s *sliceSource) Err() error   { return nil }
+
+// Returns a key source that yields the provided keys.
+func KeysFromSlice(keys []string) KeySource {
+	return &sliceSource{keys: keys}
+}
+
+// Returns a key source that yields one key per line read from r.
+func KeysFromReader(r io.Reader) KeySource {
+	return bufio.NewScanner(r)
+}
+
+// A key whose owner differs between two rings.
+type KeyMove struct {
+	Key  string
+	From string
+	To   string
+}
+
+// Iterates over the keys of a key source whose owner changed between an
+// old and a new ring. Moves are batched per destination, so a data mover
+// can copy each batch to its new owner in one go.
+type MigrationIterator struct {
+	old, next *Map
+	source    KeySource
+	batchSize int
+	pending   map[string][]KeyMove
+	flushing  []string
+	batchTo   string
+	batch     []KeyMove
+}
+
+// Creates an iterator over the keys from source that move from old to
+// next, emitting batches of at most batchSize moves.
+func NewMigrationIterator(old, next *Map, source KeySource, batchSize int) *MigrationIterator {
+	if batchSize < 1 {
+		batchSize = 1
+	}
+	return &MigrationIterator{
+		old:       old,
+		next:      next,
+		source:    source,
+		batchSize: batchSize,
+		pending:   make(map[string][]KeyMove),
+	}
+}
+
+// Advances to the next batch. Returns false once all keys have been
+// consumed, or if the key source returned an error.
+func (it *MigrationIterator) Next() bool {
+	for it.flushing == nil && it.source.Scan() {
+		key := it.source.Text()
+		from, to := it.old.Get(key), it.next.Get(key)
+		if from == to {
+			continue
+		}
+
+		it.pending[to] = append(it.pending[to], KeyMove{key, from, to})
+		if len(it.pending[to]) >= it.batchSize {
+			it.batchTo, it.batch = to, it.pending[to]
+			delete(it.pending, to)
+			return true
+		}
+	}
+
+	if it.source.Err() != nil {
+		return false
+	}
+
+	// The source is exhausted, emit the incomplete batches.
+	if it.flushing == nil {
+		it.flushing = make([]string, 0, len(it.pending))
+		for to := range it.pending {
+			it.flushing = append(it.flushing, to)
+		}
+		sort.Strings(it.flushing)
+	}
+	if len(it.flushing) == 0 {
+		it.batchTo, it.batch = "", nil
+		return false
+	}
+
+	it.batchTo = it.flushing[0]
+	it.batch = it.pending[it.batchTo]
+	it.flushing = it.flushing[1:]
+	delete(it.pending, it.batchTo)
+	return true
+}
+
+// Returns the destination and moves of the current batch.
+func (it *MigrationIterator) Batch() (to string, moves []KeyMove) {
+	return it.batchTo, it.batch
+}
+
+// Returns the error returned by the key source, if any.
+func (it *MigrationIterator) Err() error {
+	return it.source.Err()
+}
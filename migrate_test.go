@@ -0,0 +1,67 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMigrationIterator(t *testing.T) {
+	old := New(1, intHash)
+	old.AddString("10", "30")
+	next := New(1, intHash)
+	next.AddString("10", "20", "30", "40")
+
+	source := KeysFromReader(strings.NewReader("5\n11\n12\n13\n25\n35\n36\n"))
+	it := NewMigrationIterator(old, next, source, 2)
+
+	var batches []string
+	moved := 0
+	for it.Next() {
+		to, moves := it.Batch()
+		for _, move := range moves {
+			if move.To != to || move.From == to {
+				t.Errorf("Unexpected move in batch for %s: %v", to, move)
+			}
+		}
+		batches = append(batches, to)
+		moved += len(moves)
+	}
+	if it.Err() != nil {
+		t.Fatalf("Unexpected error: %s", it.Err())
+	}
+
+	// 11, 12 and 13 move to 20, 35 and 36 move to 40.
+	if moved != 5 {
+		t.Errorf("Expected 5 keys to move, but got: %d", moved)
+	}
+	expected := []string{"20", "40", "20"}
+	if strings.Join(batches, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected batches for %v, but got: %v", expected, batches)
+	}
+}
+
+func TestMigrationIteratorNoMoves(t *testing.T) {
+	old := New(1, intHash)
+	old.AddString("10")
+
+	it := NewMigrationIterator(old, old, KeysFromSlice([]string{"1", "2"}), 10)
+	if it.Next() {
+		t.Errorf("Expected no batches when rings are identical")
+	}
+}
@@ -0,0 +1,83 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"math"
+	"strconv"
+)
+
+// Returns the relative popularity of the rank-th most popular key,
+// where rank starts at 0.
+type Popularity func(rank int) float64
+
+// Returns a popularity distribution where every key is equally popular.
+func UniformPopularity() Popularity {
+	return func(int) float64 { return 1 }
+}
+
+// Returns a Zipf popularity distribution with exponent s, under which
+// the rank-th key is requested proportionally to 1/(rank+1)^s.
+func ZipfPopularity(s float64) Popularity {
+	return func(rank int) float64 { return 1 / math.Pow(float64(rank+1), s) }
+}
+
+// The expected load of each item under a synthetic key distribution.
+type LoadModel struct {
+	// Fraction of all requests expected to hit each item.
+	Load map[string]float64
+
+	// Load of the busiest item divided by the mean load.
+	PeakToMean float64
+}
+
+// Evaluates the ring against a synthetic set of keys ("key-0" being the
+// most popular, up to "key-<keys-1>") with the provided popularity, and
+// reports the expected load per item. This shows the effect of hot keys
+// before they hit production. Returns ErrNotEnoughNodes if a key has no
+// available owner.
+func (m *Map) ModelLoad(keys int, popularity Popularity) (LoadModel, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	model := LoadModel{Load: make(map[string]float64, len(m.entries))}
+	if m.isEmpty() || keys < 1 {
+		return model, nil
+	}
+	for key := range m.entries {
+		model.Load[key] = 0
+	}
+
+	var total float64
+	for rank := 0; rank < keys; rank++ {
+		owner := m.get("key-" + strconv.Itoa(rank))
+		if owner == "" {
+			return LoadModel{}, ErrNotEnoughNodes
+		}
+		p := popularity(rank)
+		model.Load[owner] += p
+		total += p
+	}
+
+	var peak float64
+	for key, load := range model.Load {
+		model.Load[key] = load / total
+		peak = math.Max(peak, load/total)
+	}
+	model.PeakToMean = peak * float64(len(model.Load))
+	return model, nil
+}
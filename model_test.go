@@ -0,0 +1,58 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"math"
+	"testing"
+)
+
+func TestModelLoad(t *testing.T) {
+	hash := New(100, nil)
+	hash.AddString("A", "B", "C", "D")
+
+	uniform, _ := hash.ModelLoad(10000, UniformPopularity())
+	var total float64
+	for _, load := range uniform.Load {
+		total += load
+	}
+	if math.Abs(total-1) > 1e-9 {
+		t.Errorf("Expected loads to add up to 1, but got: %f", total)
+	}
+	if uniform.PeakToMean > 1.3 {
+		t.Errorf("Expected uniform keys to be balanced, but peak to mean is: %f", uniform.PeakToMean)
+	}
+
+	skewed, _ := hash.ModelLoad(10000, ZipfPopularity(1.5))
+	if skewed.PeakToMean <= uniform.PeakToMean {
+		t.Errorf("Expected zipf keys to be less balanced than uniform keys")
+	}
+	if hot := hash.Get("key-0"); skewed.Load[hot] < 0.38 {
+		t.Errorf("Expected owner of the hottest key to receive its load, but got: %f", skewed.Load[hot])
+	}
+
+	if res, err := New(1, nil).ModelLoad(10, UniformPopularity()); err != nil || len(res.Load) != 0 {
+		t.Errorf("Expected no load for an empty ring, but got: %v, %v", res.Load, err)
+	}
+
+	for _, key := range []string{"A", "B", "C", "D"} {
+		hash.MarkDown(key)
+	}
+	if _, err := hash.ModelLoad(10, UniformPopularity()); err != ErrNotEnoughNodes {
+		t.Errorf("Expected ErrNotEnoughNodes with every item down, but got: %v", err)
+	}
+}
@@ -0,0 +1,57 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"testing"
+)
+
+func TestMurmur3(t *testing.T) {
+	// Reference values from the canonical C++ implementation.
+	testCases := map[string]uint32{
+		"":             0,
+		"a":            0x3c2569b2,
+		"hello":        0x248bfa47,
+		"hello, world": 0x149bbb7f,
+		"The quick brown fox jumps over the lazy dog": 0x2e4ff723,
+	}
+	for k, v := range testCases {
+		if res := Murmur3([]byte(k)); res != v {
+			t.Errorf("Murmur3(%q) should be %#x, but got: %#x", k, v, res)
+		}
+	}
+}
+
+func TestMurmur3x128(t *testing.T) {
+	testCases := map[string][2]uint64{
+		"":      {0, 0},
+		"hello": {0xcbd8a7b341bd9b02, 0x5b1e906a48ae1d19},
+		"The quick brown fox jumps over the lazy dog": {0xe34bbc7bbc071b6c, 0x7a433ca9c49a9347},
+	}
+	for k, v := range testCases {
+		h1, h2 := murmur3x64_128([]byte(k), 0)
+		if h1 != v[0] || h2 != v[1] {
+			t.Errorf("murmur3x64_128(%q) should be %#x %#x, but got: %#x %#x", k, v[0], v[1], h1, h2)
+		}
+	}
+
+	hash := New(50, Murmur3x128)
+	hash.AddString("A", "B", "C")
+	if res := hash.Get("foo"); res == "" {
+		t.Errorf("Expected Murmur3x128 to be usable as hash function")
+	}
+}
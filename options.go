@@ -0,0 +1,131 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"fmt"
+	"hash/crc32"
+	"math/rand"
+)
+
+// Configures optional behaviour of a hash, see New.
+type Option func(*Map)
+
+// The direction in which the ring is walked during lookups.
+type Direction int
+
+const (
+	// Keys map to the first replica at or after their position, and
+	// replicas are selected in ascending order. This is the default.
+	Clockwise Direction = iota
+
+	// Keys map to the first replica at or before their position, and
+	// replicas are selected in descending order.
+	Counterclockwise
+)
+
+// Sets the direction in which the ring is walked. This allows to preserve
+// key placement when migrating from systems that walk the ring
+// counterclockwise.
+func WithDirection(direction Direction) Option {
+	return func(m *Map) {
+		m.direction = direction
+	}
+}
+
+// Returns the label that is hashed to determine the position of the i-th
+// virtual node of an item.
+type LabelFunc func(key string, i int) string
+
+// Sets the function used to label virtual nodes. By default the label is
+// the index followed by the key (e.g. "0key"), which matches the
+// original Groupcache implementation.
+func WithLabelFunc(fn LabelFunc) Option {
+	return func(m *Map) {
+		m.label = fn
+	}
+}
+
+// Labels virtual nodes using a fmt format string, which receives the key
+// and the index, in that order. E.g. "%s#%d" produces "key#0", while
+// "%[2]d%[1]s" reproduces the default labels.
+func WithLabelFormat(format string) Option {
+	return WithLabelFunc(func(key string, i int) string {
+		return fmt.Sprintf(format, key, i)
+	})
+}
+
+// Mixes a seed into the hash of every virtual node and key. Rings with
+// the same items but different seeds place keys independently of each
+// other, which is useful to build independent replica groups.
+//
+// The seed is mixed into the output of the hash function (rather than
+// its input), because prefixing the input has a predictable effect on
+// linear hashes such as crc32.
+func WithSeed(seed string) Option {
+	return func(m *Map) {
+		m.seeded = true
+		m.seed = crc32.ChecksumIEEE([]byte(seed))
+	}
+}
+
+// Sets the strategy that selects replicas in GetN and its variants, see
+// SetPlacementStrategy. E.g. WithPlacementStrategy(ProportionalStrategy{})
+// spreads secondary replicas proportionally to weight.
+func WithPlacementStrategy(strategy PlacementStrategy) Option {
+	return func(m *Map) {
+		m.placement = strategy
+	}
+}
+
+// Seeds the random numbers GetAnyReplica uses to pick a replica, so that
+// the sequence of picks is reproducible.
+func WithReplicaSeed(seed int64) Option {
+	return func(m *Map) {
+		m.replicaRand = &lockedRand{rnd: rand.New(rand.NewSource(seed))}
+	}
+}
+
+// The finalizer of Murmur3, which ensures every bit of the input affects
+// every bit of the output.
+func fmix32(h uint32) uint32 {
+	h ^= h >> 16
+	h *= 0x85ebca6b
+	h ^= h >> 13
+	h *= 0xc2b2ae35
+	h ^= h >> 16
+	return h
+}
+
+// Derives the positions of virtual nodes through double hashing, as
+// h1 + i*h2 where h1 is the hash of the key and h2 is derived from h1,
+// instead of hashing a label per virtual node. This avoids building a
+// string per virtual node, and avoids the accidental clustering that the
+// similar labels of an item can cause with linear hashes such as crc32.
+// Label options are ignored.
+func WithDoubleHashing() Option {
+	return func(m *Map) {
+		m.doubleHash = true
+	}
+}
+
+// Returns the two hashes used for double hashing. The second one is
+// always odd, so that consecutive virtual nodes never coincide.
+func (m *Map) doubleHashes(key string) (uint32, uint32) {
+	h1 := uint32(m.hashString(key))
+	return h1, fmix32(h1^0x9e3779b9) | 1
+}
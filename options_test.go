@@ -0,0 +1,135 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestCounterclockwise(t *testing.T) {
+	hash := New(1, intHash, WithDirection(Counterclockwise))
+	hash.AddString("10", "20", "30")
+
+	testCases := map[string]string{
+		"5":  "30",
+		"10": "10",
+		"15": "10",
+		"29": "20",
+		"35": "30",
+	}
+	for k, v := range testCases {
+		if res := hash.Get(k); res != v {
+			t.Errorf("Asking for %s, should have yielded %s, but got: %s", k, v, res)
+		}
+	}
+
+	if res := hash.GetN("15", 3, nil); !reflect.DeepEqual(res, []string{"10", "30", "20"}) {
+		t.Errorf("Expected replicas in counterclockwise order, but got: %v", res)
+	}
+
+	if res := hash.rangesOf("30"); !reflect.DeepEqual(res, []HashRange{{30, 1<<32 - 1}, {0, 9}}) {
+		t.Errorf("Unexpected ranges for 30: %v", res)
+	}
+
+	res, _ := hash.EstimateMovement(RemoveChange("20"))
+	if expected := 10 / ringSize; res != expected {
+		t.Errorf("Expected removal of 20 to move %f, but moved: %f", expected, res)
+	}
+}
+
+func TestLabelFormat(t *testing.T) {
+	// Label virtual nodes as "<key>00<i>", which are valid integers.
+	hash := New(2, intHash, WithLabelFormat("%s00%d"))
+	hash.AddString("1", "2")
+
+	var positions []uint32
+	for pos := range hash.All() {
+		positions = append(positions, pos)
+	}
+	if !reflect.DeepEqual(positions, []uint32{1000, 1001, 2000, 2001}) {
+		t.Errorf("Unexpected virtual node positions: %v", positions)
+	}
+
+	hash.Del("1")
+	if len(hash.hashMap) != 2 {
+		t.Errorf("Expected ring to have 2 elements, but it's got: %d", len(hash.hashMap))
+	}
+
+	def := New(3, nil)
+	compat := New(3, nil, WithLabelFormat("%[2]d%[1]s"))
+	def.AddString("A", "B", "C")
+	compat.AddString("A", "B", "C")
+	for _, key := range []string{"1", "132", "foo", "bar"} {
+		if def.Get(key) != compat.Get(key) {
+			t.Errorf("Expected %s to map identically with default labels", key)
+		}
+	}
+}
+
+func TestSeed(t *testing.T) {
+	nodes := []string{"A", "B", "C", "D", "E"}
+	plain := New(50, nil)
+	plain.AddString(nodes...)
+	seeded := New(50, nil, WithSeed("group-2"))
+	seeded.AddString(nodes...)
+	again := New(50, nil, WithSeed("group-2"))
+	again.AddString(nodes...)
+
+	same := 0
+	for i := 0; i < 1000; i++ {
+		key := strconv.Itoa(i)
+		if plain.Get(key) == seeded.Get(key) {
+			same++
+		}
+		if seeded.Get(key) != again.Get(key) {
+			t.Fatalf("Expected rings with the same seed to map %s identically", key)
+		}
+	}
+
+	// Independent placement maps about 1 in 5 keys to the same item.
+	if same > 300 {
+		t.Errorf("Expected seeded ring to place keys independently, but %d of 1000 matched", same)
+	}
+}
+
+func TestDoubleHashing(t *testing.T) {
+	hash := New(100, nil, WithDoubleHashing())
+	hash.AddString("A", "B", "C", "D")
+
+	tokens, _ := hash.Tokens("A")
+	h1, h2 := hash.doubleHashes("A")
+	for i, token := range tokens {
+		if token != h1+uint32(i)*h2 {
+			t.Fatalf("Expected token %d to be derived through double hashing, but got: %d", i, token)
+		}
+	}
+	if len(hash.hashMap) != 400 {
+		t.Errorf("Expected ring to have 400 elements, but it's got: %d", len(hash.hashMap))
+	}
+
+	model, _ := hash.ModelLoad(10000, UniformPopularity())
+	if model.PeakToMean > 1.3 {
+		t.Errorf("Expected double hashing to balance keys, but peak to mean is: %f", model.PeakToMean)
+	}
+
+	hash.Del("A")
+	if len(hash.hashMap) != 300 {
+		t.Errorf("Expected ring to have 300 elements, but it's got: %d", len(hash.hashMap))
+	}
+}
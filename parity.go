@@ -0,0 +1,50 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"hash/crc32"
+)
+
+// Creates a hash in strict compatibility mode, which maps keys exactly
+// like the reference algorithm below. Implementations in other languages
+// that follow it make identical key to item decisions; reference vectors
+// are provided in testdata/parity_vectors.json.
+//
+//  1. All strings are encoded as UTF-8 without a terminator.
+//  2. The hash function is CRC-32 (IEEE polynomial 0xedb88320, as used by
+//     zlib, Java's java.util.zip.CRC32 and PHP's crc32), interpreted as an
+//     unsigned 32-bit integer.
+//  3. An item with weight w has w virtual nodes. The label of virtual node
+//     i (0 <= i < w) is the decimal representation of i, without leading
+//     zeros, followed by the item's name: "0A", "1A", ...
+//  4. The position of a virtual node is the hash of its label. When
+//     virtual nodes of different items share a position, the item whose
+//     name sorts first (bytewise) owns it, regardless of the order in which
+//     the items were added.
+//  5. A key maps to the virtual node with the smallest position that is
+//     greater than or equal to the hash of the key, wrapping around to the
+//     smallest position of the ring if there is none.
+//  6. Replicas (GetN) are found by visiting the following positions in
+//     ascending order, wrapping around, the first position being accepted
+//     unconditionally.
+//
+// The replicas in the reference vectors are the result of GetN with
+// AcceptUnique, i.e. the first distinct items visited.
+func NewParity(defaultWeight int) *Map {
+	return New(defaultWeight, crc32.ChecksumIEEE, WithCompat(CompatV1))
+}
@@ -0,0 +1,43 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+)
+
+func TestParityVectors(t *testing.T) {
+	data, err := os.ReadFile("testdata/parity_vectors.json")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	var vectors VectorSet
+	if err := json.Unmarshal(data, &vectors); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	hash := NewParity(0)
+	for _, node := range vectors.Nodes {
+		hash.AddStringWithWeight(node.ID, node.Weight)
+	}
+	for _, v := range hash.VerifyVectors(&vectors) {
+		t.Errorf("Asking for %q yielded %s and %s, which does not match the reference", v.Key, v.Owner, v.Replicas)
+	}
+}
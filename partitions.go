@@ -0,0 +1,82 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"sort"
+	"strconv"
+)
+
+// Assigns partitions of a consumer group to its members through the
+// ring. Compared to range or round-robin assignment, only the partitions
+// of a member that leaves (or that are taken over by a member that
+// joins) move.
+//
+// The plan returned by Assign has the same shape as a Kafka balance
+// strategy plan (member, topic, partitions), so it can back a custom
+// sarama or franz-go balance strategy with a few lines of glue code.
+type PartitionAssigner struct {
+	weight int
+	hash   Hash
+}
+
+// Creates an assigner that places every member on the ring with the
+// provided weight, using the provided hash function (crc32 if nil).
+func NewPartitionAssigner(weight int, fn Hash) *PartitionAssigner {
+	return &PartitionAssigner{weight: weight, hash: fn}
+}
+
+// Returns the name of the strategy, as used in group protocol metadata.
+func (a *PartitionAssigner) Name() string {
+	return "consistenthash"
+}
+
+// Assigns the partitions of each topic to the members subscribed to it.
+// The subscriptions map each member to the topics it consumes, topics
+// map each topic to its partitions.
+func (a *PartitionAssigner) Assign(subscriptions map[string][]string, topics map[string][]int32) map[string]map[string][]int32 {
+	members := make(map[string][]string)
+	for member, subscribed := range subscriptions {
+		for _, topic := range subscribed {
+			members[topic] = append(members[topic], member)
+		}
+	}
+
+	plan := make(map[string]map[string][]int32)
+	for topic, partitions := range topics {
+		if len(members[topic]) == 0 {
+			continue
+		}
+
+		ring := New(a.weight, a.hash)
+		ring.AddString(members[topic]...)
+		for _, partition := range partitions {
+			owner := ring.Get(topic + "/" + strconv.Itoa(int(partition)))
+			if plan[owner] == nil {
+				plan[owner] = make(map[string][]int32)
+			}
+			plan[owner][topic] = append(plan[owner][topic], partition)
+		}
+	}
+
+	for _, assigned := range plan {
+		for _, partitions := range assigned {
+			sort.Slice(partitions, func(i, j int) bool { return partitions[i] < partitions[j] })
+		}
+	}
+	return plan
+}
@@ -0,0 +1,64 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"testing"
+)
+
+func countPartitions(plan map[string]map[string][]int32, topic string) int {
+	total := 0
+	for _, assigned := range plan {
+		total += len(assigned[topic])
+	}
+	return total
+}
+
+func TestPartitionAssigner(t *testing.T) {
+	a := NewPartitionAssigner(50, nil)
+	topics := map[string][]int32{"orders": {0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11}, "other": {0}}
+
+	before := a.Assign(map[string][]string{
+		"c1": {"orders"},
+		"c2": {"orders"},
+		"c3": {"orders"},
+	}, topics)
+	if countPartitions(before, "orders") != 12 {
+		t.Errorf("Expected all 12 partitions to be assigned, but got: %v", before)
+	}
+	if countPartitions(before, "other") != 0 {
+		t.Errorf("Expected partitions without subscribers to remain unassigned")
+	}
+
+	after := a.Assign(map[string][]string{
+		"c1": {"orders"},
+		"c2": {"orders"},
+	}, topics)
+
+	// Only the partitions of the member that left should move.
+	for _, member := range []string{"c1", "c2"} {
+		kept := map[int32]bool{}
+		for _, p := range after[member]["orders"] {
+			kept[p] = true
+		}
+		for _, p := range before[member]["orders"] {
+			if !kept[p] {
+				t.Errorf("Partition %d should have stayed with %s", p, member)
+			}
+		}
+	}
+}
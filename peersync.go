@@ -0,0 +1,322 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"math/rand"
+	"reflect"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// The version of the state of a member. Versions are ordered by counter
+// first and by origin second, so concurrent changes of the same member
+// are resolved identically by every peer.
+type Version struct {
+	Counter uint64 `json:"counter"`
+	Origin  string `json:"origin"`
+}
+
+func (v Version) newer(other Version) bool {
+	if v.Counter != other.Counter {
+		return v.Counter > other.Counter
+	}
+	return v.Origin > other.Origin
+}
+
+// The state of a member as exchanged between peers. Removed members are
+// kept as deleted states, so removals propagate as well.
+type MemberState struct {
+	Version Version      `json:"version"`
+	Deleted bool         `json:"deleted,omitempty"`
+	Node    SnapshotNode `json:"node"`
+}
+
+// A peer to synchronize a ring with. PeerSync implements it for an
+// in-process ring; remote peers forward the calls over their transport
+// of choice.
+type SyncPeer interface {
+	// Returns a fingerprint of the member states of the peer.
+	Fingerprint(ctx context.Context) (uint64, error)
+	// Returns the states the peer has newer versions of than digest, and
+	// the members digest has newer versions of than the peer.
+	Exchange(ctx context.Context, digest map[string]Version) (newer []MemberState, wanted []string, err error)
+	// Merges the provided states into the ring of the peer.
+	Push(ctx context.Context, states []MemberState) error
+}
+
+// Keeps the members of a ring converged with the rings of its peers
+// through anti-entropy: peers compare fingerprints and, if those differ,
+// exchange the states of the members that differ. Modifications of the
+// hash are versioned with a Lamport clock, and the newest version of a
+// member wins.
+type PeerSync struct {
+	m      *Map
+	origin string
+	cancel func()
+
+	mu       sync.Mutex
+	clock    uint64
+	states   map[string]MemberState
+	expected map[*Change]MemberState
+}
+
+// Returns a PeerSync for m. The origin identifies this peer and must be
+// unique among all peers.
+func NewPeerSync(m *Map, origin string) *PeerSync {
+	s := &PeerSync{
+		m:        m,
+		origin:   origin,
+		states:   make(map[string]MemberState),
+		expected: make(map[*Change]MemberState),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.cancel = m.OnChange(s.handle)
+	for _, node := range m.Snapshot().Nodes {
+		if _, exists := s.states[node.ID]; !exists {
+			s.states[node.ID] = MemberState{Version: Version{Origin: origin}, Node: node}
+		}
+	}
+	return s
+}
+
+// Stops tracking modifications of the hash.
+func (s *PeerSync) Close() {
+	s.cancel()
+}
+
+func (s *PeerSync) handle(event ChangeEvent) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Changes made by merge are recognized by their identity, and keep
+	// the version they were received with.
+	if len(event.Changes) > 0 {
+		if state, exists := s.expected[&event.Changes[0]]; exists {
+			delete(s.expected, &event.Changes[0])
+			s.states[state.Node.ID] = state
+			return
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, c := range event.Changes {
+		// Availability is local to every process and isn't gossiped.
+		if seen[c.Key] || c.availability() {
+			continue
+		}
+		seen[c.Key] = true
+
+		s.clock++
+		state := MemberState{Version: Version{s.clock, s.origin}, Node: SnapshotNode{ID: c.Key}}
+		if node, exists := s.m.member(c.Key); exists {
+			state.Node = node
+		} else {
+			state.Deleted = true
+		}
+		s.states[c.Key] = state
+	}
+}
+
+func (m *Map) member(key string) (SnapshotNode, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	e, exists := m.entries[key]
+	if !exists {
+		return SnapshotNode{}, false
+	}
+	return snapshotNode(key, e), true
+}
+
+// Returns a fingerprint of the member states. Peers with equal
+// fingerprints have converged.
+func (s *PeerSync) Fingerprint(ctx context.Context) (uint64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	keys := make([]string, 0, len(s.states))
+	for key := range s.states {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	h := fnv.New64a()
+	for _, key := range keys {
+		state := s.states[key]
+		h.Write([]byte(key))
+		h.Write([]byte{0})
+		h.Write([]byte(strconv.FormatUint(state.Version.Counter, 10)))
+		h.Write([]byte{0})
+		h.Write([]byte(state.Version.Origin))
+		h.Write([]byte{0})
+		h.Write([]byte(strconv.FormatBool(state.Deleted)))
+		h.Write([]byte{0})
+	}
+	return h.Sum64(), nil
+}
+
+func (s *PeerSync) Exchange(ctx context.Context, digest map[string]Version) ([]MemberState, []string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var newer []MemberState
+	for key, local := range s.states {
+		if remote, exists := digest[key]; !exists || local.Version.newer(remote) {
+			newer = append(newer, local)
+		}
+	}
+	sort.Slice(newer, func(i, j int) bool { return newer[i].Node.ID < newer[j].Node.ID })
+
+	var wanted []string
+	for key, remote := range digest {
+		if local, exists := s.states[key]; !exists || remote.newer(local.Version) {
+			wanted = append(wanted, key)
+		}
+	}
+	sort.Strings(wanted)
+	return newer, wanted, nil
+}
+
+func (s *PeerSync) Push(ctx context.Context, states []MemberState) error {
+	return s.merge(states)
+}
+
+// Synchronizes with peer, pulling the members it has newer states of and
+// pushing the members this ring has newer states of.
+func (s *PeerSync) SyncWith(ctx context.Context, peer SyncPeer) error {
+	remote, err := peer.Fingerprint(ctx)
+	if err != nil {
+		return err
+	}
+	if local, _ := s.Fingerprint(ctx); local == remote {
+		return nil
+	}
+
+	newer, wanted, err := peer.Exchange(ctx, s.digest())
+	if err != nil {
+		return err
+	}
+	if err := s.merge(newer); err != nil {
+		return err
+	}
+	if len(wanted) == 0 {
+		return nil
+	}
+	return peer.Push(ctx, s.statesOf(wanted))
+}
+
+// Synchronizes with a randomly picked peer every interval until ctx is
+// done. Failed synchronizations are reported to onError, if set.
+func (s *PeerSync) Run(ctx context.Context, interval time.Duration, peers func() []SyncPeer, onError func(error)) error {
+	return runEvery(ctx, interval, func() error {
+		candidates := peers()
+		if len(candidates) == 0 {
+			return nil
+		}
+		return s.SyncWith(ctx, candidates[rand.Intn(len(candidates))])
+	}, onError)
+}
+
+func (s *PeerSync) digest() map[string]Version {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make(map[string]Version, len(s.states))
+	for key, state := range s.states {
+		out[key] = state.Version
+	}
+	return out
+}
+
+func (s *PeerSync) statesOf(keys []string) []MemberState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make([]MemberState, 0, len(keys))
+	for _, key := range keys {
+		if state, exists := s.states[key]; exists {
+			out = append(out, state)
+		}
+	}
+	return out
+}
+
+func (s *PeerSync) merge(states []MemberState) error {
+	var errs []error
+	for _, remote := range states {
+		s.mu.Lock()
+		if remote.Version.Counter > s.clock {
+			s.clock = remote.Version.Counter
+		}
+		if local, exists := s.states[remote.Node.ID]; exists && !remote.Version.newer(local.Version) {
+			s.mu.Unlock()
+			continue
+		}
+		if _, quarantined := s.m.Quarantined(remote.Node.ID); quarantined && !remote.Deleted {
+			// Leave the state unmerged, so it is merged once the quarantine
+			// is over.
+			s.mu.Unlock()
+			continue
+		}
+
+		txn := s.txnFor(remote)
+		if len(txn) == 0 {
+			s.states[remote.Node.ID] = remote
+			s.mu.Unlock()
+			continue
+		}
+		s.expected[&txn[0]] = remote
+		s.mu.Unlock()
+
+		if err := s.m.Apply(txn...); err != nil {
+			s.mu.Lock()
+			delete(s.expected, &txn[0])
+			s.mu.Unlock()
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Returns the changes that bring the member of the hash in line with
+// state, if any.
+func (s *PeerSync) txnFor(state MemberState) Txn {
+	current, exists := s.m.member(state.Node.ID)
+	if state.Deleted {
+		if exists {
+			return Txn{RemoveChange(state.Node.ID)}
+		}
+		return nil
+	}
+	if exists && reflect.DeepEqual(current, state.Node) {
+		return nil
+	}
+
+	var txn Txn
+	if exists {
+		txn = append(txn, RemoveChange(state.Node.ID))
+	}
+	return append(txn, state.Node.changes()...)
+}
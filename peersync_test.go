@@ -0,0 +1,104 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func newSyncedRing(origin string, members ...string) (*Map, *PeerSync) {
+	hash := New(3, intHash)
+	hash.AddString(members...)
+	return hash, NewPeerSync(hash, origin)
+}
+
+func TestPeerSyncConverges(t *testing.T) {
+	ctx := context.Background()
+	a, syncA := newSyncedRing("a", "10", "20")
+	b, syncB := newSyncedRing("b", "10", "20")
+	c, syncC := newSyncedRing("c", "10", "20", "30")
+	defer syncA.Close()
+	defer syncB.Close()
+	defer syncC.Close()
+
+	a.AddString("40")
+	b.Del("10")
+	c.AddStringWithWeight("20", 1) // Fails, 20 already exists.
+	c.Apply(WeightChange("20", 5))
+
+	for i := 0; i < 2; i++ {
+		for _, pair := range [][2]*PeerSync{{syncA, syncB}, {syncB, syncC}, {syncC, syncA}} {
+			if err := pair[0].SyncWith(ctx, pair[1]); err != nil {
+				t.Fatalf("Unexpected error: %s", err)
+			}
+		}
+	}
+
+	expected := []SnapshotNode{
+		{ID: "20", Weight: 5},
+		{ID: "30", Weight: 3},
+		{ID: "40", Weight: 3},
+	}
+	for name, hash := range map[string]*Map{"a": a, "b": b, "c": c} {
+		if nodes := hash.Snapshot().Nodes; !reflect.DeepEqual(nodes, expected) {
+			t.Errorf("Expected ring %s to hold %v, but got: %v", name, expected, nodes)
+		}
+	}
+
+	fa, _ := syncA.Fingerprint(ctx)
+	fb, _ := syncB.Fingerprint(ctx)
+	fc, _ := syncC.Fingerprint(ctx)
+	if fa != fb || fb != fc {
+		t.Errorf("Expected fingerprints to be equal, but got: %d, %d, %d", fa, fb, fc)
+	}
+
+	// Converged peers don't exchange any states.
+	epoch := a.Epoch()
+	syncA.SyncWith(ctx, syncB)
+	if a.Epoch() != epoch {
+		t.Errorf("Expected converged rings not to be modified")
+	}
+}
+
+func TestPeerSyncConcurrentChanges(t *testing.T) {
+	ctx := context.Background()
+	a, syncA := newSyncedRing("a", "10")
+	b, syncB := newSyncedRing("b", "10")
+	defer syncA.Close()
+	defer syncB.Close()
+
+	// Both peers change the same member at the same counter, the change
+	// of the peer with the highest origin wins.
+	a.Apply(WeightChange("10", 1))
+	b.Apply(WeightChange("10", 2))
+	syncA.SyncWith(ctx, syncB)
+
+	for name, hash := range map[string]*Map{"a": a, "b": b} {
+		if nodes := hash.Snapshot().Nodes; len(nodes) != 1 || nodes[0].Weight != 2 {
+			t.Errorf("Expected ring %s to hold 10 with weight 2, but got: %v", name, nodes)
+		}
+	}
+
+	// A later change wins over an earlier one, regardless of origin.
+	a.Apply(WeightChange("10", 4))
+	syncB.SyncWith(ctx, syncA)
+	if nodes := b.Snapshot().Nodes; nodes[0].Weight != 4 {
+		t.Errorf("Expected later change to win, but got: %v", nodes)
+	}
+}
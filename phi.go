@@ -0,0 +1,200 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// The number of heartbeat intervals a phi detector remembers per member.
+	phiWindowSize = 100
+	// The smallest standard deviation assumed for heartbeat intervals, so
+	// perfectly regular heartbeats do not make the detector hair-trigger.
+	phiMinStdDeviation = 100 * time.Millisecond
+)
+
+// Marks members down when their heartbeats stop, using the phi accrual
+// failure detector. Instead of a fixed timeout, the detector learns the
+// distribution of heartbeat intervals of every member and computes phi,
+// the suspicion that a member has failed given the time since its last
+// heartbeat: a phi of 1 means about a 10% chance the member is still up,
+// a phi of 2 about 1%, and so on. Members whose phi exceeds the threshold
+// are marked down, and marked up again at their next heartbeat.
+type PhiDetector struct {
+	m         *Map
+	threshold float64
+	expected  time.Duration
+	now       func() time.Time
+	mu        sync.Mutex
+	members   map[string]*heartbeatHistory
+}
+
+type heartbeatHistory struct {
+	last      time.Time
+	intervals []time.Duration
+	next      int // Index of the oldest interval once the window is full.
+	sum       float64
+	squares   float64
+	suspected bool // Whether the detector marked the member down.
+}
+
+// Creates a phi detector for the hash. A threshold around 8 suits most
+// networks. Until a member has sent two heartbeats, its intervals are
+// assumed to be the expected interval.
+func NewPhiDetector(m *Map, threshold float64, expected time.Duration) *PhiDetector {
+	return &PhiDetector{m: m, threshold: threshold, expected: expected, now: time.Now, members: make(map[string]*heartbeatHistory)}
+}
+
+// Records a heartbeat of a member. A member the detector marked down is
+// marked up again.
+func (d *PhiDetector) Heartbeat(key string) {
+	now := d.now()
+
+	d.mu.Lock()
+	h, tracked := d.members[key]
+	if !tracked {
+		h = &heartbeatHistory{}
+		h.add(d.expected)
+		d.members[key] = h
+	} else if !h.suspected {
+		// The silence of a suspected member says nothing about its usual
+		// interval, so it is not recorded.
+		h.add(now.Sub(h.last))
+	}
+	h.last = now
+	recovered := h.suspected
+	h.suspected = false
+	d.mu.Unlock()
+
+	if recovered {
+		d.m.MarkUp(key)
+	}
+}
+
+// Returns the current suspicion that a member has failed, or 0 if it
+// never sent a heartbeat.
+func (d *PhiDetector) Phi(key string) float64 {
+	now := d.now()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if h, tracked := d.members[key]; tracked {
+		return h.phi(now)
+	}
+	return 0
+}
+
+// Stops tracking a member, e.g. after it left the hash.
+func (d *PhiDetector) Forget(key string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.members, key)
+}
+
+// Marks down the members whose phi exceeds the threshold, and returns
+// them, sorted. Members that are not in the hash are ignored, and so are
+// members that are already down: the detector only marks up the members
+// it marked down itself.
+func (d *PhiDetector) Check() ([]string, error) {
+	now := d.now()
+
+	d.mu.Lock()
+	var suspects []string
+	phis := make(map[string]float64)
+	for key, h := range d.members {
+		if phi := h.phi(now); !h.suspected && phi > d.threshold {
+			suspects = append(suspects, key)
+			phis[key] = phi
+		}
+	}
+	d.mu.Unlock()
+	sort.Strings(suspects)
+
+	var failed []string
+	for _, key := range suspects {
+		if _, exists := d.m.Value(key); !exists {
+			continue
+		}
+		changed, err := d.m.markDown(key)
+		if err != nil {
+			return failed, err
+		}
+		if !changed {
+			continue
+		}
+		// A heartbeat may have arrived since phi was computed.
+		d.mu.Lock()
+		h, tracked := d.members[key]
+		recovered := !tracked || h.last.After(now)
+		if !recovered {
+			h.suspected = true
+		}
+		d.mu.Unlock()
+		if recovered {
+			d.m.MarkUp(key)
+			continue
+		}
+		d.m.currentLogger().Warn("Node suspected to have failed", "node", key, "phi", phis[key])
+		failed = append(failed, key)
+	}
+	return failed, nil
+}
+
+// Calls Check every interval until ctx is done. Members that could not be
+// marked down are reported to onError, if set.
+func (d *PhiDetector) Run(ctx context.Context, interval time.Duration, onError func(error)) error {
+	return runEvery(ctx, interval, func() error {
+		_, err := d.Check()
+		return err
+	}, onError)
+}
+
+func (h *heartbeatHistory) add(interval time.Duration) {
+	v := float64(interval)
+	if len(h.intervals) < phiWindowSize {
+		h.intervals = append(h.intervals, interval)
+	} else {
+		old := float64(h.intervals[h.next])
+		h.sum -= old
+		h.squares -= old * old
+		h.intervals[h.next] = interval
+		h.next = (h.next + 1) % phiWindowSize
+	}
+	h.sum += v
+	h.squares += v * v
+}
+
+// Returns phi for the time elapsed since the last heartbeat, assuming
+// normally distributed intervals. Uses the logistic approximation of the
+// normal distribution, which stays accurate far into its tail.
+func (h *heartbeatHistory) phi(now time.Time) float64 {
+	n := float64(len(h.intervals))
+	mean := h.sum / n
+	stdDev := math.Sqrt(math.Max(0, h.squares/n-mean*mean))
+	stdDev = math.Max(stdDev, float64(phiMinStdDeviation))
+
+	y := (float64(now.Sub(h.last)) - mean) / stdDev
+	e := math.Exp(-y * (1.5976 + 0.070566*y*y))
+	if y > 0 {
+		return -math.Log10(e / (1 + e))
+	}
+	return -math.Log10(1 - 1/(1+e))
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPhiDetector(t *testing.T) {
+	hash := New(1, nil)
+	hash.AddString("A", "B")
+
+	now := time.Unix(0, 0)
+	d := NewPhiDetector(hash, 8, time.Second)
+	d.now = func() time.Time { return now }
+
+	for i := 0; i < 10; i++ {
+		d.Heartbeat("A")
+		d.Heartbeat("B")
+		d.Heartbeat("C")
+		now = now.Add(time.Second)
+	}
+
+	if phi := d.Phi("A"); phi > 1 {
+		t.Errorf("Expected low phi right after the usual interval, but got: %v", phi)
+	}
+	if d.Phi("D") != 0 {
+		t.Errorf("Expected phi of 0 for an unknown member")
+	}
+
+	// B keeps sending heartbeats, A and C stop.
+	var last float64
+	for i := 0; i < 3; i++ {
+		now = now.Add(time.Second)
+		d.Heartbeat("B")
+		if phi := d.Phi("A"); phi <= last {
+			t.Errorf("Expected phi to grow, but got %v after %v", phi, last)
+		} else {
+			last = phi
+		}
+	}
+
+	failed, err := d.Check()
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if len(failed) != 1 || failed[0] != "A" || !hash.IsDown("A") || hash.IsDown("B") {
+		t.Errorf("Expected only A to be marked down, but got: %v, %v", failed, hash.Down())
+	}
+	if failed, _ := d.Check(); len(failed) != 0 {
+		t.Errorf("Expected A to be reported once, but got: %v", failed)
+	}
+
+	d.Heartbeat("A")
+	if hash.IsDown("A") {
+		t.Errorf("Expected A to be marked up at its next heartbeat")
+	}
+	if phi := d.Phi("A"); phi > 1 {
+		t.Errorf("Expected low phi after a heartbeat, but got: %v", phi)
+	}
+
+	// Members the detector did not mark down are not marked up.
+	hash.MarkDown("B")
+	d.Heartbeat("B")
+	if !hash.IsDown("B") {
+		t.Errorf("Expected B to stay down")
+	}
+}
+
+func TestPhiDetectorKeepsOperatorState(t *testing.T) {
+	hash := New(1, nil)
+	hash.AddString("A")
+
+	now := time.Unix(0, 0)
+	d := NewPhiDetector(hash, 8, time.Second)
+	d.now = func() time.Time { return now }
+	for i := 0; i < 10; i++ {
+		d.Heartbeat("A")
+		now = now.Add(time.Second)
+	}
+
+	// An operator takes A out of service before its heartbeats stop.
+	hash.MarkDown("A")
+	now = now.Add(time.Minute)
+	if failed, err := d.Check(); err != nil || len(failed) != 0 {
+		t.Errorf("Expected members that are already down to be ignored, but got: %v, %v", failed, err)
+	}
+
+	d.Heartbeat("A")
+	if !hash.IsDown("A") {
+		t.Errorf("Expected A to stay down after its next heartbeat")
+	}
+}
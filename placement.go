@@ -0,0 +1,120 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+// Selects the replicas returned by GetN and its variants. A strategy can
+// be set per hash with SetPlacementStrategy, the default is
+// RingWalkStrategy.
+type PlacementStrategy interface {
+	// Appends up to n replicas for the provided hash to dst and returns
+	// the extended slice. Every candidate must be passed to accept along
+	// with the replicas this call has picked so far, and may only be
+	// picked if accept returns true. The accept function takes care of
+	// the accept-callback and constraints of the lookup.
+	Place(ring PlacementView, dst []string, hash, n int, accept func(picked []string, candidate string) bool) []string
+}
+
+// Read-only access to a hash for placement strategies. It is only valid
+// during the call to Place it was passed to.
+type PlacementView struct {
+	m     *Map
+	trace *explainTrace // Only set by ExplainGetN.
+	stop  *bool         // Ends walks once set, only used by GetNWithError.
+}
+
+// Visits the available items on the ring in ring order, starting at
+// the item that owns the provided hash, until visit returns false. An
+// item is visited once for every virtual node it has.
+func (v PlacementView) Walk(hash int, visit func(node string) bool) {
+	v.m.walk(hash, func(pos int, owner string) bool {
+		if v.stop != nil && *v.stop {
+			return false
+		}
+		if v.trace != nil {
+			v.trace.visit(v.m, pos, owner)
+		}
+		if !v.m.available(owner) {
+			return true
+		}
+		return visit(owner)
+	})
+}
+
+// Returns the number of items a walk can visit at most: items that are
+// marked down are never visited, others may still be unavailable.
+func (v PlacementView) maxVisited() int {
+	return len(v.m.entries) - len(v.m.down)
+}
+
+// Visits every available item that has positions on the ring, in no
+// particular order.
+func (v PlacementView) eachAvailable(visit func(node string)) {
+	for key, e := range v.m.entries {
+		if len(e.positions) > 0 && v.m.available(key) {
+			visit(key)
+		}
+	}
+}
+
+// Returns the value of the item with the provided key, or nil.
+func (v PlacementView) Value(node string) EntryValue {
+	if entry, exists := v.m.entries[node]; exists {
+		return entry.value
+	}
+	return nil
+}
+
+// Returns the weight of the item with the provided key, or 0.
+func (v PlacementView) Weight(node string) int {
+	if entry, exists := v.m.entries[node]; exists {
+		return entry.weight
+	}
+	return 0
+}
+
+// Returns the value of a label of the item with the provided key.
+func (v PlacementView) Label(node, label string) (string, bool) {
+	if entry, exists := v.m.entries[node]; exists {
+		value, exists := entry.labels[label]
+		return value, exists
+	}
+	return "", false
+}
+
+// Picks the replicas in the order they are found walking the ring from
+// the position of the key.
+type RingWalkStrategy struct{}
+
+func (RingWalkStrategy) Place(ring PlacementView, dst []string, hash, n int, accept func([]string, string) bool) []string {
+	start := len(dst)
+	ring.Walk(hash, func(node string) bool {
+		if accept(dst[start:], node) {
+			dst = append(dst, node)
+		}
+		return len(dst)-start < n
+	})
+	return dst
+}
+
+// Sets the strategy that selects replicas in GetN and its variants.
+// Passing nil restores the default RingWalkStrategy. This also applies to
+// hashes with a compatibility mode, which only pins the default.
+func (m *Map) SetPlacementStrategy(strategy PlacementStrategy) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.placement = strategy
+}
@@ -0,0 +1,122 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// Picks the replicas with the highest weight first.
+type heaviestFirst struct{}
+
+func (heaviestFirst) Place(ring PlacementView, dst []string, hash, n int, accept func([]string, string) bool) []string {
+	var candidates []string
+	seen := make(map[string]bool)
+	ring.Walk(hash, func(node string) bool {
+		if !seen[node] {
+			seen[node] = true
+			candidates = append(candidates, node)
+		}
+		return true
+	})
+
+	start := len(dst)
+	for len(candidates) > 0 && len(dst)-start < n {
+		best := 0
+		for i, node := range candidates {
+			if ring.Weight(node) > ring.Weight(candidates[best]) {
+				best = i
+			}
+		}
+		if node := candidates[best]; accept(dst[start:], node) {
+			dst = append(dst, node)
+		}
+		candidates = append(candidates[:best], candidates[best+1:]...)
+	}
+	return dst
+}
+
+func TestPlacementStrategy(t *testing.T) {
+	hash := New(1, intHash)
+	hash.AddStringWithWeight("10", 1)
+	hash.AddStringWithWeight("20", 3)
+	hash.AddStringWithWeight("30", 2)
+	hash.MarkDown("30")
+
+	expected := []string{"10", "20", "20"}
+	if nodes := hash.GetN("5", 3, nil); !reflect.DeepEqual(nodes, expected) {
+		t.Errorf("Expected %v, but got: %v", expected, nodes)
+	}
+
+	hash.SetPlacementStrategy(heaviestFirst{})
+	expected = []string{"20", "10"}
+	if nodes := hash.GetN("5", 3, nil); !reflect.DeepEqual(nodes, expected) {
+		t.Errorf("Expected %v, but got: %v", expected, nodes)
+	}
+
+	hash.SetConstraints(MaxReplicasPerNode(1))
+	hash.MarkUp("30")
+	expected = []string{"20", "30"}
+	if nodes, err := hash.GetNE("5", 2, nil); err != nil || !reflect.DeepEqual(nodes, expected) {
+		t.Errorf("Expected %v, but got: %v, %v", expected, nodes, err)
+	}
+
+	hash.SetPlacementStrategy(nil)
+	expected = []string{"10", "20", "30"}
+	if nodes := hash.GetN("5", 3, nil); !reflect.DeepEqual(nodes, expected) {
+		t.Errorf("Expected %v, but got: %v", expected, nodes)
+	}
+}
+
+func TestPlacementStrategyVariants(t *testing.T) {
+	hash := New(1, intHash)
+	hash.AddStringWithWeight("10", 1)
+	hash.AddStringWithWeight("20", 3)
+	hash.AddStringWithWeight("30", 2)
+	hash.SetPlacementStrategy(heaviestFirst{})
+	hash.SetConstraints(MaxReplicasPerNode(1))
+
+	expected := []string{"20", "30"}
+	if nodes, err := hash.GetNWithError("5", 2, nil); err != nil || !reflect.DeepEqual(nodes, expected) {
+		t.Errorf("Expected %v, but got: %v, %v", expected, nodes, err)
+	}
+	stop := func(picked []string, _ string) (bool, error) {
+		if len(picked) == 1 {
+			return false, fmt.Errorf("Enough: %w", ErrStopSearch)
+		}
+		return true, nil
+	}
+	if nodes, err := hash.GetNWithError("5", 2, stop); err != nil || !reflect.DeepEqual(nodes, expected[:1]) {
+		t.Errorf("Expected a wrapped ErrStopSearch to stop at %v, but got: %v, %v", expected[:1], nodes, err)
+	}
+
+	var names []string
+	for _, v := range hash.GetNValues("5", 2, AcceptAnyValue) {
+		names = append(names, v.HashRingId())
+	}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("Expected %v, but got: %v", expected, names)
+	}
+
+	view := hash.View(func(v EntryValue) bool { return v.HashRingId() != "20" })
+	expected = []string{"30", "10"}
+	if nodes := view.GetN("5", 2, nil); !reflect.DeepEqual(nodes, expected) {
+		t.Errorf("Expected %v, but got: %v", expected, nodes)
+	}
+}
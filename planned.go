@@ -0,0 +1,117 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Returned by PlannedChange.Err when the change was cancelled.
+var ErrCancelled = errors.New("Planned change was cancelled")
+
+// A transaction that is scheduled to be applied to the hash at a later
+// point in time. Processes that schedule the same transaction for the
+// same time will all cut over to the new topology simultaneously.
+type PlannedChange struct {
+	At  time.Time
+	Txn Txn
+
+	m     *Map
+	timer *time.Timer
+	done  chan struct{}
+	mu    sync.Mutex
+	err   error
+}
+
+// Schedules txn to be applied to the hash at time t. If t is in the
+// past, the transaction is applied right away (but asynchronously).
+func (m *Map) ApplyAt(t time.Time, txn Txn) *PlannedChange {
+	p := &PlannedChange{
+		At:   t,
+		Txn:  txn,
+		m:    m,
+		done: make(chan struct{}),
+	}
+
+	m.mu.Lock()
+	m.planned[p] = struct{}{}
+	p.timer = time.AfterFunc(time.Until(t), p.fire)
+	m.mu.Unlock()
+	return p
+}
+
+// Returns all changes that are scheduled but not yet applied,
+// ordered by the time they will be applied.
+func (m *Map) Planned() []*PlannedChange {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	out := make([]*PlannedChange, 0, len(m.planned))
+	for p := range m.planned {
+		out = append(out, p)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].At.Before(out[j].At) })
+	return out
+}
+
+func (p *PlannedChange) fire() {
+	err := p.m.Apply(p.Txn...)
+
+	p.m.mu.Lock()
+	delete(p.m.planned, p)
+	p.m.mu.Unlock()
+
+	p.mu.Lock()
+	p.err = err
+	p.mu.Unlock()
+	close(p.done)
+}
+
+// Cancels the change. Returns false if the change was already applied
+// (or is being applied) and can no longer be cancelled.
+func (p *PlannedChange) Cancel() bool {
+	if !p.timer.Stop() {
+		return false
+	}
+
+	p.m.mu.Lock()
+	delete(p.m.planned, p)
+	p.m.mu.Unlock()
+
+	p.mu.Lock()
+	p.err = ErrCancelled
+	p.mu.Unlock()
+	close(p.done)
+	return true
+}
+
+// Returns a channel that is closed once the change has been applied
+// or cancelled.
+func (p *PlannedChange) Done() <-chan struct{} {
+	return p.done
+}
+
+// Returns the error that occurred while applying the change, or
+// ErrCancelled if it was cancelled. Returns nil while still pending.
+func (p *PlannedChange) Err() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.err
+}
@@ -0,0 +1,68 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"testing"
+	"time"
+)
+
+func TestApplyAt(t *testing.T) {
+	hash := New(1, intHash)
+	hash.AddString("10")
+
+	p := hash.ApplyAt(time.Now().Add(10*time.Millisecond), Txn{
+		AddChange(&StringValue{"20"}, 1),
+		RemoveChange("10"),
+	})
+	if planned := hash.Planned(); len(planned) != 1 || planned[0] != p {
+		t.Errorf("Expected change to be planned, but got: %v", planned)
+	}
+	if res := hash.Get("5"); res != "10" {
+		t.Errorf("Entry 5 should map to 10 before cut over, but instead got: %s", res)
+	}
+
+	<-p.Done()
+	if p.Err() != nil {
+		t.Errorf("Unexpected error: %s", p.Err())
+	}
+	if res := hash.Get("5"); res != "20" {
+		t.Errorf("Entry 5 should map to 20 after cut over, but instead got: %s", res)
+	}
+	if len(hash.Planned()) != 0 {
+		t.Errorf("Applied change should no longer be planned")
+	}
+	if p.Cancel() {
+		t.Errorf("Applied change should not be cancellable")
+	}
+}
+
+func TestApplyAtCancel(t *testing.T) {
+	hash := New(1, intHash)
+	p := hash.ApplyAt(time.Now().Add(time.Hour), Txn{AddChange(&StringValue{"20"}, 1)})
+
+	if !p.Cancel() {
+		t.Fatalf("Expected change to be cancelled")
+	}
+	<-p.Done()
+	if p.Err() != ErrCancelled {
+		t.Errorf("Expected ErrCancelled, but got: %v", p.Err())
+	}
+	if !hash.IsEmpty() || len(hash.Planned()) != 0 {
+		t.Errorf("Cancelled change should not be applied or planned")
+	}
+}
@@ -0,0 +1,72 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"sync"
+)
+
+// The result of GetNPooled. The nodes may be used until Release is
+// called.
+type NodeList struct {
+	Nodes []string
+}
+
+var scratchPool = sync.Pool{
+	New: func() interface{} {
+		b := make([]byte, 0, 64)
+		return &b
+	},
+}
+
+var nodeListPool = sync.Pool{
+	New: func() interface{} { return &NodeList{} },
+}
+
+// Gets the N closest items to the provided key, like GetN, but into a
+// list taken from a pool. Calling Release once the list is no longer
+// needed returns it to the pool, so lookups don't allocate on the happy
+// path.
+//
+// The accept function must not call back into the hash, see GetN.
+func (m *Map) GetNPooled(key string, n int, accept func([]string, string) bool) *NodeList {
+	l := nodeListPool.Get().(*NodeList)
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	l.Nodes = m.appendN(l.Nodes[:0], m.hashKey(key), n, accept)
+	return l
+}
+
+// Appends the N closest items to the provided key to dst and returns the
+// extended slice, like append. Only the appended items count towards n
+// and are passed to the accept function, so dst can be reused across
+// lookups (e.g. GetNInto(buf[:0], ...)) to avoid allocations entirely.
+//
+// The accept function must not call back into the hash, see GetN.
+func (m *Map) GetNInto(dst []string, key string, n int, accept func([]string, string) bool) []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.appendN(dst, m.hashKey(key), n, accept)
+}
+
+// Returns the list to the pool. Neither the list nor its nodes may be
+// used afterwards.
+func (l *NodeList) Release() {
+	clear(l.Nodes)
+	nodeListPool.Put(l)
+}
@@ -0,0 +1,73 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"reflect"
+	"runtime/debug"
+	"strconv"
+	"testing"
+)
+
+func TestGetNPooled(t *testing.T) {
+	hash := New(50, nil)
+	hash.AddString("A", "B", "C", "D")
+
+	for i := 0; i < 100; i++ {
+		key := strconv.Itoa(i)
+		l := hash.GetNPooled(key, 3, AcceptUnique)
+		if expected := hash.GetN(key, 3, AcceptUnique); !reflect.DeepEqual(l.Nodes, expected) {
+			t.Errorf("Expected %v for %s, but got: %v", expected, key, l.Nodes)
+		}
+		l.Release()
+	}
+
+	// A collection empties the pool, so keep it from running meanwhile.
+	defer debug.SetGCPercent(debug.SetGCPercent(-1))
+	allocs := testing.AllocsPerRun(100, func() {
+		hash.GetNPooled("foo", 3, AcceptUnique).Release()
+	})
+	if allocs > 0 && !raceEnabled {
+		t.Errorf("Expected pooled lookups not to allocate, but got: %v allocations", allocs)
+	}
+}
+
+func TestGetNInto(t *testing.T) {
+	hash := New(1, intHash)
+	hash.AddString("10", "20", "30")
+
+	dst := []string{"20"}
+	dst = hash.GetNInto(dst, "15", 2, AcceptUnique)
+	if expected := []string{"20", "20", "30"}; !reflect.DeepEqual(dst, expected) {
+		t.Errorf("Expected the accept function to only see appended items, got: %v", dst)
+	}
+
+	buf := make([]string, 0, 3)
+	allocs := testing.AllocsPerRun(100, func() {
+		buf = hash.GetNInto(buf[:0], "15", 3, AcceptUnique)
+	})
+	if allocs > 0 {
+		t.Errorf("Expected lookups into a large enough buffer not to allocate, but got: %v allocations", allocs)
+	}
+	if expected := []string{"20", "30", "10"}; !reflect.DeepEqual(buf, expected) {
+		t.Errorf("Expected %v, but got: %v", expected, buf)
+	}
+
+	if res := New(1, nil).GetNInto(nil, "15", 3, nil); res != nil {
+		t.Errorf("Expected dst to be returned unchanged for an empty ring, but got: %v", res)
+	}
+}
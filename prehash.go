@@ -0,0 +1,46 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+// Returns the positions of the keys on the ring, in the same order. The
+// positions can be passed to GetByHash and GetNByHash, so keys that are
+// routed repeatedly only need to be hashed once. Positions depend on the
+// hash function and options of the hash, not on its items.
+func (m *Map) HashKeys(keys []string) []uint32 {
+	out := make([]uint32, len(keys))
+	var buf []byte
+	for i, key := range keys {
+		buf = append(buf[:0], key...)
+		out[i] = uint32(m.hashBytes(buf))
+	}
+	return out
+}
+
+// Gets the closest item to a position obtained through HashKeys.
+func (m *Map) GetByHash(h uint32) string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.getHash(int(h))
+}
+
+// Gets the N closest items to a position obtained through HashKeys, see
+// GetN.
+func (m *Map) GetNByHash(h uint32, n int, accept func([]string, string) bool) []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.getNHash(int(h), n, accept)
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestHashKeys(t *testing.T) {
+	for _, hash := range []*Map{New(50, nil), New(50, nil, WithSeed("abc"))} {
+		hash.AddString("A", "B", "C")
+
+		keys := make([]string, 100)
+		for i := range keys {
+			keys[i] = strconv.Itoa(i)
+		}
+		hashes := hash.HashKeys(keys)
+		if len(hashes) != len(keys) {
+			t.Fatalf("Expected %d hashes, but got: %d", len(keys), len(hashes))
+		}
+		for i, key := range keys {
+			if hashes[i] != uint32(hash.hashKey(key)) {
+				t.Errorf("Expected hash of %s to be its position on the ring", key)
+			}
+			if hash.GetByHash(hashes[i]) != hash.Get(key) {
+				t.Errorf("Expected GetByHash to match Get for %s", key)
+			}
+			if !reflect.DeepEqual(hash.GetNByHash(hashes[i], 2, AcceptUnique), hash.GetN(key, 2, AcceptUnique)) {
+				t.Errorf("Expected GetNByHash to match GetN for %s", key)
+			}
+		}
+	}
+
+	if node := New(1, nil).GetByHash(42); node != "" {
+		t.Errorf("Expected no owner for an empty ring, but got: %s", node)
+	}
+	if nodes := New(1, nil).GetNByHash(42, 2, nil); len(nodes) != 0 {
+		t.Errorf("Expected no owners for an empty ring, but got: %v", nodes)
+	}
+}
+
+func BenchmarkHashKeys(b *testing.B) {
+	hash := New(50, nil)
+	keys := make([]string, 1000)
+	for i := range keys {
+		keys[i] = "key-" + strconv.Itoa(i)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		hash.HashKeys(keys)
+	}
+}
@@ -0,0 +1,242 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"reflect"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// The changes of the members of a hash from one epoch to another.
+type PartitionDelta struct {
+	From  uint64 `json:"from"`
+	Epoch uint64 `json:"epoch"`
+	// The members that were removed.
+	Removed []string `json:"removed,omitempty"`
+	// The members that were added or changed, in their new state.
+	Nodes []SnapshotNode `json:"nodes,omitempty"`
+}
+
+// An update of the ring for a subscriber: either the deltas since the
+// epoch the subscriber has, or a full snapshot if the deltas have been
+// discarded already. Neither is set if the subscriber is up to date.
+type PartitionUpdate struct {
+	// Identifies the publisher. Epochs start over when a publisher is
+	// restarted, so epochs of different generations are unrelated.
+	Generation string           `json:"generation,omitempty"`
+	Epoch      uint64           `json:"epoch"`
+	Snapshot   *Snapshot        `json:"snapshot,omitempty"`
+	Deltas     []PartitionDelta `json:"deltas,omitempty"`
+}
+
+// Publishes the members of a hash, and the changes to them, to remote
+// subscribers over HTTP, so they can route keys without discovering the
+// topology themselves. Subscribers resume from the epoch they have, and
+// receive the deltas since, as long as the publisher still has them. Only
+// membership is published; down, quarantined or otherwise unavailable
+// members are not.
+type PartitionPublisher struct {
+	m          *Map
+	history    int
+	generation string
+	cancel     func()
+	mu         sync.Mutex
+	current    *Snapshot
+	deltas     []PartitionDelta
+	changed    chan struct{} // Closed and replaced when current changes.
+}
+
+// Creates a publisher for the hash that keeps the given number of deltas
+// for subscribers to resume from, where a negative number keeps none.
+// Close releases it.
+func NewPartitionPublisher(m *Map, history int) *PartitionPublisher {
+	p := &PartitionPublisher{
+		m:          m,
+		history:    max(0, history),
+		generation: strconv.FormatUint(rand.Uint64(), 36),
+		changed:    make(chan struct{}),
+	}
+	p.mu.Lock()
+	p.cancel = m.OnChange(func(ChangeEvent) { p.refresh() })
+	p.current = m.Snapshot()
+	p.mu.Unlock()
+	return p
+}
+
+// Stops publishing changes of the hash.
+func (p *PartitionPublisher) Close() {
+	p.cancel()
+}
+
+// Returns the generation of the publisher, which is random for every
+// publisher that is created.
+func (p *PartitionPublisher) Generation() string {
+	return p.generation
+}
+
+// Returns the latest epoch that was published.
+func (p *PartitionPublisher) Epoch() uint64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.current.Epoch
+}
+
+// Returns the update for a subscriber that has the given epoch.
+func (p *PartitionPublisher) Update(since uint64) PartitionUpdate {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.update(since)
+}
+
+// Waits until there is an update for a subscriber that has the given
+// epoch, and returns it.
+func (p *PartitionPublisher) Wait(ctx context.Context, since uint64) (PartitionUpdate, error) {
+	for {
+		p.mu.Lock()
+		if p.current.Epoch != since {
+			defer p.mu.Unlock()
+			return p.update(since), nil
+		}
+		changed := p.changed
+		p.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return PartitionUpdate{}, ctx.Err()
+		case <-changed:
+		}
+	}
+}
+
+// Serves updates to subscribers:
+//
+//	GET /?generation=abc&since=5&wait=30s
+//
+// returns the update for a subscriber at epoch 5 of generation abc,
+// waiting up to 30 seconds for one, or 304 Not Modified if there is none.
+// Without since, or for another generation, the full snapshot is
+// returned.
+func (p *PartitionPublisher) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != "GET" {
+		w.Header().Set("Allow", "GET")
+		writeError(w, http.StatusMethodNotAllowed, "Method not allowed: "+r.Method)
+		return
+	}
+
+	query := r.URL.Query()
+	s := query.Get("since")
+	if g := query.Get("generation"); s == "" || g != "" && g != p.generation {
+		p.mu.Lock()
+		update := PartitionUpdate{Generation: p.generation, Epoch: p.current.Epoch, Snapshot: p.current}
+		p.mu.Unlock()
+		writeJSON(w, http.StatusOK, update)
+		return
+	}
+	since, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "Invalid epoch: "+s)
+		return
+	}
+	var wait time.Duration
+	if s := query.Get("wait"); s != "" {
+		if wait, err = time.ParseDuration(s); err != nil || wait < 0 {
+			writeError(w, http.StatusBadRequest, "Invalid wait duration: "+s)
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), wait)
+	defer cancel()
+	update, err := p.Wait(ctx, since)
+	if err != nil {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	writeJSON(w, http.StatusOK, update)
+}
+
+// Returns the update for a subscriber at since. The caller must hold the
+// lock.
+func (p *PartitionPublisher) update(since uint64) PartitionUpdate {
+	update := PartitionUpdate{Generation: p.generation, Epoch: p.current.Epoch}
+	if since == p.current.Epoch {
+		return update
+	}
+	for i, d := range p.deltas {
+		if d.From == since {
+			update.Deltas = append([]PartitionDelta(nil), p.deltas[i:]...)
+			return update
+		}
+	}
+	update.Snapshot = p.current
+	return update
+}
+
+func (p *PartitionPublisher) refresh() {
+	next := p.m.Snapshot()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	// The snapshot may include later changes, whose events then have
+	// nothing left to publish.
+	if next.Epoch <= p.current.Epoch {
+		return
+	}
+
+	// Changes that only affect availability leave the members as they
+	// were, and aren't worth waking up subscribers for.
+	delta := diffSnapshots(p.current, next)
+	if len(delta.Removed) == 0 && len(delta.Nodes) == 0 {
+		return
+	}
+	p.deltas = append(p.deltas, delta)
+	if len(p.deltas) > p.history {
+		p.deltas = append([]PartitionDelta(nil), p.deltas[len(p.deltas)-p.history:]...)
+	}
+	p.current = next
+	close(p.changed)
+	p.changed = make(chan struct{})
+}
+
+// Returns the delta that turns the members of a into those of b. Both
+// snapshots must be sorted by name.
+func diffSnapshots(a, b *Snapshot) PartitionDelta {
+	d := PartitionDelta{From: a.Epoch, Epoch: b.Epoch}
+	i, j := 0, 0
+	for i < len(a.Nodes) || j < len(b.Nodes) {
+		switch {
+		case j == len(b.Nodes) || i < len(a.Nodes) && a.Nodes[i].ID < b.Nodes[j].ID:
+			d.Removed = append(d.Removed, a.Nodes[i].ID)
+			i++
+		case i == len(a.Nodes) || b.Nodes[j].ID < a.Nodes[i].ID:
+			d.Nodes = append(d.Nodes, b.Nodes[j])
+			j++
+		default:
+			if !reflect.DeepEqual(a.Nodes[i], b.Nodes[j]) {
+				d.Nodes = append(d.Nodes, b.Nodes[j])
+			}
+			i++
+			j++
+		}
+	}
+	return d
+}
@@ -0,0 +1,135 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestPartitionPublisher(t *testing.T) {
+	hash := New(3, intHash)
+	hash.AddString("10", "20")
+	p := NewPartitionPublisher(hash, 2)
+	defer p.Close()
+	start := p.Epoch()
+
+	hash.AddString("30")
+	hash.Del("10")
+	hash.Apply(WeightChange("20", 5))
+
+	update := p.Update(start + 1)
+	if update.Epoch != start+3 || update.Snapshot != nil || len(update.Deltas) != 2 {
+		t.Fatalf("Expected the last two deltas, but got: %+v", update)
+	}
+	if d := update.Deltas[0]; d.From != start+1 || len(d.Removed) != 1 || d.Removed[0] != "10" || len(d.Nodes) != 0 {
+		t.Errorf("Unexpected delta for the removal: %+v", d)
+	}
+	if d := update.Deltas[1]; len(d.Removed) != 0 || len(d.Nodes) != 1 || d.Nodes[0].Weight != 5 {
+		t.Errorf("Unexpected delta for the weight change: %+v", d)
+	}
+
+	hash.SetLabels("30", map[string]string{"zone": "a"})
+	if update := p.Update(start + 3); len(update.Deltas) != 1 || update.Deltas[0].Nodes[0].Labels["zone"] != "a" {
+		t.Errorf("Expected the label change of 30, but got: %+v", update)
+	}
+	start++
+
+	// Subscribers that are too far behind get a snapshot.
+	if update := p.Update(start); update.Snapshot == nil || len(update.Snapshot.Nodes) != 2 || update.Deltas != nil {
+		t.Errorf("Expected a snapshot, but got: %+v", update)
+	}
+	if update := p.Update(start + 3); update.Snapshot != nil || update.Deltas != nil {
+		t.Errorf("Expected no update, but got: %+v", update)
+	}
+
+	done := make(chan PartitionUpdate)
+	go func() {
+		update, _ := p.Wait(context.Background(), start+3)
+		done <- update
+	}()
+	hash.AddString("40")
+	if update := <-done; update.Epoch != start+4 || len(update.Deltas) != 1 || update.Deltas[0].Nodes[0].ID != "40" {
+		t.Errorf("Expected the addition of 40, but got: %+v", update)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := p.Wait(ctx, start+4); err == nil {
+		t.Errorf("Expected Wait to time out")
+	}
+}
+
+func TestPartitionPublisherHTTP(t *testing.T) {
+	hash := New(3, intHash)
+	hash.AddString("10")
+	p := NewPartitionPublisher(hash, 10)
+	defer p.Close()
+
+	get := func(path string) (*httptest.ResponseRecorder, PartitionUpdate) {
+		rec := httptest.NewRecorder()
+		p.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		var update PartitionUpdate
+		json.Unmarshal(rec.Body.Bytes(), &update)
+		return rec, update
+	}
+
+	rec, update := get("/")
+	if rec.Code != http.StatusOK || update.Snapshot == nil || len(update.Snapshot.Nodes) != 1 {
+		t.Fatalf("Expected a snapshot, but got: %d %s", rec.Code, rec.Body)
+	}
+	epoch := update.Epoch
+
+	if rec, _ := get("/?since=" + strconv.FormatUint(epoch, 10) + "&wait=10ms"); rec.Code != http.StatusNotModified {
+		t.Errorf("Expected status 304, but got: %d", rec.Code)
+	}
+	hash.AddString("20")
+	if rec, update := get("/?since=" + strconv.FormatUint(epoch, 10)); rec.Code != http.StatusOK || len(update.Deltas) != 1 {
+		t.Errorf("Expected a delta, but got: %d %s", rec.Code, rec.Body)
+	}
+
+	for _, path := range []string{"/?since=x", "/?since=1&wait=x"} {
+		if rec, _ := get(path); rec.Code != http.StatusBadRequest {
+			t.Errorf("Expected status 400 for %s, but got: %d", path, rec.Code)
+		}
+	}
+	rec = httptest.NewRecorder()
+	p.ServeHTTP(rec, httptest.NewRequest("POST", "/", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status 405, but got: %d", rec.Code)
+	}
+}
+
+func TestPartitionPublisherWithoutHistory(t *testing.T) {
+	hash := New(3, intHash)
+	hash.AddString("10")
+	for _, history := range []int{0, -1} {
+		p := NewPartitionPublisher(hash, history)
+		start := p.Epoch()
+		hash.AddString("20")
+		hash.Del("20")
+		if update := p.Update(start); update.Snapshot == nil || update.Deltas != nil {
+			t.Errorf("Expected a snapshot without history %d, but got: %+v", history, update)
+		}
+		p.Close()
+	}
+}
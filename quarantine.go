@@ -0,0 +1,126 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"errors"
+	"time"
+)
+
+// Returned when an automatic re-add of a quarantined member is blocked.
+var ErrQuarantined = errors.New("Node is quarantined")
+
+// Quarantines a member until the deadline: lookups skip it like a member
+// that is down, and automatic re-adds (FlapDamper, PeerSync) are blocked
+// even if the member is removed in the meantime. Adding the member
+// directly is still possible. Quarantining again replaces the deadline.
+//
+// Quarantining and lifting a quarantine bump the epoch and notify the
+// listeners of the hash. A quarantine that expires does not, as nothing
+// changes at its deadline but the clock; lift it with Unquarantine
+// instead if listeners need to know.
+func (m *Map) Quarantine(node string, until time.Time) {
+	m.applyInPlace(QuarantineChange(node, until))
+}
+
+// Lifts the quarantine of a member before its deadline.
+func (m *Map) Unquarantine(node string) {
+	c := QuarantineChange(node, time.Time{})
+	m.mutatePlanned(func() ([]Change, error) {
+		if _, exists := m.quarantined[node]; !exists {
+			return nil, errUnchanged
+		}
+		return []Change{c}, m.apply(c)
+	})
+}
+
+// Quarantines a member until the deadline, or lifts its quarantine if
+// until is the zero time, and drops quarantines that expired before now.
+// The caller must hold the write lock.
+func (m *Map) setQuarantine(node string, until, now time.Time) {
+	quarantined := make(map[string]time.Time, len(m.quarantined)+1)
+	for k, v := range m.quarantined {
+		if k != node && v.After(now) {
+			quarantined[k] = v
+		}
+	}
+	if !until.IsZero() {
+		quarantined[node] = until
+	}
+	m.setQuarantined(quarantined)
+}
+
+// Returns the deadline of the quarantine of a member, and whether it is
+// currently quarantined.
+func (m *Map) Quarantined(node string) (time.Time, bool) {
+	now := time.Now()
+	m.mu.RLock()
+	until, exists := m.quarantined[node]
+	expired := len(m.quarantined) > 0 && !now.Before(m.quarantineEnd)
+	m.mu.RUnlock()
+
+	if expired {
+		m.mu.Lock()
+		m.pruneQuarantined(now)
+		m.mu.Unlock()
+	}
+	return until, exists && now.Before(until)
+}
+
+// Returns true if the member is quarantined. The caller must hold at
+// least a read lock.
+func (m *Map) isQuarantined(node string) bool {
+	if len(m.quarantined) == 0 {
+		return false
+	}
+	until, exists := m.quarantined[node]
+	return exists && time.Now().Before(until)
+}
+
+// Returns true if any member is quarantined. Expired quarantines are only
+// pruned by writers, so this is cheap once all have expired. The caller
+// must hold at least a read lock.
+func (m *Map) anyQuarantined() bool {
+	return len(m.quarantined) > 0 && time.Now().Before(m.quarantineEnd)
+}
+
+// Drops the quarantines that have expired. The caller must hold the write
+// lock.
+func (m *Map) pruneQuarantined(now time.Time) {
+	quarantined := make(map[string]time.Time, len(m.quarantined))
+	for k, v := range m.quarantined {
+		if now.Before(v) {
+			quarantined[k] = v
+		}
+	}
+	m.setQuarantined(quarantined)
+}
+
+// Replaces the quarantined members, and caches the latest deadline. The
+// caller must hold the write lock.
+func (m *Map) setQuarantined(quarantined map[string]time.Time) {
+	if len(quarantined) == 0 {
+		quarantined = nil
+	}
+	m.quarantined = quarantined
+	m.quarantineEnd = time.Time{}
+	for _, v := range quarantined {
+		if v.After(m.quarantineEnd) {
+			m.quarantineEnd = v
+		}
+	}
+}
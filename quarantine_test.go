@@ -0,0 +1,96 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestQuarantine(t *testing.T) {
+	hash := New(1, intHash)
+	hash.AddString("10", "20")
+
+	hash.Quarantine("10", time.Now().Add(time.Hour))
+	if res := hash.Get("5"); res != "20" {
+		t.Errorf("Expected quarantined 10 to be skipped, but got: %s", res)
+	}
+	if _, quarantined := hash.Quarantined("10"); !quarantined {
+		t.Errorf("Expected 10 to be quarantined")
+	}
+	if e := hash.ExplainGetN("5", 1, nil); e.Steps[0].Reason != "Quarantined" {
+		t.Errorf("Expected quarantine to be explained, but got: %+v", e.Steps)
+	}
+
+	hash.Quarantine("20", time.Now().Add(-time.Second))
+	if _, quarantined := hash.Quarantined("20"); quarantined || hash.Get("15") != "20" {
+		t.Errorf("Expected quarantine with a past deadline to have no effect")
+	}
+
+	hash.Unquarantine("10")
+	if res := hash.Get("5"); res != "10" {
+		t.Errorf("Expected 10 to be selected after lifting the quarantine, but got: %s", res)
+	}
+}
+
+func TestQuarantineBlocksReAdds(t *testing.T) {
+	hash := New(1, intHash)
+	hash.AddString("10", "20")
+	hash.Quarantine("20", time.Now().Add(time.Hour))
+	hash.Del("20")
+
+	d := NewFlapDamper(hash, time.Minute, 3)
+	if err := d.Join(&StringValue{"20"}, 1); err != ErrQuarantined {
+		t.Errorf("Expected ErrQuarantined, but got: %v", err)
+	}
+
+	s := NewPeerSync(hash, "a")
+	state := MemberState{Version: Version{Counter: 1, Origin: "b"}, Node: SnapshotNode{ID: "20", Weight: 1}}
+	if err := s.Push(context.Background(), []MemberState{state}); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if _, exists := hash.Value("20"); exists {
+		t.Errorf("Expected synchronized re-add of quarantined member to be blocked")
+	}
+
+	hash.Unquarantine("20")
+	s.Push(context.Background(), []MemberState{state})
+	if _, exists := hash.Value("20"); !exists {
+		t.Errorf("Expected member to be re-added after the quarantine")
+	}
+}
+
+func TestQuarantineExpiry(t *testing.T) {
+	hash := New(1, intHash)
+	hash.AddString("10", "20")
+
+	hash.Quarantine("10", time.Now().Add(-time.Second))
+	hash.Quarantine("20", time.Now().Add(time.Hour))
+	hash.Unquarantine("20")
+	if hash.anyQuarantined() || hash.Get("5") != "10" {
+		t.Errorf("Expected expired quarantines not to affect lookups")
+	}
+
+	hash.Quarantine("20", time.Now().Add(-time.Second))
+	if _, quarantined := hash.Quarantined("20"); quarantined {
+		t.Errorf("Expected quarantine of 20 to have expired")
+	}
+	if len(hash.quarantined) != 0 {
+		t.Errorf("Expected expired quarantines to be pruned, but got: %v", hash.quarantined)
+	}
+}
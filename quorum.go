@@ -0,0 +1,124 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Returned when not enough replicas are available to reach a quorum.
+var ErrNoQuorum = errors.New("Quorum is not achievable")
+
+// The replication parameters of a key: N replicas, of which R must
+// respond to a read and W must acknowledge a write.
+type Quorum struct {
+	N int
+	R int
+	W int
+}
+
+// Returns the quorum in which reads and writes both need a majority of n
+// replicas.
+func MajorityQuorum(n int) Quorum {
+	return Quorum{N: n, R: n/2 + 1, W: n/2 + 1}
+}
+
+// Returns an error if R or W are not between 1 and N.
+func (q Quorum) Validate() error {
+	if q.N < 1 {
+		return fmt.Errorf("Invalid quorum, N must be at least 1: %d", q.N)
+	}
+	if q.R < 1 || q.R > q.N || q.W < 1 || q.W > q.N {
+		return fmt.Errorf("Invalid quorum, R and W must be between 1 and %d: R=%d, W=%d", q.N, q.R, q.W)
+	}
+	return nil
+}
+
+// Reports whether every read quorum overlaps with every write quorum, so
+// reads always observe the latest acknowledged write.
+func (q Quorum) Strong() bool {
+	return q.R+q.W > q.N
+}
+
+// The replicas of a key along with the ones that are currently available.
+type QuorumSelection struct {
+	Quorum
+	// The first N distinct items of the key, in ring order.
+	Replicas []string
+	// The available items of Replicas, in the same order.
+	Available []string
+}
+
+// Selects the replicas of key for the provided quorum. Items for which
+// the breaker registry reports an open breaker are not available.
+func (m *Map) SelectQuorum(key string, q Quorum) (*QuorumSelection, error) {
+	if err := q.Validate(); err != nil {
+		return nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	s := &QuorumSelection{Quorum: q, Replicas: m.preferenceList(m.hashKey(key), q.N)}
+	for _, node := range s.Replicas {
+		if m.available(node) {
+			s.Available = append(s.Available, node)
+		}
+	}
+	return s, nil
+}
+
+// Returns the first n distinct items starting at hash, regardless of
+// their availability. The caller must hold at least a read lock.
+func (m *Map) preferenceList(hash, n int) []string {
+	out := []string{}
+	m.walk(hash, func(_ int, res string) bool {
+		if AcceptUnique(out, res) {
+			out = append(out, res)
+		}
+		return len(out) < n
+	})
+	return out
+}
+
+// Reports whether enough replicas are available to reach a read quorum.
+func (s *QuorumSelection) CanRead() bool {
+	return len(s.Available) >= s.R
+}
+
+// Reports whether enough replicas are available to reach a write quorum.
+func (s *QuorumSelection) CanWrite() bool {
+	return len(s.Available) >= s.W
+}
+
+// Returns the R replicas to read from, or ErrNoQuorum.
+func (s *QuorumSelection) ReadSet() ([]string, error) {
+	if !s.CanRead() {
+		return nil, ErrNoQuorum
+	}
+	return s.Available[:s.R], nil
+}
+
+// Returns the replicas to write to, which are all available replicas of
+// which W must acknowledge the write, or ErrNoQuorum.
+func (s *QuorumSelection) WriteSet() ([]string, error) {
+	if !s.CanWrite() {
+		return nil, ErrNoQuorum
+	}
+	return s.Available, nil
+}
@@ -0,0 +1,87 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestQuorumValidate(t *testing.T) {
+	testCases := []struct {
+		q      Quorum
+		valid  bool
+		strong bool
+	}{
+		{MajorityQuorum(3), true, true},
+		{MajorityQuorum(4), true, true},
+		{Quorum{N: 3, R: 1, W: 1}, true, false},
+		{Quorum{N: 3, R: 1, W: 3}, true, true},
+		{Quorum{N: 3, R: 0, W: 3}, false, false},
+		{Quorum{N: 3, R: 4, W: 1}, false, true},
+		{Quorum{N: 0, R: 0, W: 0}, false, false},
+	}
+	for _, tc := range testCases {
+		if err := tc.q.Validate(); (err == nil) != tc.valid {
+			t.Errorf("Expected validity of %+v to be %v, but got: %v", tc.q, tc.valid, err)
+		}
+		if tc.q.Strong() != tc.strong {
+			t.Errorf("Expected strong consistency of %+v to be %v", tc.q, tc.strong)
+		}
+	}
+}
+
+func TestSelectQuorum(t *testing.T) {
+	hash := New(1, intHash)
+	hash.AddString("10", "20", "30", "40")
+
+	open := map[string]bool{}
+	hash.SetBreakerRegistry(BreakerRegistryFunc(func(key string) bool { return open[key] }))
+
+	s, err := hash.SelectQuorum("15", MajorityQuorum(3))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if !reflect.DeepEqual(s.Replicas, []string{"20", "30", "40"}) {
+		t.Errorf("Unexpected replicas: %v", s.Replicas)
+	}
+	if read, err := s.ReadSet(); err != nil || !reflect.DeepEqual(read, []string{"20", "30"}) {
+		t.Errorf("Unexpected read set: %v, %v", read, err)
+	}
+
+	open["20"] = true
+	open["40"] = true
+	s, _ = hash.SelectQuorum("15", MajorityQuorum(3))
+	if !reflect.DeepEqual(s.Replicas, []string{"20", "30", "40"}) || !reflect.DeepEqual(s.Available, []string{"30"}) {
+		t.Errorf("Unexpected selection: %+v", s)
+	}
+	if s.CanRead() || s.CanWrite() {
+		t.Errorf("Expected quorum not to be achievable")
+	}
+	if _, err := s.WriteSet(); err != ErrNoQuorum {
+		t.Errorf("Expected ErrNoQuorum, but got: %v", err)
+	}
+
+	s, _ = hash.SelectQuorum("15", Quorum{N: 3, R: 1, W: 1})
+	if write, err := s.WriteSet(); err != nil || !reflect.DeepEqual(write, []string{"30"}) {
+		t.Errorf("Unexpected write set: %v, %v", write, err)
+	}
+
+	if _, err := hash.SelectQuorum("15", Quorum{N: 3}); err == nil {
+		t.Errorf("Expected error for an invalid quorum")
+	}
+}
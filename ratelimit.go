@@ -0,0 +1,156 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// A token bucket rate limiter whose buckets are assigned to limiter
+// shards (the items of a hash) through the ring, so every key is counted
+// on a consistent owner. When membership changes, buckets are re-homed
+// to their new owner and keep their state. Buckets that have been idle
+// long enough to refill completely are dropped, as they are no different
+// from new ones, so memory is bounded by the keys seen recently.
+type ShardedLimiter struct {
+	m         *Map
+	rate      float64
+	burst     float64
+	idle      time.Duration // After which an unused bucket is full.
+	now       func() time.Time
+	cancel    func()
+	mu        sync.Mutex
+	shards    map[string]map[string]*tokenBucket
+	lastEvict time.Time
+}
+
+// Creates a limiter that allows rate requests per second per key, with
+// bursts of up to burst requests.
+func NewShardedLimiter(m *Map, rate float64, burst int) *ShardedLimiter {
+	l := &ShardedLimiter{
+		m:      m,
+		rate:   rate,
+		burst:  float64(burst),
+		now:    time.Now,
+		shards: make(map[string]map[string]*tokenBucket),
+	}
+	if rate > 0 {
+		l.idle = time.Duration(float64(burst) / rate * float64(time.Second))
+	}
+	l.cancel = m.OnChange(func(ChangeEvent) { l.rehome() })
+	return l
+}
+
+// Reports whether a request for key is allowed, along with the shard
+// that owns the key's bucket.
+func (l *ShardedLimiter) Allow(key string) (shard string, allowed bool) {
+	shard = l.m.Get(key)
+	if shard == "" {
+		return "", false
+	}
+	now := l.now()
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// Sweeping once per idle period keeps the cost per call constant.
+	if l.idle > 0 && now.Sub(l.lastEvict) >= l.idle {
+		l.evict(now)
+		l.lastEvict = now
+	}
+
+	buckets, exists := l.shards[shard]
+	if !exists {
+		buckets = make(map[string]*tokenBucket)
+		l.shards[shard] = buckets
+	}
+	b, exists := buckets[key]
+	if !exists {
+		b = &tokenBucket{tokens: l.burst, last: now}
+		buckets[key] = b
+	}
+
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+	if b.tokens < 1 {
+		return shard, false
+	}
+	b.tokens--
+	return shard, true
+}
+
+// Returns the number of buckets held by each shard.
+func (l *ShardedLimiter) Buckets() map[string]int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	out := make(map[string]int, len(l.shards))
+	for shard, buckets := range l.shards {
+		out[shard] = len(buckets)
+	}
+	return out
+}
+
+// Drops the buckets that have refilled completely. The caller must hold
+// the lock.
+func (l *ShardedLimiter) evict(now time.Time) {
+	for shard, buckets := range l.shards {
+		for key, b := range buckets {
+			if now.Sub(b.last) >= l.idle {
+				delete(buckets, key)
+			}
+		}
+		if len(buckets) == 0 {
+			delete(l.shards, shard)
+		}
+	}
+}
+
+// Moves every bucket to the shard that currently owns its key.
+func (l *ShardedLimiter) rehome() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.m.mu.RLock()
+	defer l.m.mu.RUnlock()
+
+	shards := make(map[string]map[string]*tokenBucket, len(l.shards))
+	for _, buckets := range l.shards {
+		for key, b := range buckets {
+			owner := l.m.get(key)
+			if owner == "" {
+				continue
+			}
+			if shards[owner] == nil {
+				shards[owner] = make(map[string]*tokenBucket)
+			}
+			shards[owner][key] = b
+		}
+	}
+	l.shards = shards
+}
+
+// Stops following membership changes of the hash.
+func (l *ShardedLimiter) Close() {
+	l.cancel()
+}
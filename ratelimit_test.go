@@ -0,0 +1,79 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestShardedLimiter(t *testing.T) {
+	hash := New(1, intHash)
+	hash.AddString("10", "30")
+
+	l := NewShardedLimiter(hash, 0.001, 2)
+	defer l.Close()
+
+	for i := 0; i < 2; i++ {
+		if shard, ok := l.Allow("15"); !ok || shard != "30" {
+			t.Errorf("Expected request %d to be allowed by 30, but got %s and %v", i, shard, ok)
+		}
+	}
+	if _, ok := l.Allow("15"); ok {
+		t.Errorf("Expected request beyond burst to be denied")
+	}
+
+	// The bucket moves to the new owner and keeps its state.
+	hash.AddString("20")
+	if res := l.Buckets(); res["20"] != 1 || res["30"] != 0 {
+		t.Errorf("Expected bucket to be re-homed to 20, but got: %v", res)
+	}
+	if shard, ok := l.Allow("15"); ok || shard != "20" {
+		t.Errorf("Expected re-homed bucket to still be exhausted on 20, but got %s and %v", shard, ok)
+	}
+}
+
+func TestShardedLimiterEviction(t *testing.T) {
+	hash := New(1, nil)
+	hash.AddString("A", "B")
+
+	now := time.Unix(0, 0)
+	l := NewShardedLimiter(hash, 1, 2)
+	l.now = func() time.Time { return now }
+	defer l.Close()
+
+	for i := 0; i < 100; i++ {
+		l.Allow(strconv.Itoa(i))
+	}
+	l.Allow("hot")
+	l.Allow("hot")
+
+	// The 100 keys have refilled in 2s and are dropped, the hot key has
+	// not.
+	now = now.Add(1500 * time.Millisecond)
+	l.Allow("hot")
+	now = now.Add(600 * time.Millisecond)
+	l.Allow("hot")
+	total := 0
+	for _, n := range l.Buckets() {
+		total += n
+	}
+	if total != 1 {
+		t.Errorf("Expected only the bucket of the hot key to be left, but got: %v", l.Buckets())
+	}
+}
@@ -0,0 +1,121 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"errors"
+	"sort"
+)
+
+// Improves the balance of the ring without changing any weights. Items
+// that own more than (1 + tolerance) times their fair share of the ring
+// hand part of their largest arc to the item that is furthest below its
+// fair share, by placing an extra virtual node of the latter inside the
+// arc. At most as many virtual nodes are placed as there are items, so
+// repeated calls converge gradually and the keys that move on each call
+// are bounded. Returns the changes that were applied.
+func (m *Map) Rebalance(tolerance float64) ([]Change, error) {
+	if tolerance < 0 {
+		return nil, errors.New("Tolerance must not be negative")
+	}
+
+	// Plan and apply with the write lock held, so the plan can't go stale.
+	var changes []Change
+	_, err := m.mutatePlanned(func() ([]Change, error) {
+		next := m.clone()
+		for len(changes) < len(next.entries) {
+			c, ok := next.rebalanceStep(tolerance)
+			if !ok {
+				break
+			}
+			if err := next.apply(c); err != nil {
+				return nil, err
+			}
+			changes = append(changes, c)
+		}
+		if len(changes) == 0 {
+			return nil, errUnchanged
+		}
+		m.swap(next)
+		return changes, nil
+	})
+	if err != nil || len(changes) == 0 {
+		return nil, err
+	}
+	m.currentLogger().Info("Rebalanced ring", "tolerance", tolerance, "virtual_nodes", len(changes))
+	return changes, nil
+}
+
+// Returns the change that moves the ring closest to balance, if any item
+// is overloaded. The caller must hold at least a read lock.
+func (m *Map) rebalanceStep(tolerance float64) (Change, bool) {
+	totalWeight := 0
+	for _, e := range m.entries {
+		totalWeight += e.weight
+	}
+	if totalWeight == 0 {
+		return Change{}, false
+	}
+
+	owned := make(map[string]float64, len(m.entries))
+	largest := make(map[string]Arc, len(m.entries))
+	m.arcs(func(owner string, start uint32, size uint64) {
+		owned[owner] += float64(size)
+		if size > largest[owner].Size {
+			largest[owner] = Arc{Owner: owner, Start: start, Size: size}
+		}
+	})
+
+	keys := make([]string, 0, len(m.entries))
+	for key := range m.entries {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	// Find the most overloaded item and the item that is furthest below
+	// its fair share, both in positions.
+	var from, to string
+	var excess, deficit, overload float64
+	for _, key := range keys {
+		fair := float64(m.entries[key].weight) / float64(totalWeight) * ringSize
+		if d := fair - owned[key]; d > deficit {
+			to, deficit = key, d
+		}
+		if ratio := owned[key] / fair; ratio > 1+tolerance && ratio > overload {
+			from, excess, overload = key, owned[key]-fair, ratio
+		}
+	}
+	if from == "" || to == "" {
+		return Change{}, false
+	}
+
+	arc := largest[from]
+	transfer := uint64(min(float64(arc.Size/2), excess, deficit))
+	if transfer == 0 {
+		return Change{}, false
+	}
+
+	// Walking clockwise a virtual node owns the positions up to its own,
+	// so it takes the start of the arc, otherwise it takes the end.
+	var pos uint32
+	if m.direction == Counterclockwise {
+		pos = arc.Start + uint32(arc.Size-transfer)
+	} else {
+		pos = arc.Start + uint32(transfer-1)
+	}
+	return Change{Op: ChangeAddVirtualNode, Key: to, Tokens: []uint32{pos}}, true
+}
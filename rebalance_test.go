@@ -0,0 +1,106 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestRebalance(t *testing.T) {
+	hash := New(1, intHash)
+	hash.AddWithTokens(&Node{ID: "a"}, []uint32{1 << 30})
+	hash.AddWithTokens(&Node{ID: "b"}, []uint32{1 << 31})
+
+	if _, err := hash.Rebalance(-1); err == nil {
+		t.Errorf("Expected error for negative tolerance")
+	}
+
+	// a owns three quarters of the ring, half of which is handed to b.
+	changes, err := hash.Rebalance(0.1)
+	expected := []Change{{Op: ChangeAddVirtualNode, Key: "b", Tokens: []uint32{3 << 30}}}
+	if err != nil || !reflect.DeepEqual(changes, expected) {
+		t.Errorf("Expected %v, but got: %v, %v", expected, changes, err)
+	}
+	for _, s := range hash.VNodeReport() {
+		if s.Share != 0.5 {
+			t.Errorf("Expected %s to own half of the ring, but got: %v", s.Node, s.Share)
+		}
+	}
+
+	if changes, err := hash.Rebalance(0.1); err != nil || changes != nil {
+		t.Errorf("Expected balanced ring to be left alone, but got: %v, %v", changes, err)
+	}
+}
+
+func TestRebalanceConverges(t *testing.T) {
+	hash := New(4, nil)
+	for _, key := range []string{"a", "b", "c", "d", "e", "f", "g", "h"} {
+		hash.AddString(key)
+	}
+	peak := func() float64 {
+		out := 0.0
+		for _, s := range hash.VNodeReport() {
+			out = max(out, s.Share/s.FairShare)
+		}
+		return out
+	}
+
+	before := peak()
+	for i := 0; i < 10; i++ {
+		changes, err := hash.Rebalance(0.05)
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		if len(changes) > 8 {
+			t.Errorf("Expected at most one virtual node per item, but got: %d", len(changes))
+		}
+	}
+	if after := peak(); after > 1.05+1e-9 || after > before {
+		t.Errorf("Expected peak share to drop from %v to within tolerance, but got: %v", before, after)
+	}
+	if err := hash.Validate(); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+	}
+}
+
+func TestRebalanceConcurrentChanges(t *testing.T) {
+	hash := New(2, nil)
+	hash.AddString("A", "B", "C")
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 200; i++ {
+			key := "N" + strconv.Itoa(i%5)
+			if hash.Del(key) != nil {
+				hash.AddString(key)
+			}
+		}
+	}()
+
+	for i := 0; i < 200; i++ {
+		if _, err := hash.Rebalance(0.05); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+	}
+	<-done
+	if err := hash.Validate(); err != nil {
+		t.Errorf("Expected a valid ring, but got: %s", err)
+	}
+}
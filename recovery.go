@@ -0,0 +1,227 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Removes members that fail their health check from the hash, and adds
+// them back once they pass it again. Removed members are probed with
+// exponential backoff, starting at initialBackoff and doubling after every
+// failed probe up to maxBackoff, so a member that keeps failing is probed
+// less and less often and a recovered member returns without operator
+// action. Members that are quarantined are not re-added.
+//
+// Unless configured otherwise with SetMaxRemoved, at most half of the
+// members are removed: when more fail at once, the checker itself is
+// more likely to be cut off than the members, so none are removed.
+type HealthRecovery struct {
+	m              *Map
+	health         HealthChecker
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+	maxRemoved     float64
+	now            func() time.Time
+	mu             sync.Mutex
+	removed        map[string]*removedMember
+}
+
+type removedMember struct {
+	value    EntryValue
+	node     SnapshotNode
+	failures int
+	next     time.Time
+}
+
+// Creates a health recovery for the hash.
+func NewHealthRecovery(m *Map, health HealthChecker, initialBackoff, maxBackoff time.Duration) *HealthRecovery {
+	return &HealthRecovery{
+		m:              m,
+		health:         health,
+		initialBackoff: initialBackoff,
+		maxBackoff:     maxBackoff,
+		maxRemoved:     0.5,
+		now:            time.Now,
+		removed:        make(map[string]*removedMember),
+	}
+}
+
+// Removes a member from the hash because it is unhealthy, and schedules
+// it to be probed. Its weight, tokens and labels are restored when it is
+// added back.
+func (r *HealthRecovery) Remove(key string) error {
+	node, exists := r.m.member(key)
+	value, _ := r.m.Value(key)
+	if !exists {
+		return fmt.Errorf("No node with name '%s' found", key)
+	}
+	if err := r.m.Del(key); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	r.removed[key] = &removedMember{value: value, node: node, next: r.now().Add(r.initialBackoff)}
+	r.mu.Unlock()
+	r.m.currentLogger().Warn("Unhealthy node removed", "node", key)
+	return nil
+}
+
+// Sets the largest fraction of the members, including the ones removed
+// earlier, that may be removed because they are unhealthy.
+func (r *HealthRecovery) SetMaxRemoved(fraction float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.maxRemoved = fraction
+}
+
+// Checks the health of every member of the hash, removes the ones that
+// fail, and returns their keys. If removing them would exceed the
+// maximum fraction of removed members, none are removed.
+func (r *HealthRecovery) Check(ctx context.Context) ([]string, error) {
+	r.m.mu.RLock()
+	values := make([]EntryValue, 0, len(r.m.entries))
+	for _, e := range r.m.entries {
+		values = append(values, e.value)
+	}
+	r.m.mu.RUnlock()
+
+	var failed []EntryValue
+	for _, value := range values {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if r.health.Check(ctx, value) != nil {
+			failed = append(failed, value)
+		}
+	}
+	if len(failed) == 0 {
+		return nil, nil
+	}
+
+	r.mu.Lock()
+	total := len(values) + len(r.removed)
+	allowed := int(r.maxRemoved*float64(total)) - len(r.removed)
+	r.mu.Unlock()
+	if len(failed) > allowed {
+		r.m.currentLogger().Warn("Too many unhealthy nodes, none removed", "failed", len(failed), "members", total)
+		return nil, fmt.Errorf("Refusing to remove %d of %d members, at most %d may be removed", len(failed), total, max(0, allowed))
+	}
+
+	var removed []string
+	var errs []error
+	for _, value := range failed {
+		if err := r.Remove(value.HashRingId()); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		removed = append(removed, value.HashRingId())
+	}
+	sort.Strings(removed)
+	return removed, errors.Join(errs...)
+}
+
+// Probes the removed members that are due, adds the ones that pass their
+// health check back to the hash, and returns their keys.
+func (r *HealthRecovery) Probe(ctx context.Context) ([]string, error) {
+	now := r.now()
+	r.mu.Lock()
+	due := make(map[string]*removedMember)
+	for key, member := range r.removed {
+		if !now.Before(member.next) {
+			due[key] = member
+		}
+	}
+	r.mu.Unlock()
+
+	var readded []string
+	var errs []error
+	for key, member := range due {
+		if err := ctx.Err(); err != nil {
+			return readded, err
+		}
+		if _, quarantined := r.m.Quarantined(key); quarantined || r.health.Check(ctx, member.value) != nil {
+			r.backoff(member, now)
+			continue
+		}
+
+		changes := member.node.changes()
+		changes[0].Value = member.value
+		if err := r.m.Apply(changes...); err != nil {
+			r.backoff(member, now)
+			errs = append(errs, err)
+			continue
+		}
+		r.mu.Lock()
+		delete(r.removed, key)
+		r.mu.Unlock()
+		r.m.currentLogger().Info("Recovered node re-added", "node", key, "failed_probes", member.failures)
+		readded = append(readded, key)
+	}
+	sort.Strings(readded)
+	return readded, errors.Join(errs...)
+}
+
+// Schedules the next probe of a member after a failed one.
+func (r *HealthRecovery) backoff(member *removedMember, now time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	member.failures++
+	delay := r.initialBackoff
+	for i := 0; i < member.failures && delay < r.maxBackoff; i++ {
+		delay *= 2
+	}
+	member.next = now.Add(min(delay, r.maxBackoff))
+}
+
+// Forgets a removed member, so it is no longer probed.
+func (r *HealthRecovery) Forget(key string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.removed, key)
+}
+
+// Returns the removed members that are waiting to be re-added, along
+// with the time of their next probe.
+func (r *HealthRecovery) Removed() map[string]time.Time {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	out := make(map[string]time.Time, len(r.removed))
+	for key, member := range r.removed {
+		out[key] = member.next
+	}
+	return out
+}
+
+// Calls Check and Probe every interval until ctx is done, reporting
+// their errors to onError unless it is nil.
+func (r *HealthRecovery) Run(ctx context.Context, interval time.Duration, onError func(error)) error {
+	return runEvery(ctx, interval, func() error {
+		_, err := r.Check(ctx)
+		if _, probeErr := r.Probe(ctx); probeErr != nil {
+			err = errors.Join(err, probeErr)
+		}
+		return err
+	}, onError)
+}
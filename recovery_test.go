@@ -0,0 +1,127 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestHealthRecovery(t *testing.T) {
+	hash := New(1, intHash)
+	hash.AddString("10")
+	hash.AddWithWeight(&Node{ID: "20", Tags: map[string]string{"zone": "a"}}, 2)
+	hash.AddVirtualNode("20", 50)
+
+	var mu sync.Mutex
+	unhealthy := map[string]bool{"20": true}
+	health := HealthCheckerFunc(func(_ context.Context, value EntryValue) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if unhealthy[value.HashRingId()] {
+			return errors.New("unhealthy")
+		}
+		return nil
+	})
+
+	r := NewHealthRecovery(hash, health, time.Second, 5*time.Second)
+	now := time.Unix(1000, 0)
+	r.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	if removed, err := r.Check(ctx); err != nil || !reflect.DeepEqual(removed, []string{"20"}) {
+		t.Fatalf("Expected 20 to be removed, but got: %v, %v", removed, err)
+	}
+	if _, exists := hash.Value("20"); exists {
+		t.Errorf("Expected 20 to be removed from the hash")
+	}
+
+	// Failed probes back off: 1s, 2s, 4s, then capped at 5s.
+	for _, delay := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second} {
+		if readded, _ := r.Probe(ctx); len(readded) != 0 {
+			t.Fatalf("Expected no probe before the backoff elapsed, but got: %v", readded)
+		}
+		now = now.Add(delay)
+		if readded, _ := r.Probe(ctx); len(readded) != 0 {
+			t.Fatalf("Expected unhealthy member to stay removed, but got: %v", readded)
+		}
+	}
+	if next := r.Removed()["20"]; !next.Equal(now.Add(5 * time.Second)) {
+		t.Errorf("Expected next probe to be capped at 5s, but got: %v", next.Sub(now))
+	}
+
+	mu.Lock()
+	unhealthy["20"] = false
+	mu.Unlock()
+	hash.Quarantine("20", time.Now().Add(time.Hour))
+	now = now.Add(5 * time.Second)
+	if readded, _ := r.Probe(ctx); len(readded) != 0 {
+		t.Errorf("Expected quarantined member not to be re-added, but got: %v", readded)
+	}
+
+	hash.Unquarantine("20")
+	now = now.Add(5 * time.Second)
+	if readded, err := r.Probe(ctx); err != nil || !reflect.DeepEqual(readded, []string{"20"}) {
+		t.Fatalf("Expected 20 to be re-added, but got: %v, %v", readded, err)
+	}
+	if positions := hash.VirtualNodesOf("20"); !reflect.DeepEqual(positions, []uint32{20, 50, 120}) {
+		t.Errorf("Expected weight and virtual nodes to be restored, but got: %v", positions)
+	}
+	if labels := hash.Labels("20"); labels["zone"] != "a" || len(r.Removed()) != 0 {
+		t.Errorf("Expected labels to be restored, but got: %v", labels)
+	}
+}
+
+func TestHealthRecoveryMaxRemoved(t *testing.T) {
+	hash := New(1, intHash)
+	hash.AddString("10", "20", "30", "40")
+
+	healthy := map[string]bool{"40": true}
+	health := HealthCheckerFunc(func(_ context.Context, value EntryValue) error {
+		if !healthy[value.HashRingId()] {
+			return errors.New("unhealthy")
+		}
+		return nil
+	})
+	r := NewHealthRecovery(hash, health, time.Second, time.Second)
+	ctx := context.Background()
+
+	// The checker can't reach most members, so none are removed.
+	if removed, err := r.Check(ctx); err == nil || len(removed) != 0 || len(hash.Snapshot().Nodes) != 4 {
+		t.Errorf("Expected no members to be removed, but got: %v, %v", removed, err)
+	}
+
+	healthy["30"] = true
+	if removed, err := r.Check(ctx); err != nil || !reflect.DeepEqual(removed, []string{"10", "20"}) {
+		t.Errorf("Expected 10 and 20 to be removed, but got: %v, %v", removed, err)
+	}
+
+	// Members removed earlier count towards the limit.
+	healthy["30"] = false
+	if removed, err := r.Check(ctx); err == nil || len(removed) != 0 {
+		t.Errorf("Expected no more members to be removed, but got: %v, %v", removed, err)
+	}
+
+	r.SetMaxRemoved(1)
+	if removed, err := r.Check(ctx); err != nil || !reflect.DeepEqual(removed, []string{"30"}) {
+		t.Errorf("Expected 30 to be removed, but got: %v, %v", removed, err)
+	}
+}
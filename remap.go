@@ -0,0 +1,152 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"sort"
+	"time"
+)
+
+// The effect of a single modification of the hash on the ring.
+type RemapStat struct {
+	Epoch   uint64
+	At      time.Time
+	Changes []Change
+	// The ranges of the ring that changed owner.
+	Ranges []HashRange
+	// The fraction of the ring (between 0 and 1) that changed owner.
+	Moved float64
+	// The number of virtual nodes that were added, removed or changed
+	// owner.
+	VirtualNodes int
+}
+
+type remapHistory struct {
+	size  int
+	stats []RemapStat
+}
+
+// Starts measuring the effect of every modification of the hash, and
+// keeps the measurements of the last history modifications. The
+// measurements are also passed to change listeners. Measuring requires a
+// copy of the ring for every modification; passing a history of zero or
+// less stops measuring.
+func (m *Map) TrackRemaps(history int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if history < 1 {
+		m.remaps = nil
+		return
+	}
+	if m.remaps == nil {
+		m.remaps = &remapHistory{}
+	}
+	m.remaps.size = history
+	m.remaps.trim()
+}
+
+// Returns the measurements of the tracked modifications, oldest first.
+func (m *Map) Remaps() []RemapStat {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if m.remaps == nil {
+		return nil
+	}
+	return append([]RemapStat(nil), m.remaps.stats...)
+}
+
+// Returns the ranges of the ring that changed owner after sinceEpoch, so
+// caches can invalidate or warm only those ranges. Ranges that changed
+// owner and changed back are included as well. The result is exact only
+// if all modifications since then are within the tracked history (see
+// TrackRemaps); otherwise the whole ring is returned.
+func (m *Map) ChangedRanges(sinceEpoch uint64) []HashRange {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if sinceEpoch >= m.epoch {
+		return nil
+	}
+	if m.remaps == nil || len(m.remaps.stats) == 0 || m.remaps.stats[0].Epoch > sinceEpoch+1 {
+		return []HashRange{{0, 1<<32 - 1}}
+	}
+
+	var ranges []HashRange
+	for _, stat := range m.remaps.stats {
+		if stat.Epoch > sinceEpoch {
+			ranges = append(ranges, stat.Ranges...)
+		}
+	}
+	return mergeRanges(ranges)
+}
+
+// Sorts the ranges and merges the ones that overlap or are adjacent.
+func mergeRanges(ranges []HashRange) []HashRange {
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i].Start < ranges[j].Start })
+
+	var out []HashRange
+	for _, r := range ranges {
+		if n := len(out); n > 0 && uint64(r.Start) <= uint64(out[n-1].End)+1 {
+			out[n-1].End = max(out[n-1].End, r.End)
+			continue
+		}
+		out = append(out, r)
+	}
+	return out
+}
+
+// Records the effect of event, which turned prev into next. The caller
+// must hold the write lock of next.
+func (h *remapHistory) record(prev, next *Map, event ChangeEvent) *RemapStat {
+	ranges := changedRanges(prev, next)
+	stat := RemapStat{
+		Epoch:        event.Epoch,
+		At:           time.Now(),
+		Changes:      event.Changes,
+		Ranges:       ranges,
+		Moved:        rangesFraction(ranges),
+		VirtualNodes: changedVirtualNodes(prev, next),
+	}
+	h.stats = append(h.stats, stat)
+	h.trim()
+	return &stat
+}
+
+func (h *remapHistory) trim() {
+	if n := len(h.stats); n > h.size {
+		h.stats = append([]RemapStat(nil), h.stats[n-h.size:]...)
+	}
+}
+
+// Returns the number of positions that were added, removed or got
+// another owner between a and b.
+func changedVirtualNodes(a, b *Map) int {
+	n := 0
+	for pos, id := range a.hashMap {
+		if owner, exists := b.hashMap[pos]; !exists || b.names[owner] != a.names[id] {
+			n++
+		}
+	}
+	for pos := range b.hashMap {
+		if _, exists := a.hashMap[pos]; !exists {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTrackRemaps(t *testing.T) {
+	hash := New(1, intHash)
+	hash.AddString("10")
+	if stats := hash.Remaps(); stats != nil {
+		t.Errorf("Expected no measurements before tracking, but got: %v", stats)
+	}
+
+	hash.TrackRemaps(2)
+	var events []ChangeEvent
+	hash.OnChange(func(event ChangeEvent) { events = append(events, event) })
+
+	hash.AddString("20")
+	hash.AddString("30")
+	hash.Apply(RemoveChange("20"), AddChange(&StringValue{"40"}, 1))
+
+	stats := hash.Remaps()
+	if len(stats) != 2 {
+		t.Fatalf("Expected the last 2 measurements, but got: %v", stats)
+	}
+
+	// Adding 30 moves (20, 30] from 10 to 30.
+	if stats[0].Epoch != 3 || stats[0].VirtualNodes != 1 || !reflect.DeepEqual(stats[0].Ranges, []HashRange{{21, 30}}) {
+		t.Errorf("Unexpected measurement: %+v", stats[0])
+	}
+	if stats[0].Moved != 10/ringSize {
+		t.Errorf("Expected 10 positions to have moved, but got: %v", stats[0].Moved*ringSize)
+	}
+
+	// Replacing 20 by 40 moves (10, 20] to 30 and (30, 40] to 40.
+	if stats[1].VirtualNodes != 2 || !reflect.DeepEqual(stats[1].Ranges, []HashRange{{11, 20}, {31, 40}}) {
+		t.Errorf("Unexpected measurement: %+v", stats[1])
+	}
+	if len(stats[1].Changes) != 2 {
+		t.Errorf("Expected measurement to hold the changes, but got: %v", stats[1].Changes)
+	}
+
+	if len(events) != 3 || events[2].Remap == nil || events[2].Remap.Epoch != 4 {
+		t.Errorf("Expected measurements to be passed to listeners, but got: %+v", events)
+	}
+
+	hash.TrackRemaps(0)
+	hash.AddString("50")
+	if stats := hash.Remaps(); stats != nil {
+		t.Errorf("Expected no measurements after disabling, but got: %v", stats)
+	}
+}
+
+func TestChangedRanges(t *testing.T) {
+	hash := New(1, intHash)
+	hash.AddString("10", "50")
+
+	full := []HashRange{{0, 1<<32 - 1}}
+	if ranges := hash.ChangedRanges(0); !reflect.DeepEqual(ranges, full) {
+		t.Errorf("Expected the whole ring without tracking, but got: %v", ranges)
+	}
+
+	hash.TrackRemaps(10)
+	epoch := hash.Epoch()
+	if ranges := hash.ChangedRanges(epoch); ranges != nil {
+		t.Errorf("Expected no ranges without changes, but got: %v", ranges)
+	}
+
+	hash.AddString("30")
+	hash.AddString("20")
+	hash.AddString("40")
+
+	expected := []HashRange{{11, 40}}
+	if ranges := hash.ChangedRanges(epoch); !reflect.DeepEqual(ranges, expected) {
+		t.Errorf("Expected %v, but got: %v", expected, ranges)
+	}
+	expected = []HashRange{{31, 40}}
+	if ranges := hash.ChangedRanges(epoch + 2); !reflect.DeepEqual(ranges, expected) {
+		t.Errorf("Expected %v, but got: %v", expected, ranges)
+	}
+
+	// Changes before tracking started are unknown.
+	if ranges := hash.ChangedRanges(epoch - 1); !reflect.DeepEqual(ranges, full) {
+		t.Errorf("Expected the whole ring, but got: %v", ranges)
+	}
+}
+
+func TestMergeRanges(t *testing.T) {
+	ranges := []HashRange{{30, 40}, {0, 5}, {6, 10}, {35, 50}, {60, 70}}
+	expected := []HashRange{{0, 10}, {30, 50}, {60, 70}}
+	if merged := mergeRanges(ranges); !reflect.DeepEqual(merged, expected) {
+		t.Errorf("Expected %v, but got: %v", expected, merged)
+	}
+}
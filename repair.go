@@ -0,0 +1,132 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+)
+
+// The result of reading a key from a single replica.
+type ReplicaRead struct {
+	Node  string
+	Value interface{}
+	Err   error
+}
+
+// Reads a key from a single replica.
+type ReplicaReader func(ctx context.Context, node, key string) (interface{}, error)
+
+// Writes a value of a key to a single replica.
+type ReplicaWriter func(ctx context.Context, node, key string, value interface{}) error
+
+// Chooses the value to return and repair stale replicas with from the
+// successful reads of all replicas, which are in ring order.
+type ReadResolver func(reads []ReplicaRead) interface{}
+
+// Reads keys from all of their replicas and writes the resolved value
+// back to replicas that returned a different one.
+type ReadRepair struct {
+	m        *Map
+	replicas int
+	read     ReplicaReader
+	write    ReplicaWriter
+	resolve  ReadResolver
+}
+
+// Creates a read repairer that reads from the first replicas distinct
+// items of every key. If resolve is nil, ResolveMajority is used.
+func NewReadRepair(m *Map, replicas int, read ReplicaReader, write ReplicaWriter, resolve ReadResolver) *ReadRepair {
+	if resolve == nil {
+		resolve = ResolveMajority
+	}
+	return &ReadRepair{m: m, replicas: replicas, read: read, write: write, resolve: resolve}
+}
+
+// Chooses the value returned by most replicas. Ties are won by the value
+// returned by the replica that comes first in ring order.
+func ResolveMajority(reads []ReplicaRead) interface{} {
+	best, bestCount := 0, 0
+	for i, a := range reads {
+		count := 0
+		for _, b := range reads {
+			if reflect.DeepEqual(a.Value, b.Value) {
+				count++
+			}
+		}
+		if count > bestCount {
+			best, bestCount = i, count
+		}
+	}
+	return reads[best].Value
+}
+
+// Reads key from all of its replicas in parallel and returns the
+// resolved value, which is written back to every replica that returned a
+// different value. Replicas that failed to read are neither considered
+// nor repaired. Returns the names of the repaired replicas, and an error
+// if no replica could be read or a repair failed; in the latter case the
+// resolved value is returned as well.
+func (r *ReadRepair) Get(ctx context.Context, key string) (value interface{}, repaired []string, err error) {
+	nodes := r.m.GetN(key, r.replicas, AcceptUnique)
+	if len(nodes) == 0 {
+		return nil, nil, ErrEmptyRing
+	}
+
+	reads := make([]ReplicaRead, len(nodes))
+	var wg sync.WaitGroup
+	for i, node := range nodes {
+		wg.Add(1)
+		go func(i int, node string) {
+			defer wg.Done()
+			value, err := r.read(ctx, node, key)
+			reads[i] = ReplicaRead{Node: node, Value: value, Err: err}
+		}(i, node)
+	}
+	wg.Wait()
+
+	var (
+		ok   []ReplicaRead
+		errs []error
+	)
+	for _, read := range reads {
+		if read.Err != nil {
+			errs = append(errs, read.Err)
+			continue
+		}
+		ok = append(ok, read)
+	}
+	if len(ok) == 0 {
+		return nil, nil, errors.Join(errs...)
+	}
+
+	value = r.resolve(ok)
+	errs = nil
+	for _, read := range ok {
+		if reflect.DeepEqual(read.Value, value) {
+			continue
+		}
+		if err := r.write(ctx, read.Node, key, value); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		repaired = append(repaired, read.Node)
+	}
+	return value, repaired, errors.Join(errs...)
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+type fakeReplicas struct {
+	mu     sync.Mutex
+	values map[string]interface{}
+	failed map[string]bool
+}
+
+func (f *fakeReplicas) read(ctx context.Context, node, key string) (interface{}, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.failed[node] {
+		return nil, errors.New("read of " + node + " failed")
+	}
+	return f.values[node], nil
+}
+
+func (f *fakeReplicas) write(ctx context.Context, node, key string, value interface{}) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.failed[node] {
+		return errors.New("write of " + node + " failed")
+	}
+	f.values[node] = value
+	return nil
+}
+
+func TestReadRepair(t *testing.T) {
+	ctx := context.Background()
+	hash := New(1, intHash)
+	hash.AddString("10", "20", "30")
+
+	replicas := &fakeReplicas{
+		values: map[string]interface{}{"10": "new", "20": "old", "30": "new"},
+		failed: map[string]bool{},
+	}
+	rr := NewReadRepair(hash, 3, replicas.read, replicas.write, nil)
+
+	value, repaired, err := rr.Get(ctx, "5")
+	if err != nil || value != "new" || !reflect.DeepEqual(repaired, []string{"20"}) {
+		t.Errorf("Expected 20 to be repaired with new, but got: %v, %v, %v", value, repaired, err)
+	}
+	if replicas.values["20"] != "new" {
+		t.Errorf("Expected 20 to hold new, but got: %v", replicas.values["20"])
+	}
+
+	// Failed replicas are ignored, ties are won in ring order.
+	replicas.values["10"] = "first"
+	replicas.failed["30"] = true
+	value, repaired, err = rr.Get(ctx, "15")
+	if err != nil || value != "new" || !reflect.DeepEqual(repaired, []string{"10"}) {
+		t.Errorf("Expected 10 to be repaired with new, but got: %v, %v, %v", value, repaired, err)
+	}
+
+	replicas.failed = map[string]bool{"10": true, "20": true, "30": true}
+	if _, _, err := rr.Get(ctx, "5"); err == nil {
+		t.Errorf("Expected an error when all reads fail")
+	}
+
+	latest := func(reads []ReplicaRead) interface{} { return reads[len(reads)-1].Value }
+	replicas.failed = map[string]bool{}
+	replicas.values["30"] = "newest"
+	rr = NewReadRepair(hash, 3, replicas.read, replicas.write, latest)
+	if value, repaired, _ := rr.Get(ctx, "5"); value != "newest" || len(repaired) != 2 {
+		t.Errorf("Expected custom resolver to be used, but got: %v, %v", value, repaired)
+	}
+
+	if _, _, err := NewReadRepair(New(1, nil), 3, replicas.read, replicas.write, nil).Get(ctx, "5"); err != ErrEmptyRing {
+		t.Errorf("Expected ErrEmptyRing, but got: %v", err)
+	}
+}
@@ -0,0 +1,113 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"sync"
+)
+
+// The number of replicas to place per value of a label, for example
+// ReplicaPlacement{"dc1": 2, "dc2": 1} for the "dc" label.
+type ReplicaPlacement map[string]int
+
+// Returns the total number of replicas of the placement.
+func (p ReplicaPlacement) Total() int {
+	total := 0
+	for _, n := range p {
+		total += n
+	}
+	return total
+}
+
+// Gets exactly as many distinct items per value of label as the
+// placement specifies, in ring order starting at key. Returns the items
+// found along with an error if there are not enough available items for
+// any of the label values.
+func (m *Map) GetNByPlacement(key, label string, placement ReplicaPlacement) ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	remaining := make(map[string]int, len(placement))
+	for value, n := range placement {
+		if n > 0 {
+			remaining[value] = n
+		}
+	}
+
+	out := []string{}
+	seen := make(map[string]bool)
+	m.walk(m.hashKey(key), func(_ int, res string) bool {
+		if seen[res] || !m.available(res) {
+			return true
+		}
+		seen[res] = true
+
+		value := m.entries[res].labels[label]
+		if remaining[value] > 0 {
+			remaining[value]--
+			out = append(out, res)
+			if remaining[value] == 0 {
+				delete(remaining, value)
+			}
+		}
+		return len(remaining) > 0
+	})
+
+	if len(remaining) > 0 {
+		values := make([]string, 0, len(remaining))
+		for value := range remaining {
+			values = append(values, value)
+		}
+		sort.Strings(values)
+		return out, fmt.Errorf("Not enough nodes with %s=%s: missing %d", label, values[0], remaining[values[0]])
+	}
+	return out, nil
+}
+
+// Gets the N distinct closest items to the provided key like GetN, and
+// returns one of them picked at random. This spreads reads over all
+// replicas instead of always sending them to the first one. Use
+// WithReplicaSeed for a reproducible sequence of picks.
+func (m *Map) GetAnyReplica(key string, n int) string {
+	m.mu.RLock()
+	replicas := m.getN(key, n, AcceptUnique)
+	rnd := m.replicaRand
+	m.mu.RUnlock()
+
+	if len(replicas) == 0 {
+		return ""
+	}
+	if rnd == nil {
+		return replicas[rand.Intn(len(replicas))]
+	}
+	return replicas[rnd.Intn(len(replicas))]
+}
+
+// A source of random numbers that is safe for concurrent use.
+type lockedRand struct {
+	mu  sync.Mutex
+	rnd *rand.Rand
+}
+
+func (r *lockedRand) Intn(n int) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.rnd.Intn(n)
+}
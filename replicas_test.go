@@ -0,0 +1,87 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestGetNByPlacement(t *testing.T) {
+	hash := New(1, intHash)
+	for key, dc := range map[string]string{"10": "dc1", "20": "dc2", "30": "dc1", "40": "dc1", "50": "dc2"} {
+		hash.AddWithWeight(&Node{ID: key, Tags: map[string]string{"dc": dc}}, 1)
+	}
+
+	placement := ReplicaPlacement{"dc1": 2, "dc2": 1}
+	if placement.Total() != 3 {
+		t.Errorf("Expected total of 3, but got: %d", placement.Total())
+	}
+
+	nodes, err := hash.GetNByPlacement("15", "dc", placement)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if expected := []string{"20", "30", "40"}; !reflect.DeepEqual(nodes, expected) {
+		t.Errorf("Expected %v, but got: %v", expected, nodes)
+	}
+
+	nodes, err = hash.GetNByPlacement("15", "dc", ReplicaPlacement{"dc2": 3, "dc1": 1})
+	if err == nil || err.Error() != "Not enough nodes with dc=dc2: missing 1" {
+		t.Errorf("Expected error for missing dc2 node, but got: %v", err)
+	}
+	if expected := []string{"20", "30", "50"}; !reflect.DeepEqual(nodes, expected) {
+		t.Errorf("Expected %v, but got: %v", expected, nodes)
+	}
+
+	if nodes, err := hash.GetNByPlacement("15", "dc", nil); err != nil || len(nodes) != 0 {
+		t.Errorf("Expected no nodes for an empty placement, but got: %v, %v", nodes, err)
+	}
+}
+
+func TestGetAnyReplica(t *testing.T) {
+	hash := New(1, intHash, WithReplicaSeed(1))
+	hash.AddString("10", "20", "30", "40")
+
+	picks := make(map[string]int)
+	var sequence []string
+	for i := 0; i < 300; i++ {
+		node := hash.GetAnyReplica("15", 3)
+		picks[node]++
+		sequence = append(sequence, node)
+	}
+	if len(picks) != 3 || picks["10"] != 0 {
+		t.Errorf("Expected picks to be spread over 20, 30 and 40, but got: %v", picks)
+	}
+	for node, n := range picks {
+		if n < 50 {
+			t.Errorf("Expected %s to be picked about 100 times, but got: %d", node, n)
+		}
+	}
+
+	again := New(1, intHash, WithReplicaSeed(1))
+	again.AddString("10", "20", "30", "40")
+	for i, expected := range sequence {
+		if node := again.GetAnyReplica("15", 3); node != expected {
+			t.Fatalf("Expected the same sequence of picks for the same seed, but pick %d differs: %s != %s", i, node, expected)
+		}
+	}
+
+	if node := New(1, intHash).GetAnyReplica("15", 3); node != "" {
+		t.Errorf("Expected no replica for empty hash, but got: %s", node)
+	}
+}
@@ -0,0 +1,130 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+// The number of keys routed to a single item.
+type NodeLoad struct {
+	Node  string
+	Keys  int
+	Share float64 // Fraction of all keys, between 0 and 1.
+}
+
+// The number of occurrences of a single key.
+type KeyCount struct {
+	Key   string
+	Count int
+	Node  string
+}
+
+// The distribution of a stream of keys over the items of a hash.
+type KeyLoadReport struct {
+	Total int
+	// All items, the busiest first.
+	Nodes []NodeLoad
+	// The most frequent keys, the most frequent first.
+	TopKeys []KeyCount
+}
+
+// Routes every key of the source (e.g. KeysFromReader(os.Stdin) for one
+// key per line) and reports how many keys every item receives, along
+// with the top most frequent keys. Empty keys are skipped.
+func (m *Map) ReportKeys(keys KeySource, top int) (*KeyLoadReport, error) {
+	counts := make(map[string]int)
+	nodes := make(map[string]int)
+	m.mu.RLock()
+	for key := range m.entries {
+		nodes[key] = 0
+	}
+	m.mu.RUnlock()
+
+	report := &KeyLoadReport{}
+	for keys.Scan() {
+		key := keys.Text()
+		if key == "" {
+			continue
+		}
+		counts[key]++
+		nodes[m.Get(key)]++
+		report.Total++
+	}
+	if err := keys.Err(); err != nil {
+		return nil, err
+	}
+
+	for node, n := range nodes {
+		load := NodeLoad{Node: node, Keys: n}
+		if report.Total > 0 {
+			load.Share = float64(n) / float64(report.Total)
+		}
+		report.Nodes = append(report.Nodes, load)
+	}
+	sort.Slice(report.Nodes, func(i, j int) bool {
+		a, b := report.Nodes[i], report.Nodes[j]
+		if a.Keys != b.Keys {
+			return a.Keys > b.Keys
+		}
+		return a.Node < b.Node
+	})
+
+	for key, n := range counts {
+		report.TopKeys = append(report.TopKeys, KeyCount{Key: key, Count: n})
+	}
+	sort.Slice(report.TopKeys, func(i, j int) bool {
+		a, b := report.TopKeys[i], report.TopKeys[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.Key < b.Key
+	})
+	if len(report.TopKeys) > top {
+		report.TopKeys = report.TopKeys[:max(top, 0)]
+	}
+	for i := range report.TopKeys {
+		report.TopKeys[i].Node = m.Get(report.TopKeys[i].Key)
+	}
+	return report, nil
+}
+
+// Writes the report as human readable tables.
+func (r *KeyLoadReport) WriteTo(w io.Writer) (int64, error) {
+	var sb strings.Builder
+	tw := tabwriter.NewWriter(&sb, 0, 4, 2, ' ', 0)
+
+	fmt.Fprintf(tw, "NODE\tKEYS\tSHARE\n")
+	for _, n := range r.Nodes {
+		fmt.Fprintf(tw, "%s\t%d\t%.2f%%\n", n.Node, n.Keys, n.Share*100)
+	}
+	fmt.Fprintf(tw, "TOTAL\t%d\t100.00%%\n", r.Total)
+	if len(r.TopKeys) > 0 {
+		fmt.Fprintf(tw, "\nKEY\tCOUNT\tNODE\n")
+		for _, k := range r.TopKeys {
+			fmt.Fprintf(tw, "%s\t%d\t%s\n", k.Key, k.Count, k.Node)
+		}
+	}
+	tw.Flush()
+
+	n, err := io.WriteString(w, sb.String())
+	return int64(n), err
+}
@@ -0,0 +1,63 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReportKeys(t *testing.T) {
+	hash := New(1, intHash)
+	hash.AddString("10", "20", "30")
+
+	keys := "5\n15\n15\n\n25\n15\n5\n8\n"
+	report, err := hash.ReportKeys(KeysFromReader(strings.NewReader(keys)), 2)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	expected := &KeyLoadReport{
+		Total: 7,
+		Nodes: []NodeLoad{
+			{"10", 3, 3.0 / 7},
+			{"20", 3, 3.0 / 7},
+			{"30", 1, 1.0 / 7},
+		},
+		TopKeys: []KeyCount{{"15", 3, "20"}, {"5", 2, "10"}},
+	}
+	if !reflect.DeepEqual(report, expected) {
+		t.Errorf("Expected %+v, but got: %+v", expected, report)
+	}
+
+	var sb strings.Builder
+	report.WriteTo(&sb)
+	expectedText := `NODE   KEYS  SHARE
+10     3     42.86%
+20     3     42.86%
+30     1     14.29%
+TOTAL  7     100.00%
+
+KEY  COUNT  NODE
+15   3      20
+5    2      10
+`
+	if sb.String() != expectedText {
+		t.Errorf("Unexpected report:\n%s", sb.String())
+	}
+}
@@ -0,0 +1,185 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// Checks whether an item is able to serve requests.
+type HealthChecker interface {
+	Check(ctx context.Context, value EntryValue) error
+}
+
+// A HealthChecker backed by a function.
+type HealthCheckerFunc func(ctx context.Context, value EntryValue) error
+
+func (f HealthCheckerFunc) Check(ctx context.Context, value EntryValue) error {
+	return f(ctx, value)
+}
+
+// Sets the health checker consulted by GetHealthy. Passing nil disables
+// health checks.
+func (m *Map) SetHealthChecker(health HealthChecker) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.health = health
+}
+
+// Gets the first distinct owner of key that passes the health checker of
+// the hash, and the number of owners that were skipped for being
+// unhealthy. Health checks are passed ctx, so its deadline bounds the
+// whole lookup. Returns ErrEmptyRing if there are no items, the error of
+// ctx if it is done, or an error wrapping ErrNotEnoughNodes and the
+// health check errors if no owner is healthy.
+func (m *Map) GetHealthy(ctx context.Context, key string) (string, int, error) {
+	m.mu.RLock()
+	if m.isEmpty() {
+		m.mu.RUnlock()
+		return "", 0, ErrEmptyRing
+	}
+	candidates := m.getN(key, len(m.entries), AcceptUnique)
+	health := m.health
+	values := make([]EntryValue, len(candidates))
+	for i, node := range candidates {
+		values[i] = m.entries[node].value
+	}
+	m.mu.RUnlock()
+
+	errs := []error{ErrNotEnoughNodes}
+	for i, node := range candidates {
+		if health == nil {
+			return node, i, nil
+		}
+		if err := ctx.Err(); err != nil {
+			return "", i, err
+		}
+		if err := health.Check(ctx, values[i]); err != nil {
+			errs = append(errs, fmt.Errorf("Node '%s' is unhealthy: %w", node, err))
+			continue
+		}
+		return node, i, nil
+	}
+	return "", len(candidates), errors.Join(errs...)
+}
+
+// Counters describing the calls made through a Router.
+type RouterStats struct {
+	Calls     uint64 // Calls to Do.
+	Failures  uint64 // Calls to Do that failed on every candidate.
+	Fallbacks uint64 // Times fn failed and the next owner was tried.
+	Unhealthy uint64 // Times an owner was skipped by the health checker.
+}
+
+// Routes calls for a key to the owner of that key, falling back to the
+// next distinct owners if the owner is unhealthy or the call fails.
+type Router struct {
+	m           *Map
+	health      HealthChecker
+	maxAttempts int
+	calls       uint64
+	failures    uint64
+	fallbacks   uint64
+	unhealthy   uint64
+}
+
+// Creates a router for the provided hash. The health checker is optional.
+// At most maxAttempts owners are tried per call; if maxAttempts is smaller
+// than 1 all distinct owners may be tried.
+func NewRouter(m *Map, health HealthChecker, maxAttempts int) *Router {
+	return &Router{m: m, health: health, maxAttempts: maxAttempts}
+}
+
+// Resolves the owner of key and calls fn with it. If the owner is
+// unhealthy, or fn returns an error, the next distinct owner is tried.
+// When the hash has a latency tracker, the duration of every call to fn
+// is observed.
+func (r *Router) Do(ctx context.Context, key string, fn func(ctx context.Context, value EntryValue) error) error {
+	atomic.AddUint64(&r.calls, 1)
+
+	r.m.mu.RLock()
+	n := len(r.m.entries)
+	if r.maxAttempts > 0 && r.maxAttempts < n {
+		n = r.maxAttempts
+	}
+	candidates := r.m.getN(key, n, AcceptUnique)
+	latencies := r.m.latencies
+	r.m.mu.RUnlock()
+
+	if len(candidates) == 0 {
+		atomic.AddUint64(&r.failures, 1)
+		return ErrEmptyRing
+	}
+	return r.attempt(ctx, candidates, latencies, fn)
+}
+
+// Calls fn with the candidates in order until it succeeds.
+func (r *Router) attempt(ctx context.Context, candidates []string, latencies *LatencyTracker, fn func(ctx context.Context, value EntryValue) error) error {
+	var errs []error
+	for i, node := range candidates {
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, err)
+			break
+		}
+		if i > 0 {
+			atomic.AddUint64(&r.fallbacks, 1)
+		}
+
+		value, exists := r.m.Value(node)
+		if !exists {
+			continue
+		}
+		if r.health != nil {
+			if err := r.health.Check(ctx, value); err != nil {
+				atomic.AddUint64(&r.unhealthy, 1)
+				errs = append(errs, fmt.Errorf("Node '%s' is unhealthy: %w", node, err))
+				continue
+			}
+		}
+
+		start := time.Now()
+		err := fn(ctx, value)
+		if latencies != nil {
+			latencies.Observe(node, time.Since(start))
+		}
+		if err == nil {
+			return nil
+		}
+		errs = append(errs, err)
+	}
+
+	atomic.AddUint64(&r.failures, 1)
+	if len(errs) == 0 {
+		// Every candidate left the hash since the lookup.
+		return fmt.Errorf("%w: all %d owners were removed", ErrNotEnoughNodes, len(candidates))
+	}
+	return errors.Join(errs...)
+}
+
+// Returns a snapshot of the router's counters.
+func (r *Router) Stats() RouterStats {
+	return RouterStats{
+		Calls:     atomic.LoadUint64(&r.calls),
+		Failures:  atomic.LoadUint64(&r.failures),
+		Fallbacks: atomic.LoadUint64(&r.fallbacks),
+		Unhealthy: atomic.LoadUint64(&r.unhealthy),
+	}
+}
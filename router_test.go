@@ -0,0 +1,135 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRouterDo(t *testing.T) {
+	hash := New(1, intHash)
+	hash.AddString("10", "20", "30")
+	hash.SetLatencyTracker(NewLatencyTracker(0))
+
+	health := HealthCheckerFunc(func(_ context.Context, value EntryValue) error {
+		if value.HashRingId() == "10" {
+			return errors.New("down")
+		}
+		return nil
+	})
+	r := NewRouter(hash, health, 0)
+
+	var served string
+	err := r.Do(context.Background(), "5", func(_ context.Context, value EntryValue) error {
+		served = value.HashRingId()
+		return nil
+	})
+	if err != nil || served != "20" {
+		t.Errorf("Expected call to be served by 20, but got %s and error: %v", served, err)
+	}
+	if _, observed := hash.latencies.Latency("20"); !observed {
+		t.Errorf("Expected latency of 20 to be observed")
+	}
+
+	err = r.Do(context.Background(), "5", func(context.Context, EntryValue) error {
+		return errors.New("failed")
+	})
+	if err == nil {
+		t.Errorf("Expected error, but it wasn't returned")
+	}
+
+	stats := r.Stats()
+	expected := RouterStats{Calls: 2, Failures: 1, Fallbacks: 3, Unhealthy: 2}
+	if stats != expected {
+		t.Errorf("Expected stats %+v, but got: %+v", expected, stats)
+	}
+}
+
+func TestRouterMaxAttempts(t *testing.T) {
+	hash := New(1, intHash)
+	hash.AddString("10", "20", "30")
+
+	attempts := 0
+	NewRouter(hash, nil, 2).Do(context.Background(), "5", func(context.Context, EntryValue) error {
+		attempts++
+		return errors.New("failed")
+	})
+	if attempts != 2 {
+		t.Errorf("Expected 2 attempts, but got: %d", attempts)
+	}
+}
+
+func TestGetHealthy(t *testing.T) {
+	ctx := context.Background()
+	hash := New(1, intHash)
+	if _, _, err := hash.GetHealthy(ctx, "15"); err != ErrEmptyRing {
+		t.Errorf("Expected ErrEmptyRing, but got: %v", err)
+	}
+
+	hash.AddString("10", "20", "30")
+	if node, skipped, err := hash.GetHealthy(ctx, "15"); node != "20" || skipped != 0 || err != nil {
+		t.Errorf("Expected 20 without a health checker, but got: %s, %d, %v", node, skipped, err)
+	}
+
+	unhealthy := map[string]bool{"20": true, "30": true}
+	hash.SetHealthChecker(HealthCheckerFunc(func(ctx context.Context, value EntryValue) error {
+		if unhealthy[value.HashRingId()] {
+			return errors.New("unhealthy")
+		}
+		return nil
+	}))
+	if node, skipped, err := hash.GetHealthy(ctx, "15"); node != "10" || skipped != 2 || err != nil {
+		t.Errorf("Expected 10 after skipping 2, but got: %s, %d, %v", node, skipped, err)
+	}
+
+	unhealthy["10"] = true
+	node, skipped, err := hash.GetHealthy(ctx, "15")
+	if node != "" || skipped != 3 || !errors.Is(err, ErrNotEnoughNodes) {
+		t.Errorf("Expected ErrNotEnoughNodes after skipping 3, but got: %s, %d, %v", node, skipped, err)
+	}
+	if err != nil && !strings.Contains(err.Error(), "Node '30' is unhealthy") {
+		t.Errorf("Expected health check errors to be included, but got: %v", err)
+	}
+
+	cancelled, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, _, err := hash.GetHealthy(cancelled, "15"); err != context.Canceled {
+		t.Errorf("Expected context.Canceled, but got: %v", err)
+	}
+}
+
+func TestRouterDoRemovedOwners(t *testing.T) {
+	hash := New(1, intHash)
+	hash.AddString("10")
+	r := NewRouter(hash, nil, 0)
+
+	// The owners were looked up, but have all left the hash since.
+	called := false
+	err := r.attempt(context.Background(), []string{"20", "30"}, nil, func(context.Context, EntryValue) error {
+		called = true
+		return nil
+	})
+	if !errors.Is(err, ErrNotEnoughNodes) || called {
+		t.Errorf("Expected ErrNotEnoughNodes without calling fn, but got: %v", err)
+	}
+	if stats := r.Stats(); stats.Failures != 1 {
+		t.Errorf("Expected the call to count as a failure, but got: %+v", stats)
+	}
+}
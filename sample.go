@@ -0,0 +1,96 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// Generates n keys, of the form prefix followed by a number, that map to
+// the provided item. This is useful to write tests, to reproduce issues
+// on a specific item, or to verify that other implementations map keys
+// identically.
+func (m *Map) SampleKeys(key string, n int, prefix string) ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if _, exists := m.entries[key]; !exists {
+		return nil, fmt.Errorf("No node with name '%s' found", key)
+	}
+
+	share := rangesFraction(m.rangesOf(key))
+	if share == 0 {
+		return nil, fmt.Errorf("Node '%s' does not own any part of the ring", key)
+	}
+
+	// Give up once we've tried far more keys than needed on average.
+	limit := int(float64(n)/share)*100 + 1000
+
+	out := make([]string, 0, n)
+	for i := 0; len(out) < n && i < limit; i++ {
+		sample := prefix + strconv.Itoa(i)
+		if m.get(sample) == key {
+			out = append(out, sample)
+		}
+	}
+	if len(out) < n {
+		return out, fmt.Errorf("Only found %d of %d keys for node '%s'", len(out), n, key)
+	}
+	return out, nil
+}
+
+// Returns the ranges of the ring owned by the provided item, in ring
+// order. The caller must hold at least a read lock.
+func (m *Map) rangesOf(key string) []HashRange {
+	positions := make([]int, 0, len(m.keys))
+	for i, k := range m.keys {
+		if i == 0 || m.keys[i-1] != k {
+			positions = append(positions, k)
+		}
+	}
+
+	var out []HashRange
+	last := len(positions) - 1
+	for i, k := range positions {
+		if m.names[m.hashMap[k]] != key {
+			continue
+		}
+
+		if m.direction == Counterclockwise {
+			if i < last {
+				out = append(out, HashRange{uint32(k), uint32(positions[i+1] - 1)})
+				continue
+			}
+			out = append(out, HashRange{uint32(k), 1<<32 - 1})
+			if positions[0] > 0 {
+				out = append(out, HashRange{0, uint32(positions[0] - 1)})
+			}
+			continue
+		}
+
+		if i > 0 {
+			out = append(out, HashRange{uint32(positions[i-1] + 1), uint32(k)})
+			continue
+		}
+		if positions[last] < 1<<32-1 {
+			out = append(out, HashRange{uint32(positions[last] + 1), 1<<32 - 1})
+		}
+		out = append(out, HashRange{0, uint32(k)})
+	}
+	return out
+}
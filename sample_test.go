@@ -0,0 +1,56 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"testing"
+)
+
+func TestSampleKeys(t *testing.T) {
+	hash := New(50, nil)
+	hash.AddString("A", "B", "C")
+
+	keys, err := hash.SampleKeys("B", 20, "user-")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if len(keys) != 20 {
+		t.Errorf("Expected 20 keys, but got: %d", len(keys))
+	}
+	for _, key := range keys {
+		if res := hash.Get(key); res != "B" {
+			t.Errorf("Sample key %s should map to B, but instead got: %s", key, res)
+		}
+	}
+
+	if _, err := hash.SampleKeys("D", 1, ""); err == nil {
+		t.Errorf("Expected error, but it wasn't returned")
+	}
+}
+
+func TestRangesOf(t *testing.T) {
+	hash := New(1, intHash)
+	hash.AddString("10", "20")
+
+	ranges := hash.rangesOf("10")
+	if len(ranges) != 2 || ranges[0] != (HashRange{21, 1<<32 - 1}) || ranges[1] != (HashRange{0, 10}) {
+		t.Errorf("Unexpected ranges for 10: %v", ranges)
+	}
+	if ranges = hash.rangesOf("20"); len(ranges) != 1 || ranges[0] != (HashRange{11, 20}) {
+		t.Errorf("Unexpected ranges for 20: %v", ranges)
+	}
+}
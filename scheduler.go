@@ -0,0 +1,160 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Applies queued membership changes to a hash at a bounded rate, so that
+// a burst of changes (e.g. when reconciling against service discovery)
+// does not move a large part of the keyspace at once.
+//
+// The rate is expressed as the fraction of the keyspace (between 0 and 1)
+// that is allowed to change owner per minute. Removals of dead nodes are
+// always applied before any other queued change.
+type Scheduler struct {
+	m       *Map
+	perMin  float64
+	mu      sync.Mutex
+	dead    []Change
+	pending []Change
+	budget  float64
+	last    time.Time
+}
+
+// Creates a scheduler for the provided hash that moves at most
+// maxMovementPerMinute of the keyspace per minute. A limit of zero or
+// less disables rate limiting, so every queued change is applied at the
+// next step.
+func NewScheduler(m *Map, maxMovementPerMinute float64) *Scheduler {
+	return &Scheduler{
+		m:      m,
+		perMin: maxMovementPerMinute,
+		budget: maxMovementPerMinute,
+	}
+}
+
+// Queues a change to be applied once there is enough budget.
+func (s *Scheduler) Enqueue(changes ...Change) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pending = append(s.pending, changes...)
+}
+
+// Queues the removal of a node that is known to be dead. These are
+// applied before any change queued through Enqueue.
+func (s *Scheduler) EnqueueDead(keys ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, key := range keys {
+		s.dead = append(s.dead, RemoveChange(key))
+	}
+}
+
+// Returns the number of changes that have not been applied yet.
+func (s *Scheduler) Pending() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.dead) + len(s.pending)
+}
+
+// Applies as many queued changes as the budget allows at the given time,
+// and returns the changes that were applied. A change that cannot be
+// applied is dropped from the queue and its error is returned.
+//
+// A single change that moves more than the budget for a full minute is
+// applied once the budget is fully replenished, so it cannot block the
+// queue forever.
+func (s *Scheduler) Step(now time.Time) ([]Change, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.last.IsZero() {
+		s.budget += s.perMin * now.Sub(s.last).Minutes()
+		if s.budget > s.perMin {
+			s.budget = s.perMin
+		}
+	}
+	s.last = now
+
+	var applied []Change
+	for {
+		queue := &s.dead
+		if len(*queue) == 0 {
+			queue = &s.pending
+		}
+		if len(*queue) == 0 {
+			return applied, nil
+		}
+
+		c := (*queue)[0]
+		if s.perMin <= 0 {
+			err := s.m.Apply(c)
+			*queue = (*queue)[1:]
+			if err != nil {
+				return applied, err
+			}
+			applied = append(applied, c)
+			continue
+		}
+
+		cost, err := s.m.EstimateMovement(c)
+		if err == nil {
+			if cost > s.budget && s.budget < s.perMin {
+				return applied, nil
+			}
+			err = s.m.Apply(c)
+		}
+		*queue = (*queue)[1:]
+		if err != nil {
+			return applied, err
+		}
+
+		s.budget -= cost
+		applied = append(applied, c)
+	}
+}
+
+// Calls Step every interval until the context is cancelled. Errors
+// returned by Step are passed to onError if it is not nil.
+func (s *Scheduler) Run(ctx context.Context, interval time.Duration, onError func(error)) error {
+	return runEvery(ctx, interval, func() error {
+		_, err := s.Step(time.Now())
+		return err
+	}, onError)
+}
+
+// Calls step every interval until ctx is done, and returns the error of
+// ctx. Errors returned by step are passed to onError if it is not nil.
+func runEvery(ctx context.Context, interval time.Duration, step func() error, onError func(error)) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if err := step(); err != nil && onError != nil {
+				onError(err)
+			}
+		}
+	}
+}
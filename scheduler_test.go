@@ -0,0 +1,75 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSchedulerRateLimit(t *testing.T) {
+	hash := New(1, intHash)
+	hash.AddString("0", "1073741824", "2147483648", "3221225472")
+
+	s := NewScheduler(hash, 0.25)
+	s.Enqueue(RemoveChange("1073741824"), RemoveChange("3221225472"))
+	s.EnqueueDead("0")
+
+	now := time.Now()
+	applied, err := s.Step(now)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if len(applied) != 1 || applied[0].Key != "0" {
+		t.Fatalf("Expected dead node to be removed first, but got: %v", applied)
+	}
+
+	if applied, _ = s.Step(now.Add(30 * time.Second)); len(applied) != 0 {
+		t.Errorf("Expected budget to be exhausted, but applied: %v", applied)
+	}
+	if applied, _ = s.Step(now.Add(time.Minute)); len(applied) != 1 {
+		t.Errorf("Expected one change to be applied, but applied: %v", applied)
+	}
+	if s.Pending() != 1 {
+		t.Errorf("Expected 1 pending change, but got: %d", s.Pending())
+	}
+}
+
+func TestSchedulerDropsInvalidChange(t *testing.T) {
+	hash := New(1, intHash)
+	s := NewScheduler(hash, 1)
+	s.Enqueue(RemoveChange("10"), AddChange(&StringValue{"10"}, 1))
+
+	if _, err := s.Step(time.Now()); err == nil {
+		t.Errorf("Expected error, but it wasn't returned")
+	}
+	if applied, _ := s.Step(time.Now()); len(applied) != 1 {
+		t.Errorf("Expected remaining change to be applied, but applied: %v", applied)
+	}
+}
+
+func TestSchedulerUnlimited(t *testing.T) {
+	for _, limit := range []float64{0, -1} {
+		hash := New(1, intHash)
+		s := NewScheduler(hash, limit)
+		s.Enqueue(AddChange(&StringValue{"10"}, 1), AddChange(&StringValue{"20"}, 1), AddChange(&StringValue{"30"}, 1))
+
+		if applied, err := s.Step(time.Now()); err != nil || len(applied) != 3 || s.Pending() != 0 {
+			t.Errorf("Expected limit %v to apply every change, but applied: %v, %v", limit, applied, err)
+		}
+	}
+}
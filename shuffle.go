@@ -0,0 +1,56 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"strconv"
+)
+
+// Deterministically selects shardSize distinct items for a tenant
+// (shuffle sharding). Members are picked by hashing salted variants of
+// the tenant name, so two tenants rarely end up with the same shard and
+// a misbehaving tenant only impacts its own small set of backends.
+func (m *Map) ShuffleShard(tenant string, shardSize int) []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	out := []string{}
+	if m.isEmpty() || shardSize < 1 {
+		return out
+	}
+	if shardSize > len(m.entries) {
+		shardSize = len(m.entries)
+	}
+
+	// Salted lookups keep colliding once most items are picked, so
+	// fall back to walking the ring after a bounded number of attempts.
+	for i := 0; len(out) < shardSize && i < shardSize*4; i++ {
+		owner := m.get(tenant + "#" + strconv.Itoa(i))
+		if owner != "" && AcceptUnique(out, owner) {
+			out = append(out, owner)
+		}
+	}
+	if len(out) < shardSize {
+		m.walk(m.hashKey(tenant), func(_ int, owner string) bool {
+			if m.available(owner) && AcceptUnique(out, owner) {
+				out = append(out, owner)
+			}
+			return len(out) < shardSize
+		})
+	}
+	return out
+}
@@ -0,0 +1,49 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestShuffleShard(t *testing.T) {
+	hash := New(50, nil)
+	for i := 0; i < 20; i++ {
+		hash.AddString(fmt.Sprintf("node-%d", i))
+	}
+
+	shard := hash.ShuffleShard("tenant-a", 4)
+	if len(shard) != 4 {
+		t.Fatalf("Expected shard of 4 items, but got: %v", shard)
+	}
+	for i, v := range shard {
+		if !AcceptUnique(shard[:i], v) {
+			t.Errorf("Expected distinct items, but got: %v", shard)
+		}
+	}
+	if res := hash.ShuffleShard("tenant-a", 4); !reflect.DeepEqual(res, shard) {
+		t.Errorf("Expected shards to be deterministic, but got %v and %v", shard, res)
+	}
+	if res := hash.ShuffleShard("tenant-b", 4); reflect.DeepEqual(res, shard) {
+		t.Errorf("Expected different tenants to get different shards, but both got: %v", res)
+	}
+	if res := hash.ShuffleShard("tenant-a", 30); len(res) != 20 {
+		t.Errorf("Expected shard size to be capped at ring size, but got: %d", len(res))
+	}
+}
@@ -0,0 +1,92 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"fmt"
+	"sync"
+)
+
+type flightKey struct {
+	key  string
+	node string
+}
+
+type flight struct {
+	wg    sync.WaitGroup
+	value interface{}
+	err   error
+	dups  int
+}
+
+// Coalesces concurrent loads of the same key from the same item, so only
+// one of them reaches the backend. This protects backends against a
+// thundering herd, e.g. after a topology change caused many cache misses
+// at once. Loads for the same key routed to different items (before and
+// after a topology change) are not coalesced.
+type Coalescer struct {
+	m       *Map
+	mu      sync.Mutex
+	flights map[flightKey]*flight
+}
+
+// Creates a coalescer that routes keys using the provided hash.
+func NewCoalescer(m *Map) *Coalescer {
+	return &Coalescer{m: m, flights: make(map[flightKey]*flight)}
+}
+
+// Resolves the owner of key and calls fn with it, unless a call for the
+// same key and owner is already in flight, in which case its result is
+// awaited and returned instead. Shared reports whether the result was
+// handed to more than one caller.
+func (c *Coalescer) Do(key string, fn func(node string) (interface{}, error)) (value interface{}, shared bool, err error) {
+	node := c.m.Get(key)
+	if node == "" {
+		return nil, false, ErrEmptyRing
+	}
+	fk := flightKey{key, node}
+
+	c.mu.Lock()
+	if f, exists := c.flights[fk]; exists {
+		f.dups++
+		c.mu.Unlock()
+		f.wg.Wait()
+		return f.value, true, f.err
+	}
+	f := &flight{}
+	f.wg.Add(1)
+	c.flights[fk] = f
+	c.mu.Unlock()
+
+	// Clean up even if fn panics, so waiters and later calls for the key
+	// don't block forever. The panic itself propagates to this caller.
+	returned := false
+	defer func() {
+		if !returned {
+			f.value, f.err = nil, fmt.Errorf("Load of '%s' from '%s' panicked", key, node)
+		}
+		c.mu.Lock()
+		delete(c.flights, fk)
+		shared = f.dups > 0
+		c.mu.Unlock()
+		f.wg.Done()
+	}()
+
+	f.value, f.err = fn(node)
+	returned = true
+	return f.value, false, f.err // Shared is set once the flight is removed.
+}
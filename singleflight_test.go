@@ -0,0 +1,126 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestCoalescer(t *testing.T) {
+	hash := New(1, intHash)
+	hash.AddString("10")
+	c := NewCoalescer(hash)
+
+	var calls int32
+	release := make(chan struct{})
+	started := make(chan struct{})
+
+	var wg sync.WaitGroup
+	results := make([]interface{}, 5)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		results[0], _, _ = c.Do("5", func(node string) (interface{}, error) {
+			atomic.AddInt32(&calls, 1)
+			close(started)
+			<-release
+			return node, nil
+		})
+	}()
+	<-started
+
+	for i := 1; i < len(results); i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], _, _ = c.Do("5", func(node string) (interface{}, error) {
+				atomic.AddInt32(&calls, 1)
+				return node, nil
+			})
+		}(i)
+	}
+
+	// Wait for the other calls to join the flight before releasing it.
+	for {
+		c.mu.Lock()
+		dups := c.flights[flightKey{"5", "10"}].dups
+		c.mu.Unlock()
+		if dups == len(results)-1 {
+			break
+		}
+		runtime.Gosched()
+	}
+	close(release)
+	wg.Wait()
+
+	if calls != 1 {
+		t.Errorf("Expected a single call, but got: %d", calls)
+	}
+	for i, res := range results {
+		if res != "10" {
+			t.Errorf("Expected result %d to be 10, but got: %v", i, res)
+		}
+	}
+}
+
+func TestCoalescerPanic(t *testing.T) {
+	hash := New(1, intHash)
+	hash.AddString("10")
+	c := NewCoalescer(hash)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	panicked := make(chan interface{})
+	go func() {
+		defer func() { panicked <- recover() }()
+		c.Do("5", func(node string) (interface{}, error) {
+			close(started)
+			<-release
+			panic("backend")
+		})
+	}()
+	<-started
+
+	waiter := make(chan error)
+	go func() {
+		_, _, err := c.Do("5", func(string) (interface{}, error) { return nil, nil })
+		waiter <- err
+	}()
+	for {
+		c.mu.Lock()
+		dups := c.flights[flightKey{"5", "10"}].dups
+		c.mu.Unlock()
+		if dups == 1 {
+			break
+		}
+		runtime.Gosched()
+	}
+	close(release)
+
+	if r := <-panicked; r != "backend" {
+		t.Errorf("Expected the panic to propagate to the caller, but got: %v", r)
+	}
+	if err := <-waiter; err == nil {
+		t.Errorf("Expected the waiter to get an error")
+	}
+	if res, _, err := c.Do("5", func(node string) (interface{}, error) { return node, nil }); err != nil || res != "10" {
+		t.Errorf("Expected later calls to run, but got: %v, %v", res, err)
+	}
+}
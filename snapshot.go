@@ -0,0 +1,266 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	snapshotMagic   = "GoConsistentHash snapshot"
+	snapshotVersion = 2
+)
+
+// The persisted state of a single item.
+type SnapshotNode struct {
+	ID           string   `json:"id"`
+	Weight       int      `json:"weight"`
+	Tokens       []uint32 `json:"tokens,omitempty"`
+	VirtualNodes []uint32 `json:"virtual_nodes,omitempty"`
+	// Virtual nodes derived from the weight that were removed.
+	RemovedVirtualNodes []uint32               `json:"removed_virtual_nodes,omitempty"`
+	Labels              map[string]string      `json:"labels,omitempty"`
+	Metadata            map[string]interface{} `json:"metadata,omitempty"`
+	Capacity            float64                `json:"capacity,omitempty"`
+}
+
+// The persisted state of a hash. Only the items are persisted; the hash
+// function and options are part of the configuration of the hash the
+// snapshot is restored into.
+type Snapshot struct {
+	Epoch uint64 `json:"epoch"`
+	// The compatibility mode of the hash, see CompatMode. Empty if the
+	// hash has none, or if the snapshot predates version 2.
+	Compat string         `json:"compat,omitempty"`
+	Nodes  []SnapshotNode `json:"nodes"`
+}
+
+// Returns a snapshot of the items of the hash, sorted by name.
+func (m *Map) Snapshot() *Snapshot {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	s := &Snapshot{Epoch: m.epoch, Nodes: make([]SnapshotNode, 0, len(m.entries))}
+	if m.compat != CompatNone {
+		s.Compat = m.compat.String()
+	}
+	for key, e := range m.entries {
+		s.Nodes = append(s.Nodes, snapshotNode(key, e))
+	}
+	sort.Slice(s.Nodes, func(i, j int) bool { return s.Nodes[i].ID < s.Nodes[j].ID })
+	return s
+}
+
+func snapshotNode(key string, e *entry) SnapshotNode {
+	node := SnapshotNode{
+		ID:       key,
+		Weight:   e.weight,
+		Labels:   copyLabels(e.labels),
+		Metadata: copyMetadata(e.meta),
+		Capacity: e.capacity,
+	}
+	if e.explicit {
+		node.Tokens = toTokens(e.positions)
+	} else {
+		node.VirtualNodes = toTokens(e.manual)
+		node.RemovedVirtualNodes = toTokens(e.removed)
+	}
+	return node
+}
+
+// Returns the changes that add the node to a hash.
+func (n SnapshotNode) changes() []Change {
+	value := &Node{ID: n.ID, Tags: copyLabels(n.Labels), Meta: copyMetadata(n.Metadata)}
+	var out []Change
+	if n.Tokens != nil {
+		out = append(out, AddTokensChange(value, n.Tokens))
+	} else {
+		out = append(out, AddChange(value, n.Weight))
+		for _, pos := range n.RemovedVirtualNodes {
+			out = append(out, Change{Op: ChangeRemoveVirtualNode, Key: n.ID, Tokens: []uint32{pos}})
+		}
+		for _, pos := range n.VirtualNodes {
+			out = append(out, Change{Op: ChangeAddVirtualNode, Key: n.ID, Tokens: []uint32{pos}})
+		}
+	}
+	if n.Capacity != 0 {
+		out = append(out, CapacityChange(n.ID, n.Capacity))
+	}
+	return out
+}
+
+func toTokens(positions []int) []uint32 {
+	if len(positions) == 0 {
+		return nil
+	}
+	out := make([]uint32, len(positions))
+	for i, pos := range positions {
+		out[i] = uint32(pos)
+	}
+	return out
+}
+
+// Replaces all items of the hash with the items of the snapshot, as a
+// single atomic change. Restored items are *Node values, and the epoch
+// of the hash ends up past the epoch of the snapshot. Fails if the
+// snapshot was taken of a hash in a different compatibility mode, as
+// keys would map differently.
+func (m *Map) Restore(s *Snapshot) error {
+	if s.Compat != "" && s.Compat != m.compat.String() {
+		return fmt.Errorf("Snapshot was taken in compatibility mode %s, but the hash uses %s", s.Compat, m.compat)
+	}
+
+	// The items are replaced under a single write lock, so concurrent
+	// changes cannot end up mixed with the snapshot.
+	_, err := m.mutatePlanned(func() ([]Change, error) {
+		txn := make(Txn, 0, len(m.entries)+len(s.Nodes))
+		for key := range m.entries {
+			txn = append(txn, RemoveChange(key))
+		}
+		for _, n := range s.Nodes {
+			txn = append(txn, n.changes()...)
+		}
+
+		next := m.clone()
+		for _, c := range txn {
+			if err := next.apply(c); err != nil {
+				return nil, err
+			}
+		}
+		m.swap(next)
+		// Continue after the epoch of the snapshot, so snapshots stored
+		// after a restart sort after the one restored from.
+		m.epoch = max(m.epoch, s.Epoch)
+		return txn, nil
+	})
+	return err
+}
+
+// Writes the snapshot, preceded by a format version header.
+func (s *Snapshot) WriteTo(w io.Writer) (int64, error) {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return 0, err
+	}
+	n, err := fmt.Fprintf(w, "%s v%d\n%s\n", snapshotMagic, snapshotVersion, data)
+	return int64(n), err
+}
+
+// Reads a snapshot written by Snapshot.WriteTo.
+func ReadSnapshot(r io.Reader) (*Snapshot, error) {
+	br := bufio.NewReader(r)
+	header, err := br.ReadString('\n')
+	if err != nil {
+		return nil, fmt.Errorf("Unable to read snapshot header: %w", err)
+	}
+
+	var version int
+	if _, err := fmt.Sscanf(strings.TrimPrefix(header, snapshotMagic), " v%d\n", &version); err != nil || !strings.HasPrefix(header, snapshotMagic) {
+		return nil, fmt.Errorf("Not a snapshot, invalid header: %q", header)
+	}
+	decode, exists := snapshotDecoders[version]
+	if !exists {
+		return nil, fmt.Errorf("Unsupported snapshot version: %d", version)
+	}
+
+	s, err := decode(br)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to decode snapshot: %w", err)
+	}
+	return s, nil
+}
+
+// Decodes the body of a snapshot per format version. Decoders of older
+// versions migrate the snapshot to the current layout, so snapshots
+// written by older releases remain loadable.
+var snapshotDecoders = map[int]func(io.Reader) (*Snapshot, error){
+	1: decodeSnapshotV1,
+	2: decodeSnapshotV2,
+}
+
+// Version 1 did not record the compatibility mode, which is left empty.
+func decodeSnapshotV1(r io.Reader) (*Snapshot, error) {
+	var v1 struct {
+		Epoch uint64         `json:"epoch"`
+		Nodes []SnapshotNode `json:"nodes"`
+	}
+	if err := json.NewDecoder(r).Decode(&v1); err != nil {
+		return nil, err
+	}
+	return &Snapshot{Epoch: v1.Epoch, Nodes: v1.Nodes}, nil
+}
+
+func decodeSnapshotV2(r io.Reader) (*Snapshot, error) {
+	s := &Snapshot{}
+	if err := json.NewDecoder(r).Decode(s); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Saves a snapshot of the hash to path. The snapshot is written to a
+// temporary file first, which is then renamed, so path always holds
+// either the previous or the new snapshot.
+func (m *Map) SaveToFile(path string) error {
+	return writeFileAtomic(path, func(w io.Writer) error {
+		_, err := m.Snapshot().WriteTo(w)
+		return err
+	})
+}
+
+// Replaces the items of the hash with a snapshot saved by SaveToFile.
+func (m *Map) LoadFromFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	s, err := ReadSnapshot(f)
+	if err != nil {
+		return err
+	}
+	return m.Restore(s)
+}
+
+func writeFileAtomic(path string, write func(io.Writer) error) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := write(tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,208 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestSaveAndLoadFile(t *testing.T) {
+	hash := New(3, nil)
+	hash.AddString("A", "B")
+	hash.AddWithWeight(&Node{ID: "C", Tags: map[string]string{"zone": "a"}, Meta: map[string]interface{}{"port": "11211"}}, 5)
+	hash.AddWithTokens(&StringValue{"D"}, []uint32{1, 2, 3})
+	hash.AddVirtualNode("A", 42)
+
+	path := filepath.Join(t.TempDir(), "ring.snapshot")
+	if err := hash.SaveToFile(path); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(path)); len(entries) != 1 {
+		t.Errorf("Expected temporary files to be cleaned up, but got: %v", entries)
+	}
+
+	restored := New(3, nil)
+	restored.AddString("E")
+	if err := restored.LoadFromFile(path); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if _, exists := restored.Value("E"); exists {
+		t.Errorf("Expected existing items to be replaced")
+	}
+	for _, key := range []string{"1", "42", "132", "foo", "bar", "baz"} {
+		if hash.Get(key) != restored.Get(key) {
+			t.Errorf("Expected %s to map identically after restoring", key)
+		}
+	}
+	if labels := restored.Labels("C"); labels["zone"] != "a" {
+		t.Errorf("Expected labels to be restored, but got: %v", labels)
+	}
+	if port, _ := restored.MetadataValue("C", "port"); port != "11211" {
+		t.Errorf("Expected metadata to be restored, but got: %v", port)
+	}
+	if _, explicit := restored.Tokens("D"); !explicit {
+		t.Errorf("Expected explicit tokens to be restored")
+	}
+}
+
+func TestReadSnapshotErrors(t *testing.T) {
+	testCases := []string{
+		"",
+		"something else\n{}",
+		"GoConsistentHash snapshot v99\n{}",
+		"GoConsistentHash snapshot v1\n{",
+		"GoConsistentHash snapshot v2\n[]",
+	}
+	for _, data := range testCases {
+		if _, err := ReadSnapshot(strings.NewReader(data)); err == nil {
+			t.Errorf("Expected error for %q, but it wasn't returned", data)
+		}
+	}
+}
+
+func TestReadSnapshotV1(t *testing.T) {
+	data := `GoConsistentHash snapshot v1
+{"epoch":7,"nodes":[{"id":"A","weight":2,"virtual_nodes":[42]},{"id":"B","weight":1,"tokens":[10]}]}
+`
+	s, err := ReadSnapshot(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if s.Epoch != 7 || s.Compat != "" || len(s.Nodes) != 2 || s.Nodes[0].VirtualNodes[0] != 42 || s.Nodes[1].Tokens[0] != 10 {
+		t.Errorf("Unexpected snapshot: %+v", s)
+	}
+
+	hash := NewParity(1)
+	if err := hash.Restore(s); err != nil {
+		t.Errorf("Expected version 1 snapshot to restore into any hash, but got: %s", err)
+	}
+}
+
+func TestSnapshotCompat(t *testing.T) {
+	hash := NewParity(1)
+	hash.AddString("A")
+
+	var buf strings.Builder
+	hash.Snapshot().WriteTo(&buf)
+	if !strings.HasPrefix(buf.String(), "GoConsistentHash snapshot v2\n") || !strings.Contains(buf.String(), `"compat":"v1"`) {
+		t.Errorf("Unexpected snapshot: %s", buf.String())
+	}
+
+	s, err := ReadSnapshot(strings.NewReader(buf.String()))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if err := NewParity(1).Restore(s); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+	}
+	err = New(1, nil).Restore(s)
+	if err == nil || err.Error() != "Snapshot was taken in compatibility mode v1, but the hash uses none" {
+		t.Errorf("Expected error for mismatching compatibility mode, but got: %v", err)
+	}
+}
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	hash := New(3, nil)
+	hash.AddString("A", "B", "C")
+	hash.AddWithTokens(&StringValue{"D"}, []uint32{1 << 30, 3 << 30})
+	derived := hash.VirtualNodesOf("A")
+	hash.RemoveVirtualNode("A", derived[1])
+	hash.AddVirtualNode("B", 12345)
+	hash.SetCapacity("C", 10)
+	hash.SetLabels("C", map[string]string{"zone": "a"})
+
+	// Removed virtual nodes stay removed when the weight changes.
+	hash.Apply(WeightChange("A", 4))
+	if tokens := hash.VirtualNodesOf("A"); len(tokens) != 3 {
+		t.Errorf("Expected A to have 3 virtual nodes, but got: %v", tokens)
+	}
+
+	var buf bytes.Buffer
+	hash.Snapshot().WriteTo(&buf)
+	fromJSON, err := ReadSnapshot(&buf)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	data, _ := hash.Snapshot().MarshalBinary()
+	fromBinary := &Snapshot{}
+	if err := fromBinary.UnmarshalBinary(data); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	for name, s := range map[string]*Snapshot{"JSON": fromJSON, "binary": fromBinary} {
+		restored := New(3, nil)
+		if err := restored.Restore(s); err != nil {
+			t.Fatalf("Unexpected error restoring %s snapshot: %s", name, err)
+		}
+		for _, node := range []string{"A", "B", "C", "D"} {
+			if got, expected := restored.VirtualNodesOf(node), hash.VirtualNodesOf(node); !reflect.DeepEqual(got, expected) {
+				t.Errorf("Expected virtual nodes %v of %s after %s round trip, but got: %v", expected, node, name, got)
+			}
+		}
+		if restored.Capacity("C") != 10 || restored.Labels("C")["zone"] != "a" {
+			t.Errorf("Expected capacity and labels of C to survive %s round trip", name)
+		}
+		for i := 0; i < 1000; i++ {
+			key := strconv.Itoa(i)
+			if got, expected := restored.Get(key), hash.Get(key); got != expected {
+				t.Errorf("Expected %s for %s after %s round trip, but got: %s", expected, key, name, got)
+			}
+		}
+	}
+}
+
+func TestRestoreConcurrentWriter(t *testing.T) {
+	source := New(3, nil)
+	source.AddString("A", "B", "C")
+	snapshot := source.Snapshot()
+
+	hash := New(3, nil)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 200; i++ {
+			hash.AddString("X")
+			hash.Del("X")
+		}
+	}()
+	for i := 0; i < 200; i++ {
+		if err := hash.Restore(snapshot); err != nil {
+			t.Fatalf("Expected restore not to conflict with concurrent changes, but got: %s", err)
+		}
+	}
+	<-done
+
+	if err := hash.Restore(snapshot); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	var nodes []string
+	for _, n := range hash.Snapshot().Nodes {
+		nodes = append(nodes, n.ID)
+	}
+	sort.Strings(nodes)
+	if !reflect.DeepEqual(nodes, []string{"A", "B", "C"}) {
+		t.Errorf("Expected exactly the items of the snapshot, but got: %v", nodes)
+	}
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// Routes shard keys to database connection pools. Every item of the hash
+// is a shard with a registered DSN; the pool of a shard is opened on
+// first use and closed once the shard is removed from the hash.
+type SQLShards struct {
+	driver  string
+	mu      sync.RWMutex
+	dsns    map[string]string
+	clients *ClientRegistry
+}
+
+// Creates a shard router for the items of the hash, that opens pools
+// using the provided database/sql driver. Errors returned when closing
+// pools are passed to onError, which may be nil.
+func NewSQLShards(m *Map, driverName string, onError func(node string, err error)) *SQLShards {
+	s := &SQLShards{driver: driverName, dsns: make(map[string]string)}
+	s.clients = NewClientRegistry(m, s.open, onError)
+	return s
+}
+
+func (s *SQLShards) open(value EntryValue) (io.Closer, error) {
+	s.mu.RLock()
+	dsn, exists := s.dsns[value.HashRingId()]
+	s.mu.RUnlock()
+
+	if !exists {
+		return nil, fmt.Errorf("No DSN registered for shard '%s'", value.HashRingId())
+	}
+	return sql.Open(s.driver, dsn)
+}
+
+// Registers the DSN of a shard. If the shard already has an open pool
+// for another DSN, that pool is closed.
+func (s *SQLShards) Register(node, dsn string) {
+	s.mu.Lock()
+	previous, exists := s.dsns[node]
+	s.dsns[node] = dsn
+	s.mu.Unlock()
+
+	if exists && previous != dsn {
+		s.clients.remove(node)
+	}
+}
+
+// Returns the pool of the shard that owns key.
+func (s *SQLShards) DB(key string) (*sql.DB, error) {
+	_, client, err := s.clients.ForKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return client.(*sql.DB), nil
+}
+
+// Returns the pool of a shard.
+func (s *SQLShards) Shard(node string) (*sql.DB, error) {
+	client, err := s.clients.Client(node)
+	if err != nil {
+		return nil, err
+	}
+	return client.(*sql.DB), nil
+}
+
+// Stops following changes of the hash and closes all pools.
+func (s *SQLShards) Close() error {
+	return s.clients.Close()
+}
@@ -0,0 +1,79 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+)
+
+type fakeSQLDriver struct{}
+
+func (fakeSQLDriver) Open(dsn string) (driver.Conn, error) {
+	return nil, errors.New("not implemented")
+}
+
+func init() {
+	sql.Register("consistenthash-fake", fakeSQLDriver{})
+}
+
+func isClosed(db *sql.DB) bool {
+	_, err := db.Conn(context.Background())
+	return err != nil && err.Error() == "sql: database is closed"
+}
+
+func TestSQLShards(t *testing.T) {
+	hash := New(1, intHash)
+	hash.AddString("10", "20")
+	shards := NewSQLShards(hash, "consistenthash-fake", nil)
+	defer shards.Close()
+
+	if _, err := shards.DB("15"); err == nil {
+		t.Errorf("Expected error for a shard without DSN")
+	}
+
+	shards.Register("10", "dsn-10")
+	shards.Register("20", "dsn-20")
+	db, err := shards.DB("15")
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if again, _ := shards.Shard("20"); again != db {
+		t.Errorf("Expected the pool of a shard to be reused")
+	}
+
+	shards.Register("20", "dsn-20-new")
+	if !isClosed(db) {
+		t.Errorf("Expected pool to be closed after its DSN changed")
+	}
+	db, _ = shards.DB("15")
+
+	hash.Del("20")
+	if !isClosed(db) {
+		t.Errorf("Expected pool to be closed after its shard was removed")
+	}
+	if _, err := shards.Shard("20"); err == nil {
+		t.Errorf("Expected error for a removed shard")
+	}
+
+	if _, err := NewSQLShards(New(1, nil), "consistenthash-fake", nil).DB("15"); err != ErrEmptyRing {
+		t.Errorf("Expected ErrEmptyRing, but got: %v", err)
+	}
+}
@@ -0,0 +1,153 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Counts observations in buckets with fixed upper bounds. It is safe for
+// concurrent use and doesn't allocate when observing.
+type histogram struct {
+	bounds []int64
+	counts []uint64 // One per bound, plus one for larger values.
+	count  uint64
+	sum    uint64
+}
+
+// Returns a histogram with n buckets whose bounds double, starting at
+// first.
+func newHistogram(first int64, n int) *histogram {
+	h := &histogram{bounds: make([]int64, n), counts: make([]uint64, n+1)}
+	for i := range h.bounds {
+		h.bounds[i] = first << i
+	}
+	return h
+}
+
+func (h *histogram) observe(v int64) {
+	i := 0
+	for i < len(h.bounds) && v > h.bounds[i] {
+		i++
+	}
+	atomic.AddUint64(&h.counts[i], 1)
+	atomic.AddUint64(&h.count, 1)
+	atomic.AddUint64(&h.sum, uint64(max(v, 0)))
+}
+
+func (h *histogram) observeSince(start time.Time) {
+	h.observe(int64(time.Since(start)))
+}
+
+// Returns the state of the histogram, with values divided by scale.
+func (h *histogram) snapshot(scale float64) Histogram {
+	out := Histogram{
+		Bounds: make([]float64, len(h.bounds)),
+		Counts: make([]uint64, len(h.counts)),
+		Count:  atomic.LoadUint64(&h.count),
+		Sum:    float64(atomic.LoadUint64(&h.sum)) / scale,
+	}
+	for i, b := range h.bounds {
+		out.Bounds[i] = float64(b) / scale
+	}
+	for i := range h.counts {
+		out.Counts[i] = atomic.LoadUint64(&h.counts[i])
+	}
+	return out
+}
+
+// The distribution of a measurement.
+type Histogram struct {
+	// The inclusive upper bounds of the buckets.
+	Bounds []float64
+	// The number of observations per bucket, not cumulative. The last
+	// bucket counts the observations larger than the last bound.
+	Counts []uint64
+	Count  uint64
+	Sum    float64
+}
+
+// The distributions of lookup latencies and walk lengths of a hash.
+type LookupStats struct {
+	Get  Histogram // Duration of Get, in seconds.
+	GetN Histogram // Duration of GetN, in seconds.
+	// The number of candidates considered per replica selection, i.e.
+	// GetN and its variants.
+	WalkLength Histogram
+}
+
+type lookupStats struct {
+	get, getN, walk *histogram
+}
+
+// Tracks the latency of Get and GetN, and the number of candidates that
+// replica selections consider, see Stats. Long walks are the result of
+// accept functions or constraints that reject many candidates.
+func WithLookupStats() Option {
+	return func(m *Map) {
+		m.stats = &lookupStats{
+			get:  newHistogram(int64(100*time.Nanosecond), 16),
+			getN: newHistogram(int64(100*time.Nanosecond), 16),
+			walk: newHistogram(1, 12),
+		}
+	}
+}
+
+func (s *lookupStats) observeWalk(n int) {
+	if s != nil {
+		s.walk.observe(int64(n))
+	}
+}
+
+// Returns the lookup statistics of the hash, or false if it was not
+// created with WithLookupStats.
+func (m *Map) Stats() (LookupStats, bool) {
+	if m.stats == nil {
+		return LookupStats{}, false
+	}
+	return LookupStats{
+		Get:        m.stats.get.snapshot(float64(time.Second)),
+		GetN:       m.stats.getN.snapshot(float64(time.Second)),
+		WalkLength: m.stats.walk.snapshot(1),
+	}, true
+}
+
+// Writes the statistics in the Prometheus text exposition format.
+func (s LookupStats) WritePrometheus(w io.Writer) error {
+	var sb strings.Builder
+	writePrometheusHistogram(&sb, "consistenthash_get_duration_seconds", "Duration of Get lookups.", s.Get)
+	writePrometheusHistogram(&sb, "consistenthash_getn_duration_seconds", "Duration of GetN lookups.", s.GetN)
+	writePrometheusHistogram(&sb, "consistenthash_walk_length", "Candidates considered per replica selection.", s.WalkLength)
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func writePrometheusHistogram(sb *strings.Builder, name, help string, h Histogram) {
+	fmt.Fprintf(sb, "# HELP %s %s\n# TYPE %s histogram\n", name, help, name)
+	cumulative := uint64(0)
+	for i, b := range h.Bounds {
+		cumulative += h.Counts[i]
+		fmt.Fprintf(sb, "%s_bucket{le=\"%s\"} %d\n", name, strconv.FormatFloat(b, 'g', -1, 64), cumulative)
+	}
+	fmt.Fprintf(sb, "%s_bucket{le=\"+Inf\"} %d\n", name, h.Count)
+	fmt.Fprintf(sb, "%s_sum %s\n%s_count %d\n", name, strconv.FormatFloat(h.Sum, 'g', -1, 64), name, h.Count)
+}
@@ -0,0 +1,68 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLookupStats(t *testing.T) {
+	if _, enabled := New(1, nil).Stats(); enabled {
+		t.Errorf("Expected stats to be disabled by default")
+	}
+
+	hash := New(1, intHash, WithLookupStats())
+	hash.AddString("10", "20", "30")
+	hash.Get("5")
+	hash.GetN("5", 1, nil)
+	hash.GetN("5", 2, AcceptUnique)
+	hash.GetNInto(nil, "5", 3, func(picked []string, found string) bool { return found == "30" })
+
+	stats, enabled := hash.Stats()
+	if !enabled || stats.Get.Count != 1 || stats.GetN.Count != 2 {
+		t.Errorf("Unexpected lookup counts: %+v", stats)
+	}
+	if stats.Get.Sum <= 0 || len(stats.Get.Counts) != len(stats.Get.Bounds)+1 {
+		t.Errorf("Unexpected latency histogram: %+v", stats.Get)
+	}
+
+	// Walks of 1, 2 and 3 candidates, the last one being cut off after
+	// visiting the whole ring.
+	walk := stats.WalkLength
+	if walk.Count != 3 || walk.Sum != 6 || walk.Counts[0] != 1 || walk.Counts[1] != 1 || walk.Counts[2] != 1 {
+		t.Errorf("Unexpected walk length histogram: %+v", walk)
+	}
+
+	var sb strings.Builder
+	if err := stats.WritePrometheus(&sb); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	for _, line := range []string{
+		"# TYPE consistenthash_get_duration_seconds histogram\n",
+		"consistenthash_get_duration_seconds_bucket{le=\"1e-07\"} ",
+		"consistenthash_walk_length_bucket{le=\"1\"} 1\n",
+		"consistenthash_walk_length_bucket{le=\"2\"} 2\n",
+		"consistenthash_walk_length_bucket{le=\"4\"} 3\n",
+		"consistenthash_walk_length_bucket{le=\"+Inf\"} 3\n",
+		"consistenthash_walk_length_sum 6\nconsistenthash_walk_length_count 3\n",
+	} {
+		if !strings.Contains(sb.String(), line) {
+			t.Errorf("Expected exposition to contain %q, but got:\n%s", line, sb.String())
+		}
+	}
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"sync"
+	"time"
+)
+
+type session struct {
+	owner   string
+	expires time.Time
+}
+
+// Pins keys to the owner they were first resolved to for a fixed time,
+// so topology changes only move a key once its session expires.
+type StickySessions struct {
+	m        *Map
+	ttl      time.Duration
+	mu       sync.Mutex
+	sessions map[string]session
+}
+
+// Creates sticky sessions that pin keys for ttl.
+func NewStickySessions(m *Map, ttl time.Duration) *StickySessions {
+	return &StickySessions{m: m, ttl: ttl, sessions: make(map[string]session)}
+}
+
+// Gets the owner of key, see GetAt.
+func (s *StickySessions) Get(key string) string {
+	return s.GetAt(key, time.Now())
+}
+
+// Gets the owner key was pinned to, or resolves and pins it if its
+// session expired at time now. Sessions whose owner was removed from the
+// hash, or is marked down, are resolved again right away.
+func (s *StickySessions) GetAt(key string, now time.Time) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if sess, exists := s.sessions[key]; exists && now.Before(sess.expires) && s.valid(sess.owner) {
+		return sess.owner
+	}
+
+	owner := s.m.Get(key)
+	if owner == "" {
+		delete(s.sessions, key)
+		return ""
+	}
+	s.sessions[key] = session{owner: owner, expires: now.Add(s.ttl)}
+	return owner
+}
+
+func (s *StickySessions) valid(owner string) bool {
+	s.m.mu.RLock()
+	defer s.m.mu.RUnlock()
+
+	_, exists := s.m.entries[owner]
+	return exists && s.m.available(owner)
+}
+
+// Ends the session of key, so it is resolved again on the next lookup.
+func (s *StickySessions) Forget(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions, key)
+}
+
+// Removes all sessions that expired at time now and returns how many
+// sessions remain.
+func (s *StickySessions) Sweep(now time.Time) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for key, sess := range s.sessions {
+		if !now.Before(sess.expires) {
+			delete(s.sessions, key)
+		}
+	}
+	return len(s.sessions)
+}
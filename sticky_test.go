@@ -0,0 +1,64 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStickySessions(t *testing.T) {
+	hash := New(1, intHash)
+	hash.AddString("10", "30")
+	s := NewStickySessions(hash, time.Minute)
+
+	now := time.Unix(1000, 0)
+	if node := s.GetAt("15", now); node != "30" {
+		t.Errorf("Expected 30, but got: %s", node)
+	}
+
+	hash.AddString("20")
+	if node := s.GetAt("15", now.Add(30*time.Second)); node != "30" {
+		t.Errorf("Expected session to stick to 30, but got: %s", node)
+	}
+	if node := s.GetAt("15", now.Add(time.Minute)); node != "20" {
+		t.Errorf("Expected expired session to be resolved again, but got: %s", node)
+	}
+
+	// Sessions of removed or down owners are resolved again.
+	s.GetAt("25", now)
+	hash.Del("30")
+	if node := s.GetAt("25", now); node != "10" {
+		t.Errorf("Expected session of removed owner to be resolved again, but got: %s", node)
+	}
+	hash.MarkDown("10")
+	if node := s.GetAt("25", now); node != "20" {
+		t.Errorf("Expected session of down owner to be resolved again, but got: %s", node)
+	}
+
+	s.Forget("25")
+	if remaining := s.Sweep(now.Add(time.Minute + 30*time.Second)); remaining != 1 {
+		t.Errorf("Expected one session to remain, but got: %d", remaining)
+	}
+	if remaining := s.Sweep(now.Add(2 * time.Minute)); remaining != 0 {
+		t.Errorf("Expected no sessions to remain, but got: %d", remaining)
+	}
+
+	if node := NewStickySessions(New(1, nil), time.Minute).Get("foo"); node != "" {
+		t.Errorf("Expected no owner for an empty ring, but got: %s", node)
+	}
+}
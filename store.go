@@ -0,0 +1,110 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Stores snapshots by the epoch they were taken at. Implementations
+// could be backed by a filesystem, an object store or a database.
+type SnapshotStore interface {
+	// Stores the snapshot, replacing any snapshot with the same epoch.
+	Put(s *Snapshot) error
+	// Returns the snapshot taken at the given epoch. Returns an error
+	// wrapping os.ErrNotExist if there is no such snapshot.
+	Get(epoch uint64) (*Snapshot, error)
+	// Returns the epochs of all stored snapshots in ascending order.
+	List() ([]uint64, error)
+}
+
+// Returns the stored snapshot with the highest epoch. Epochs start at 0
+// in every process, so a restarted process should restore this snapshot
+// before storing new ones; Restore moves the epoch of the hash past it.
+func LatestSnapshot(store SnapshotStore) (*Snapshot, error) {
+	epochs, err := store.List()
+	if err != nil {
+		return nil, err
+	}
+	if len(epochs) == 0 {
+		return nil, fmt.Errorf("No snapshots stored: %w", os.ErrNotExist)
+	}
+	return store.Get(epochs[len(epochs)-1])
+}
+
+const snapshotFilePrefix = "snapshot-"
+
+// A SnapshotStore that keeps every snapshot in a separate file in Dir.
+type FileSnapshotStore struct {
+	Dir string
+}
+
+// Returns a snapshot store that stores snapshots in dir, creating the
+// directory if it doesn't exist yet.
+func NewFileSnapshotStore(dir string) (*FileSnapshotStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return &FileSnapshotStore{Dir: dir}, nil
+}
+
+func (fs *FileSnapshotStore) path(epoch uint64) string {
+	return filepath.Join(fs.Dir, fmt.Sprintf("%s%020d", snapshotFilePrefix, epoch))
+}
+
+func (fs *FileSnapshotStore) Put(s *Snapshot) error {
+	return writeFileAtomic(fs.path(s.Epoch), func(w io.Writer) error {
+		_, err := s.WriteTo(w)
+		return err
+	})
+}
+
+func (fs *FileSnapshotStore) Get(epoch uint64) (*Snapshot, error) {
+	f, err := os.Open(fs.path(epoch))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadSnapshot(f)
+}
+
+func (fs *FileSnapshotStore) List() ([]uint64, error) {
+	entries, err := os.ReadDir(fs.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var epochs []uint64
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasPrefix(e.Name(), snapshotFilePrefix) {
+			continue
+		}
+		epoch, err := strconv.ParseUint(strings.TrimPrefix(e.Name(), snapshotFilePrefix), 10, 64)
+		if err != nil {
+			continue
+		}
+		epochs = append(epochs, epoch)
+	}
+	sort.Slice(epochs, func(i, j int) bool { return epochs[i] < epochs[j] })
+	return epochs, nil
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestFileSnapshotStore(t *testing.T) {
+	store, err := NewFileSnapshotStore(filepath.Join(t.TempDir(), "snapshots"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	if _, err := LatestSnapshot(store); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected os.ErrNotExist for an empty store, but got: %v", err)
+	}
+
+	hash := New(3, nil)
+	for _, key := range []string{"A", "B", "C"} {
+		hash.AddString(key)
+		if err := store.Put(hash.Snapshot()); err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+	}
+	os.WriteFile(filepath.Join(store.Dir, "unrelated"), nil, 0644)
+
+	epochs, err := store.List()
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if !reflect.DeepEqual(epochs, []uint64{1, 2, 3}) {
+		t.Errorf("Expected epochs [1 2 3], but got: %v", epochs)
+	}
+
+	s, err := store.Get(2)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if len(s.Nodes) != 2 {
+		t.Errorf("Expected snapshot of epoch 2 to hold 2 nodes, but got: %d", len(s.Nodes))
+	}
+
+	latest, err := LatestSnapshot(store)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if latest.Epoch != 3 || len(latest.Nodes) != 3 {
+		t.Errorf("Expected latest snapshot to be of epoch 3 with 3 nodes, but got: %+v", latest)
+	}
+
+	if _, err := store.Get(42); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected os.ErrNotExist for an unknown epoch, but got: %v", err)
+	}
+}
+
+func TestFileSnapshotStoreRestart(t *testing.T) {
+	store, err := NewFileSnapshotStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	hash := New(3, nil)
+	hash.AddString("A", "B", "C")
+	hash.Del("C")
+	store.Put(hash.Snapshot())
+
+	// A restarted process starts over at epoch 0.
+	restarted := New(3, nil)
+	latest, err := LatestSnapshot(store)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	var events []uint64
+	restarted.OnChange(func(e ChangeEvent) { events = append(events, e.Epoch) })
+	if err := restarted.Restore(latest); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if epoch := restarted.Epoch(); epoch <= latest.Epoch {
+		t.Errorf("Expected the epoch to continue after %d, but got: %d", latest.Epoch, epoch)
+	}
+
+	restarted.AddString("D")
+	store.Put(restarted.Snapshot())
+	if !reflect.DeepEqual(events, []uint64{latest.Epoch + 1, latest.Epoch + 2}) {
+		t.Errorf("Expected listeners to see epochs %d and %d, but got: %v", latest.Epoch+1, latest.Epoch+2, events)
+	}
+
+	latest, err = LatestSnapshot(store)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if len(latest.Nodes) != 3 {
+		t.Errorf("Expected the snapshot taken after the restart to be the latest, but got: %+v", latest)
+	}
+}
@@ -0,0 +1,171 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"math"
+	"sort"
+)
+
+// Picks distinct items in ring order, like Cassandra's SimpleStrategy.
+type SimpleStrategy struct{}
+
+func (SimpleStrategy) Place(ring PlacementView, dst []string, hash, n int, accept func([]string, string) bool) []string {
+	start := len(dst)
+	ring.Walk(hash, func(node string) bool {
+		if AcceptUnique(dst[start:], node) && accept(dst[start:], node) {
+			dst = append(dst, node)
+		}
+		return len(dst)-start < n
+	})
+	return dst
+}
+
+// Picks distinct items in ring order, preferring items in a zone that
+// has no replica yet, like racks in Cassandra's NetworkTopologyStrategy.
+// Only when there are fewer zones than replicas, more than one replica
+// is placed in a zone. Items without the label are each considered to
+// be in a zone of their own.
+type ZoneAwareStrategy struct {
+	Label string // The label that holds the zone, e.g. "zone".
+}
+
+func (s ZoneAwareStrategy) Place(ring PlacementView, dst []string, hash, n int, accept func([]string, string) bool) []string {
+	start := len(dst)
+	if n < 1 {
+		return dst
+	}
+
+	zones := make(map[string]bool)
+	seen := make(map[string]bool)
+	var skipped []string
+	total := ring.maxVisited()
+	ring.Walk(hash, func(node string) bool {
+		if seen[node] {
+			return true
+		}
+		seen[node] = true
+
+		zone, labeled := ring.Label(node, s.Label)
+		if labeled && zones[zone] {
+			skipped = append(skipped, node)
+		} else if accept(dst[start:], node) {
+			dst = append(dst, node)
+			if labeled {
+				zones[zone] = true
+			}
+		}
+		return len(dst)-start < n && len(seen) < total
+	})
+
+	for _, node := range skipped {
+		if len(dst)-start >= n {
+			break
+		}
+		if accept(dst[start:], node) {
+			dst = append(dst, node)
+		}
+	}
+	return dst
+}
+
+// Picks the number of distinct items per data center that Replicas
+// specifies, in ring order, like Cassandra's NetworkTopologyStrategy.
+// Items in data centers that are not listed are never picked, and no
+// more than n items are picked in total.
+type DCAwareStrategy struct {
+	Label    string           // The label that holds the data center, e.g. "dc".
+	Replicas ReplicaPlacement // The number of replicas per data center.
+}
+
+func (s DCAwareStrategy) Place(ring PlacementView, dst []string, hash, n int, accept func([]string, string) bool) []string {
+	start := len(dst)
+	placed := make(map[string]int, len(s.Replicas))
+	seen := make(map[string]bool)
+	ring.Walk(hash, func(node string) bool {
+		if seen[node] {
+			return true
+		}
+		seen[node] = true
+
+		dc, _ := ring.Label(node, s.Label)
+		if placed[dc] < s.Replicas[dc] && accept(dst[start:], node) {
+			dst = append(dst, node)
+			placed[dc]++
+		}
+		return len(dst)-start < n
+	})
+	return dst
+}
+
+// Picks distinct items like SimpleStrategy, but orders the replicas
+// after the first by weight rather than by ring position: heavier items
+// are more likely to come first, and thus take a proportional share of
+// the secondary-replica traffic as well. The first replica is always the
+// owner of the key, and the order is deterministic per key.
+type ProportionalStrategy struct{}
+
+func (ProportionalStrategy) Place(ring PlacementView, dst []string, hash, n int, accept func([]string, string) bool) []string {
+	first := ""
+	ring.Walk(hash, func(node string) bool {
+		first = node
+		return false
+	})
+	if first == "" {
+		return dst
+	}
+
+	// Weighted random sampling without replacement (Efraimidis-Spirakis),
+	// with the random numbers derived from the key and the item. The order
+	// doesn't depend on the ring, so there is no need to walk it.
+	type candidate struct {
+		node  string
+		score float64
+	}
+	var rest []candidate
+	ring.eachAvailable(func(node string) {
+		if node == first {
+			return
+		}
+		c := candidate{node, math.Inf(-1)}
+		if w := ring.Weight(node); w > 0 {
+			u := (float64(fmix32(FNV1a32([]byte(node))^uint32(hash))) + 1) / (ringSize + 1)
+			c.score = math.Log(u) / float64(w)
+		}
+		rest = append(rest, c)
+	})
+	sort.Slice(rest, func(i, j int) bool {
+		if rest[i].score != rest[j].score {
+			return rest[i].score > rest[j].score
+		}
+		return rest[i].node < rest[j].node
+	})
+
+	start := len(dst)
+	if n > 0 && accept(dst[start:], first) {
+		dst = append(dst, first)
+	}
+	for _, c := range rest {
+		if len(dst)-start >= n {
+			break
+		}
+		if accept(dst[start:], c.node) {
+			dst = append(dst, c.node)
+		}
+	}
+	return dst
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestPlacementStrategyPresets(t *testing.T) {
+	hash := New(2, intHash)
+	hash.AddWithWeight(&Node{ID: "10", Tags: map[string]string{"zone": "a", "dc": "dc1"}}, 2)
+	hash.AddWithWeight(&Node{ID: "20", Tags: map[string]string{"zone": "a", "dc": "dc1"}}, 2)
+	hash.AddWithWeight(&Node{ID: "30", Tags: map[string]string{"zone": "b", "dc": "dc1"}}, 2)
+	hash.AddWithWeight(&Node{ID: "40", Tags: map[string]string{"zone": "c", "dc": "dc2"}}, 2)
+	hash.AddWithWeight(&Node{ID: "50", Tags: map[string]string{"dc": "dc2"}}, 2)
+
+	testCases := []struct {
+		strategy PlacementStrategy
+		n        int
+		expected []string
+	}{
+		{SimpleStrategy{}, 3, []string{"10", "20", "30"}},
+		{SimpleStrategy{}, 8, []string{"10", "20", "30", "40", "50"}},
+		{ZoneAwareStrategy{Label: "zone"}, 3, []string{"10", "30", "40"}},
+		{ZoneAwareStrategy{Label: "zone"}, 5, []string{"10", "30", "40", "50", "20"}},
+		{DCAwareStrategy{Label: "dc", Replicas: ReplicaPlacement{"dc1": 1, "dc2": 2}}, 5, []string{"10", "40", "50"}},
+		{DCAwareStrategy{Label: "dc", Replicas: ReplicaPlacement{"dc1": 2, "dc2": 2}}, 3, []string{"10", "20", "40"}},
+	}
+
+	for i, testCase := range testCases {
+		hash.SetPlacementStrategy(testCase.strategy)
+		if nodes := hash.GetN("5", testCase.n, nil); !reflect.DeepEqual(nodes, testCase.expected) {
+			t.Errorf("%d: Expected %v, but got: %v", i, testCase.expected, nodes)
+		}
+	}
+
+	hash.SetPlacementStrategy(ZoneAwareStrategy{Label: "zone"})
+	hash.MarkDown("30")
+	expected := []string{"10", "40", "50"}
+	if nodes := hash.GetN("5", 3, nil); !reflect.DeepEqual(nodes, expected) {
+		t.Errorf("Expected %v, but got: %v", expected, nodes)
+	}
+}
+
+func TestProportionalStrategy(t *testing.T) {
+	hash := New(10, nil, WithPlacementStrategy(ProportionalStrategy{}))
+	plain := New(10, nil)
+	for key, weight := range map[string]int{"a": 1, "b": 1, "c": 1, "d": 5} {
+		hash.AddStringWithWeight(key, weight)
+		plain.AddStringWithWeight(key, weight)
+	}
+
+	seconds, others := make(map[string]int), 0
+	for i := 0; i < 4000; i++ {
+		key := strconv.Itoa(i)
+		nodes := hash.GetN(key, 3, nil)
+		if len(nodes) != 3 || nodes[0] != plain.Get(key) || !reflect.DeepEqual(nodes, hash.GetN(key, 3, nil)) {
+			t.Fatalf("Expected 3 distinct replicas starting at the owner %s, deterministically, but got: %v", plain.Get(key), nodes)
+		}
+		if nodes[0] != "d" {
+			seconds[nodes[1]]++
+			others++
+		}
+	}
+
+	// Whenever d doesn't own a key it has 5 times the weight of either of
+	// the other candidates, so it should be the second replica 5 out of 7
+	// times.
+	if share := float64(seconds["d"]) / float64(others); share < 0.65 || share > 0.77 {
+		t.Errorf("Expected d to be a frequent second replica, but got: %v", seconds)
+	}
+}
@@ -0,0 +1,125 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"sort"
+	"sync"
+)
+
+// A subject that moved from one consumer to another. From is empty for
+// subjects that were just tracked, To is empty if no consumer is left.
+type Reassignment struct {
+	Subject string
+	From    string
+	To      string
+}
+
+// Assigns stream subjects (or any other work keys) to the consumer
+// instances that are the items of a ring, and reports reassignments when
+// membership changes. Every consumer, e.g. of a NATS JetStream stream,
+// can use it to decide which subjects to subscribe to, and to move its
+// subscriptions when consumers join or leave.
+type StreamAssigner struct {
+	m          *Map
+	onReassign func([]Reassignment)
+	cancel     func()
+	mu         sync.Mutex
+	owners     map[string]string
+}
+
+// Creates an assigner for the consumers in m. The onReassign callback is
+// called with all reassignments caused by a single change, sorted by
+// subject. When called due to a membership change it must not modify the
+// hash.
+func NewStreamAssigner(m *Map, onReassign func([]Reassignment)) *StreamAssigner {
+	a := &StreamAssigner{m: m, onReassign: onReassign, owners: make(map[string]string)}
+	a.cancel = m.OnChange(func(ChangeEvent) { a.refresh(nil) })
+	return a
+}
+
+// Starts tracking subjects, and reports their initial assignments.
+func (a *StreamAssigner) Track(subjects ...string) {
+	a.refresh(subjects)
+}
+
+// Stops tracking subjects.
+func (a *StreamAssigner) Untrack(subjects ...string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for _, subject := range subjects {
+		delete(a.owners, subject)
+	}
+}
+
+// Returns the consumer a tracked subject is assigned to.
+func (a *StreamAssigner) ConsumerFor(subject string) string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.owners[subject]
+}
+
+// Returns the subjects assigned to each consumer, sorted.
+func (a *StreamAssigner) Assignments() map[string][]string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	out := make(map[string][]string)
+	for subject, owner := range a.owners {
+		if owner != "" {
+			out[owner] = append(out[owner], subject)
+		}
+	}
+	for _, subjects := range out {
+		sort.Strings(subjects)
+	}
+	return out
+}
+
+// Stops following membership changes of the hash.
+func (a *StreamAssigner) Close() {
+	a.cancel()
+}
+
+// Recomputes the assignments of all tracked subjects plus the new ones.
+func (a *StreamAssigner) refresh(subjects []string) {
+	var moved []Reassignment
+
+	a.mu.Lock()
+	a.m.mu.RLock()
+	for _, subject := range subjects {
+		if _, exists := a.owners[subject]; !exists {
+			owner := a.m.get(subject)
+			a.owners[subject] = owner
+			moved = append(moved, Reassignment{Subject: subject, To: owner})
+		}
+	}
+	for subject, owner := range a.owners {
+		if current := a.m.get(subject); current != owner {
+			a.owners[subject] = current
+			moved = append(moved, Reassignment{Subject: subject, From: owner, To: current})
+		}
+	}
+	a.m.mu.RUnlock()
+	a.mu.Unlock()
+
+	if len(moved) == 0 || a.onReassign == nil {
+		return
+	}
+	sort.Slice(moved, func(i, j int) bool { return moved[i].Subject < moved[j].Subject })
+	a.onReassign(moved)
+}
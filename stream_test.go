@@ -0,0 +1,63 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStreamAssigner(t *testing.T) {
+	hash := New(1, intHash)
+	hash.AddString("10", "30")
+
+	var events [][]Reassignment
+	a := NewStreamAssigner(hash, func(moved []Reassignment) { events = append(events, moved) })
+	defer a.Close()
+
+	a.Track("15", "25", "35")
+	expected := []Reassignment{{"15", "", "30"}, {"25", "", "30"}, {"35", "", "10"}}
+	if len(events) != 1 || !reflect.DeepEqual(events[0], expected) {
+		t.Errorf("Expected initial assignments %v, but got: %v", expected, events)
+	}
+
+	hash.AddString("20")
+	expected = []Reassignment{{"15", "30", "20"}}
+	if len(events) != 2 || !reflect.DeepEqual(events[1], expected) {
+		t.Errorf("Expected reassignments %v, but got: %v", expected, events)
+	}
+	if consumer := a.ConsumerFor("15"); consumer != "20" {
+		t.Errorf("Expected 15 to be assigned to 20, but got: %s", consumer)
+	}
+
+	hash.Del("30")
+	expected = []Reassignment{{"25", "30", "10"}}
+	if len(events) != 3 || !reflect.DeepEqual(events[2], expected) {
+		t.Errorf("Expected reassignments %v, but got: %v", expected, events)
+	}
+
+	a.Untrack("15")
+	if assignments := a.Assignments(); !reflect.DeepEqual(assignments, map[string][]string{"10": {"25", "35"}}) {
+		t.Errorf("Unexpected assignments: %v", assignments)
+	}
+
+	// Changes that don't move tracked subjects are not reported.
+	hash.AddString("12")
+	if len(events) != 3 {
+		t.Errorf("Expected no reassignments, but got: %v", events[3:])
+	}
+}
@@ -0,0 +1,208 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// The delays between reconnection attempts of a subscriber start at
+	// the minimum and double after every failure up to the maximum.
+	subscriberMinBackoff = 100 * time.Millisecond
+	subscriberMaxBackoff = 30 * time.Second
+)
+
+var errEpochGap = errors.New("Missed changes of the ring")
+
+// Maintains a read-only copy of the ring of a PartitionPublisher, and
+// looks up keys in it locally. The copy is kept up to date by long
+// polling the publisher, resuming from the last epoch received. When
+// deltas are missing, or the publisher was restarted, the full ring is
+// fetched again.
+type PartitionSubscriber struct {
+	url        string
+	client     *http.Client
+	m          *Map
+	mu         sync.Mutex
+	generation string
+	epoch      uint64
+	synced     bool
+}
+
+// Creates a subscriber to the publisher at the given URL. The hash
+// function and options must match those of the published hash, or keys
+// map differently; the default weight is irrelevant, as the weights of
+// the members are published.
+func NewPartitionSubscriber(url string, fn Hash, opts ...Option) *PartitionSubscriber {
+	return &PartitionSubscriber{url: url, client: http.DefaultClient, m: New(1, fn, opts...)}
+}
+
+// Returns the epoch of the publisher the copy is at, and whether the
+// subscriber has received the ring at all.
+func (s *PartitionSubscriber) Epoch() (uint64, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.epoch, s.synced
+}
+
+// Gets the closest item in the copy of the ring to the provided key.
+func (s *PartitionSubscriber) Get(key string) string {
+	return s.m.Get(key)
+}
+
+// Gets up to n distinct items from the copy of the ring for the provided
+// key, see Map.GetN.
+func (s *PartitionSubscriber) GetN(key string, n int, accept func([]string, string) bool) []string {
+	return s.m.GetN(key, n, accept)
+}
+
+// Returns the members of the copy of the ring.
+func (s *PartitionSubscriber) Snapshot() *Snapshot {
+	return s.m.Snapshot()
+}
+
+// Fetches the changes since the last sync, waiting up to wait for one,
+// and applies them to the copy.
+func (s *PartitionSubscriber) Sync(ctx context.Context, wait time.Duration) error {
+	err := s.sync(ctx, wait)
+	if err == errEpochGap {
+		s.m.currentLogger().Warn("Missed changes of the ring, fetching it again", "url", s.url)
+		err = s.sync(ctx, 0)
+	}
+	return err
+}
+
+// Keeps the copy up to date until ctx is done, reconnecting with
+// exponential backoff when the publisher cannot be reached. Errors are
+// passed to onError, which may be nil.
+func (s *PartitionSubscriber) Run(ctx context.Context, wait time.Duration, onError func(error)) error {
+	backoff := subscriberMinBackoff
+	for {
+		err := s.Sync(ctx, wait)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err == nil {
+			backoff = subscriberMinBackoff
+			continue
+		}
+
+		if onError != nil {
+			onError(err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff = min(2*backoff, subscriberMaxBackoff)
+	}
+}
+
+func (s *PartitionSubscriber) sync(ctx context.Context, wait time.Duration) error {
+	u, err := url.Parse(s.url)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	generation, epoch, synced := s.generation, s.epoch, s.synced
+	s.mu.Unlock()
+	if synced {
+		query := u.Query()
+		if generation != "" {
+			query.Set("generation", generation)
+		}
+		query.Set("since", strconv.FormatUint(epoch, 10))
+		query.Set("wait", wait.String())
+		u.RawQuery = query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotModified:
+		return nil
+	default:
+		var e errorResponse
+		json.NewDecoder(resp.Body).Decode(&e)
+		return fmt.Errorf("Publisher returned status %d: %s", resp.StatusCode, e.Error)
+	}
+
+	var update PartitionUpdate
+	if err := json.NewDecoder(resp.Body).Decode(&update); err != nil {
+		return fmt.Errorf("Invalid update from publisher: %s", err)
+	}
+	return s.apply(update)
+}
+
+func (s *PartitionSubscriber) apply(update PartitionUpdate) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if update.Snapshot != nil {
+		if err := s.m.Restore(update.Snapshot); err != nil {
+			return err
+		}
+		s.generation, s.epoch, s.synced = update.Generation, update.Snapshot.Epoch, true
+		return nil
+	}
+	if update.Generation != s.generation {
+		s.synced = false
+		return errEpochGap
+	}
+
+	for _, d := range update.Deltas {
+		if d.From != s.epoch {
+			s.synced = false
+			return errEpochGap
+		}
+		var txn Txn
+		for _, key := range d.Removed {
+			txn = append(txn, RemoveChange(key))
+		}
+		for _, n := range d.Nodes {
+			if _, exists := s.m.Value(n.ID); exists {
+				txn = append(txn, RemoveChange(n.ID))
+			}
+			txn = append(txn, n.changes()...)
+		}
+		if err := s.m.Apply(txn...); err != nil {
+			s.synced = false
+			return err
+		}
+		s.epoch = d.Epoch
+	}
+	return nil
+}
@@ -0,0 +1,160 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPartitionSubscriber(t *testing.T) {
+	hash := New(3, intHash)
+	hash.AddString("10", "20")
+	p := NewPartitionPublisher(hash, 1)
+	defer p.Close()
+	server := httptest.NewServer(p)
+	defer server.Close()
+
+	s := NewPartitionSubscriber(server.URL, intHash)
+	if _, synced := s.Epoch(); synced {
+		t.Errorf("Expected subscriber not to be synced before the first sync")
+	}
+	if err := s.Sync(context.Background(), 0); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if epoch, synced := s.Epoch(); !synced || epoch != hash.Epoch() {
+		t.Errorf("Expected subscriber at epoch %d, but got: %d", hash.Epoch(), epoch)
+	}
+
+	check := func() {
+		t.Helper()
+		for _, key := range []string{"5", "15", "25", "115", "215"} {
+			if got, expected := s.GetN(key, 2, nil), hash.GetN(key, 2, nil); strings.Join(got, ",") != strings.Join(expected, ",") {
+				t.Errorf("Expected %v for %s, but got: %v", expected, key, got)
+			}
+		}
+	}
+	check()
+
+	// Up to date subscribers get a 304.
+	if err := s.Sync(context.Background(), 0); err != nil {
+		t.Errorf("Unexpected error: %s", err)
+	}
+
+	hash.AddString("30")
+	if err := s.Sync(context.Background(), 0); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	check()
+
+	// The publisher only keeps one delta, so this needs a snapshot.
+	hash.Del("10")
+	hash.Apply(WeightChange("20", 1))
+	if err := s.Sync(context.Background(), 0); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	check()
+	if epoch, _ := s.Epoch(); epoch != hash.Epoch() {
+		t.Errorf("Expected subscriber at epoch %d, but got: %d", hash.Epoch(), epoch)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- s.Run(ctx, time.Second, nil) }()
+	hash.AddString("40")
+	for deadline := time.Now().Add(5 * time.Second); s.Get("35") != "40"; {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected subscriber to receive the addition of 40")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("Expected Run to stop when cancelled, but got: %v", err)
+	}
+}
+
+func TestPartitionSubscriberEpochGap(t *testing.T) {
+	responses := []string{
+		`{"epoch": 5, "snapshot": {"epoch": 5, "nodes": [{"id": "10", "weight": 3}]}}`,
+		`{"epoch": 8, "deltas": [{"from": 7, "epoch": 8, "removed": ["10"]}]}`,
+		`{"epoch": 8, "snapshot": {"epoch": 8, "nodes": [{"id": "20", "weight": 3}]}}`,
+	}
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.RawQuery)
+		w.Write([]byte(responses[0]))
+		responses = responses[1:]
+	}))
+	defer server.Close()
+
+	s := NewPartitionSubscriber(server.URL, intHash)
+	s.Sync(context.Background(), 0)
+	if err := s.Sync(context.Background(), 0); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if len(queries) != 3 || queries[1] != "since=5&wait=0s" || queries[2] != "" {
+		t.Errorf("Expected the ring to be fetched again after the gap, but got: %q", queries)
+	}
+	if epoch, _ := s.Epoch(); epoch != 8 || s.Get("15") != "20" {
+		t.Errorf("Expected subscriber at epoch 8, but got: %d, %s", epoch, s.Get("15"))
+	}
+}
+
+func TestPartitionSubscriberPublisherRestart(t *testing.T) {
+	hash := New(3, intHash)
+	hash.AddString("10", "20")
+	p := NewPartitionPublisher(hash, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { p.ServeHTTP(w, r) }))
+	defer server.Close()
+
+	s := NewPartitionSubscriber(server.URL, intHash)
+	if err := s.Sync(context.Background(), 0); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	synced, _ := s.Epoch()
+
+	// The restarted publisher reaches the same epoch with other members,
+	// and then has a delta from it.
+	p.Close()
+	restarted := New(3, intHash)
+	restarted.AddString("30", "40")
+	p = NewPartitionPublisher(restarted, 10)
+	defer p.Close()
+	restarted.AddString("50")
+	if epoch := restarted.Epoch(); epoch != synced+1 {
+		t.Fatalf("Expected the restarted publisher at epoch %d, but got: %d", synced+1, epoch)
+	}
+
+	if err := s.Sync(context.Background(), 0); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	for _, key := range []string{"5", "15", "35", "45"} {
+		if got, expected := s.Get(key), restarted.Get(key); got != expected {
+			t.Errorf("Expected %s for %s after the restart, but got: %s", expected, key, got)
+		}
+	}
+
+	// Deltas of another generation are never applied.
+	if err := s.apply(PartitionUpdate{Generation: "other", Epoch: synced + 2, Deltas: []PartitionDelta{{From: synced + 1, Epoch: synced + 2}}}); err != errEpochGap {
+		t.Errorf("Expected errEpochGap for another generation, but got: %v", err)
+	}
+}
@@ -0,0 +1,61 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"math/rand"
+	"sort"
+)
+
+// Deterministically selects k of the provided backends for the client
+// with the provided ID (e.g. the ordinal of the client's instance). The
+// subset is stable across restarts, and clients with consecutive IDs
+// are spread evenly across all backends, so every backend ends up with
+// roughly the same number of clients.
+//
+// Clients are divided into rounds of len(backends)/k clients. Within a
+// round backends are shuffled the same way and every client takes a
+// distinct slice of k of them.
+func Subset(backends []string, clientID int, k int) []string {
+	sorted := make([]string, len(backends))
+	copy(sorted, backends)
+	sort.Strings(sorted)
+	if k >= len(sorted) {
+		return sorted
+	}
+	if k < 1 || clientID < 0 {
+		return []string{}
+	}
+
+	subsetCount := len(sorted) / k
+	round := clientID / subsetCount
+	r := rand.New(rand.NewSource(int64(round)))
+	r.Shuffle(len(sorted), func(i, j int) { sorted[i], sorted[j] = sorted[j], sorted[i] })
+
+	start := (clientID % subsetCount) * k
+	out := sorted[start : start+k]
+	sort.Strings(out)
+	return out
+}
+
+// Creates a hash that only contains the subset of backends selected for
+// the client with the provided ID, see Subset.
+func NewSubset(defaultWeight int, fn Hash, backends []string, clientID, k int) *Map {
+	m := New(defaultWeight, fn)
+	m.AddString(Subset(backends, clientID, k)...)
+	return m
+}
@@ -0,0 +1,60 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestSubset(t *testing.T) {
+	var backends []string
+	for i := 0; i < 12; i++ {
+		backends = append(backends, fmt.Sprintf("backend-%d", i))
+	}
+
+	if res := Subset(backends, 7, 3); !reflect.DeepEqual(res, Subset(backends, 7, 3)) {
+		t.Errorf("Expected subsets to be deterministic")
+	}
+
+	// 12 clients make up 3 full rounds, so every backend gets 3 clients.
+	clients := map[string]int{}
+	for id := 0; id < 12; id++ {
+		subset := Subset(backends, id, 3)
+		if len(subset) != 3 {
+			t.Fatalf("Expected subset of 3, but got: %v", subset)
+		}
+		for _, backend := range subset {
+			clients[backend]++
+		}
+	}
+	for _, backend := range backends {
+		if clients[backend] != 3 {
+			t.Errorf("Expected %s to have 3 clients, but got: %d", backend, clients[backend])
+		}
+	}
+
+	if res := Subset(backends, 1, 20); len(res) != 12 {
+		t.Errorf("Expected all backends when k exceeds their number, but got: %v", res)
+	}
+
+	hash := NewSubset(10, nil, backends, 5, 4)
+	if len(hash.entries) != 4 {
+		t.Errorf("Expected ring of 4 items, but got: %d", len(hash.entries))
+	}
+}
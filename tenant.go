@@ -0,0 +1,53 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+// A handle on a hash that salts every key with a tenant ID before it is
+// hashed, so identical keys of different tenants are spread
+// independently of each other.
+type TenantRing struct {
+	m      *Map
+	tenant string
+}
+
+// Returns a handle on the hash for the provided tenant.
+func (m *Map) ForTenant(tenant string) TenantRing {
+	return TenantRing{m: m, tenant: tenant}
+}
+
+// Returns the tenant ID keys are salted with.
+func (t TenantRing) Tenant() string {
+	return t.tenant
+}
+
+func (t TenantRing) hashKey(key string) int {
+	return t.m.hashString(t.tenant + "\x00" + key)
+}
+
+// Gets the closest item to the salted key.
+func (t TenantRing) Get(key string) string {
+	t.m.mu.RLock()
+	defer t.m.mu.RUnlock()
+	return t.m.getHash(t.hashKey(key))
+}
+
+// Gets the N closest items to the salted key, see Map.GetN.
+func (t TenantRing) GetN(key string, n int, accept func([]string, string) bool) []string {
+	t.m.mu.RLock()
+	defer t.m.mu.RUnlock()
+	return t.m.getNHash(t.hashKey(key), n, accept)
+}
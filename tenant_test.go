@@ -0,0 +1,56 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestForTenant(t *testing.T) {
+	hash := New(50, nil)
+	hash.AddString("A", "B", "C", "D", "E")
+
+	a, b := hash.ForTenant("tenant-a"), hash.ForTenant("tenant-b")
+	if a.Tenant() != "tenant-a" {
+		t.Errorf("Expected tenant-a, but got: %s", a.Tenant())
+	}
+
+	differ := 0
+	for i := 0; i < 1000; i++ {
+		key := strconv.Itoa(i)
+		if a.Get(key) != a.Get(key) {
+			t.Fatalf("Expected lookups of %s to be stable", key)
+		}
+		if a.Get(key) != b.Get(key) {
+			differ++
+		}
+		if nodes := a.GetN(key, 2, AcceptUnique); len(nodes) != 2 || nodes[0] != a.Get(key) {
+			t.Fatalf("Expected GetN to start at Get, but got: %v", nodes)
+		}
+	}
+
+	// With 5 items, independent placement puts about 80% of the keys on a
+	// different item.
+	if differ < 700 {
+		t.Errorf("Expected tenants to be spread independently, but only %d of 1000 keys differ", differ)
+	}
+
+	if node := New(1, nil).ForTenant("x").Get("foo"); node != "" {
+		t.Errorf("Expected no owner for an empty ring, but got: %s", node)
+	}
+}
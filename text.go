@@ -0,0 +1,71 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Returns a stable, line based listing of the items of the hash with
+// their weight, number of virtual nodes, share of the ring and labels,
+// ordered by item. It is meant to be checked in and diffed, e.g. to
+// compare the topology of environments, and doesn't include the epoch.
+//
+//	# GoConsistentHash ring: 2 nodes
+//	A weight=3 vnodes=3 share=41.20% zone=a
+//	B weight=3 vnodes=3 share=58.80% zone=b
+func (m *Map) MarshalText() ([]byte, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# GoConsistentHash ring: %d nodes", len(m.entries))
+	if m.compat != CompatNone {
+		fmt.Fprintf(&b, ", compat %s", m.compat)
+	}
+	b.WriteByte('\n')
+
+	for _, s := range m.vnodeReport() {
+		fmt.Fprintf(&b, "%s weight=%d vnodes=%d share=%.2f%%", quoteText(s.Node), m.entries[s.Node].weight, s.VirtualNodes, s.Share*100)
+		if m.entries[s.Node].explicit {
+			b.WriteString(" tokens=explicit")
+		}
+
+		labels := m.entries[s.Node].labels
+		keys := make([]string, 0, len(labels))
+		for k := range labels {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fmt.Fprintf(&b, " %s=%s", quoteText(k), quoteText(labels[k]))
+		}
+		b.WriteByte('\n')
+	}
+	return []byte(b.String()), nil
+}
+
+// Quotes a value if it would be ambiguous in a textual listing.
+func quoteText(v string) string {
+	if v == "" || strings.ContainsAny(v, " \t\n\"=#") || !strconv.CanBackquote(v) {
+		return strconv.Quote(v)
+	}
+	return v
+}
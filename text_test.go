@@ -0,0 +1,46 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"encoding"
+	"testing"
+)
+
+var _ encoding.TextMarshaler = &Map{}
+
+func TestMarshalText(t *testing.T) {
+	hash := New(1, intHash)
+	hash.AddWithWeight(&Node{ID: "10", Tags: map[string]string{"zone": "a", "rack": "r 1"}}, 1)
+	hash.AddStringWithWeight("20", 1)
+	hash.AddWithTokens(&StringValue{"my node"}, []uint32{1 << 31})
+
+	text, err := hash.MarshalText()
+	expected := `# GoConsistentHash ring: 3 nodes
+10 weight=1 vnodes=1 share=50.00% rack="r 1" zone=a
+20 weight=1 vnodes=1 share=0.00%
+"my node" weight=1 vnodes=1 share=50.00% tokens=explicit
+`
+	if err != nil || string(text) != expected {
+		t.Errorf("Expected:\n%s\nbut got:\n%s", expected, text)
+	}
+
+	hash = NewParity(1)
+	if text, _ := hash.MarshalText(); string(text) != "# GoConsistentHash ring: 0 nodes, compat v1\n" {
+		t.Errorf("Unexpected listing of empty hash: %q", text)
+	}
+}
@@ -0,0 +1,61 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"fmt"
+)
+
+// Adds an item at explicit positions on the ring (Cassandra initial_token
+// style) instead of positions derived from its weight. This allows to
+// reproduce or pin the exact token layout of an existing cluster. The
+// weight of the item is the number of tokens.
+func (m *Map) AddWithTokens(entryValue EntryValue, tokens []uint32) error {
+	return m.mutate([]Change{AddTokensChange(entryValue, tokens)}, func() error {
+		return m.addTokens(entryValue, tokens)
+	})
+}
+
+func (m *Map) addTokens(entryValue EntryValue, tokens []uint32) error {
+	positions := make([]int, 0, len(tokens))
+	seen := make(map[uint32]bool, len(tokens))
+	for _, token := range tokens {
+		if seen[token] {
+			return fmt.Errorf("Token %d is listed more than once", token)
+		}
+		seen[token] = true
+		positions = append(positions, int(token))
+	}
+	return m.addEntry(entryValue, len(tokens), positions, true)
+}
+
+// Returns the tokens of an item, and whether they were provided
+// explicitly through AddWithTokens.
+func (m *Map) Tokens(key string) (tokens []uint32, explicit bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	e, exists := m.entries[key]
+	if !exists {
+		return nil, false
+	}
+	tokens = make([]uint32, len(e.positions))
+	for i, pos := range e.positions {
+		tokens[i] = uint32(pos)
+	}
+	return tokens, e.explicit
+}
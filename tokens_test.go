@@ -0,0 +1,57 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAddWithTokens(t *testing.T) {
+	hash := New(3, intHash)
+	if err := hash.AddWithTokens(&StringValue{"A"}, []uint32{100, 300}); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	hash.AddWithTokens(&StringValue{"B"}, []uint32{200})
+
+	testCases := map[string]string{
+		"50":  "A",
+		"150": "B",
+		"250": "A",
+		"350": "A",
+	}
+	for k, v := range testCases {
+		if res := hash.Get(k); res != v {
+			t.Errorf("Asking for %s, should have yielded %s, but got: %s", k, v, res)
+		}
+	}
+
+	if tokens, explicit := hash.Tokens("A"); !explicit || !reflect.DeepEqual(tokens, []uint32{100, 300}) {
+		t.Errorf("Expected explicit tokens 100 and 300, but got: %v", tokens)
+	}
+	if err := hash.Apply(WeightChange("A", 5)); err == nil {
+		t.Errorf("Expected error, but it wasn't returned")
+	}
+	if err := hash.AddWithTokens(&StringValue{"C"}, []uint32{5, 5}); err == nil {
+		t.Errorf("Expected error, but it wasn't returned")
+	}
+
+	hash.Del("A")
+	if len(hash.hashMap) != 1 || hash.Get("350") != "B" {
+		t.Errorf("Expected only B to remain on the ring")
+	}
+}
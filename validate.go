@@ -0,0 +1,108 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// Verifies the internal consistency of the hash and returns an error
+// describing every inconsistency found, or nil if there are none. It is
+// meant for debugging and health endpoints.
+func (m *Map) Validate() error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var errs []error
+	if !sort.IntsAreSorted(m.keys) {
+		errs = append(errs, errors.New("Positions are not sorted"))
+	}
+
+	counts := make(map[int]int, len(m.keys))
+	for _, pos := range m.keys {
+		counts[pos]++
+	}
+	for pos, n := range counts {
+		if n > 1 {
+			errs = append(errs, fmt.Errorf("Position %d appears %d times", pos, n))
+		}
+		if _, exists := m.hashMap[pos]; !exists {
+			errs = append(errs, fmt.Errorf("Position %d has no owner", pos))
+		}
+	}
+
+	for pos, id := range m.hashMap {
+		if counts[pos] == 0 {
+			errs = append(errs, fmt.Errorf("Position %d has an owner but is not on the ring", pos))
+		}
+		if id < 0 || int(id) >= len(m.names) || m.names[id] == "" {
+			errs = append(errs, fmt.Errorf("Position %d refers to unknown item index %d", pos, id))
+			continue
+		}
+		e, exists := m.entries[m.names[id]]
+		if !exists {
+			errs = append(errs, fmt.Errorf("Position %d refers to '%s', which is not an item", pos, m.names[id]))
+		} else if indexOf(e.positions, pos) < 0 {
+			errs = append(errs, fmt.Errorf("Position %d refers to '%s', which has no virtual node there", pos, m.names[id]))
+		}
+	}
+
+	for key, e := range m.entries {
+		if e.id < 0 || int(e.id) >= len(m.names) || m.names[e.id] != key {
+			errs = append(errs, fmt.Errorf("Node '%s' has an invalid index %d", key, e.id))
+		}
+		if limit := e.weight + len(e.manual); !e.explicit && len(e.positions) > limit {
+			errs = append(errs, fmt.Errorf("Node '%s' has %d virtual nodes, but its weight allows at most %d", key, len(e.positions), limit))
+		}
+		for _, pos := range e.positions {
+			id, exists := m.hashMap[pos]
+			if exists && id != e.id && m.contends(e.id, pos) {
+				if m.names[id] > key {
+					errs = append(errs, fmt.Errorf("Node '%s' sorts before '%s', but does not own position %d", key, m.names[id], pos))
+				}
+				continue
+			}
+			if !exists || id != e.id {
+				errs = append(errs, fmt.Errorf("Node '%s' does not own its virtual node at position %d", key, pos))
+			}
+		}
+	}
+
+	for pos, contenders := range m.contenders {
+		for _, id := range contenders {
+			if e, exists := m.entries[m.names[id]]; !exists || e.id != id || indexOf(e.positions, pos) < 0 {
+				errs = append(errs, fmt.Errorf("Position %d has a contender that has no virtual node there", pos))
+			}
+		}
+	}
+
+	sort.Slice(errs, func(i, j int) bool { return errs[i].Error() < errs[j].Error() })
+	return errors.Join(errs...)
+}
+
+// Returns whether the item with the provided index lost the position to
+// another item. The caller must hold at least a read lock.
+func (m *Map) contends(id int32, pos int) bool {
+	for _, c := range m.contenders[pos] {
+		if c == id {
+			return true
+		}
+	}
+	return false
+}
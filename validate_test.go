@@ -0,0 +1,62 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidate(t *testing.T) {
+	hash := New(3, intHash)
+	if err := hash.Validate(); err != nil {
+		t.Errorf("Expected an empty ring to be valid, but got: %s", err)
+	}
+
+	hash.AddString("10", "20", "30")
+	hash.AddVirtualNode("10", 1000)
+	hash.RemoveVirtualNode("20", 120)
+	hash.AddWithTokens(&StringValue{"40"}, []uint32{5000, 6000})
+	hash.Apply(WeightChange("30", 5))
+	hash.Del("20")
+	if err := hash.Validate(); err != nil {
+		t.Errorf("Expected ring to be valid, but got: %s", err)
+	}
+
+	// Corrupt the ring in a few different ways.
+	hash.keys[0], hash.keys[1] = hash.keys[1], hash.keys[0]
+	hash.hashMap[7] = hash.entries["10"].id
+	delete(hash.hashMap, 5000)
+	hash.entries["30"].weight = 1
+
+	err := hash.Validate()
+	if err == nil {
+		t.Fatalf("Expected corrupted ring to be invalid")
+	}
+	for _, expected := range []string{
+		"Positions are not sorted",
+		"Position 5000 has no owner",
+		"Position 7 has an owner but is not on the ring",
+		"Position 7 refers to '10', which has no virtual node there",
+		"Node '40' does not own its virtual node at position 5000",
+		"Node '30' has 5 virtual nodes, but its weight allows at most 1",
+	} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected error to contain %q, but got: %s", expected, err)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+)
+
+// An item of the ring a set of test vectors was generated for.
+type VectorNode struct {
+	ID     string `json:"id"`
+	Weight int    `json:"weight"`
+}
+
+// The decision made for a single key.
+type Vector struct {
+	Key      string   `json:"key"`
+	Owner    string   `json:"owner"`
+	Replicas []string `json:"replicas"`
+}
+
+// A machine-readable set of key to item decisions, which client
+// implementations in other languages can use to verify they map keys
+// identically. Replicas are computed with GetN and AcceptUnique.
+type VectorSet struct {
+	Replicas int          `json:"replicas"`
+	Nodes    []VectorNode `json:"nodes"`
+	Vectors  []Vector     `json:"vectors"`
+}
+
+// Generates test vectors for the provided keys against the current
+// configuration of the ring, with the requested number of replicas.
+func (m *Map) GenerateVectors(keys []string, replicas int) *VectorSet {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	set := &VectorSet{
+		Replicas: replicas,
+		Nodes:    make([]VectorNode, 0, len(m.entries)),
+		Vectors:  make([]Vector, 0, len(keys)),
+	}
+	for key, e := range m.entries {
+		set.Nodes = append(set.Nodes, VectorNode{key, e.weight})
+	}
+	sort.Slice(set.Nodes, func(i, j int) bool { return set.Nodes[i].ID < set.Nodes[j].ID })
+
+	for _, key := range keys {
+		set.Vectors = append(set.Vectors, Vector{
+			Key:      key,
+			Owner:    m.get(key),
+			Replicas: m.getN(key, replicas, AcceptUnique),
+		})
+	}
+	return set
+}
+
+// Generates test vectors for a ring with the provided items and weights,
+// see Map.GenerateVectors. Items without a weight get defaultWeight.
+func GenerateVectors(defaultWeight int, fn Hash, nodes []string, weights map[string]int, keys []string, replicas int) (*VectorSet, error) {
+	m := New(defaultWeight, fn)
+	for _, node := range nodes {
+		weight, exists := weights[node]
+		if !exists {
+			weight = defaultWeight
+		}
+		if err := m.AddStringWithWeight(node, weight); err != nil {
+			return nil, err
+		}
+	}
+	return m.GenerateVectors(keys, replicas), nil
+}
+
+// Writes the vectors as indented JSON.
+func (s *VectorSet) WriteJSON(w io.Writer) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
+
+// Verifies the ring against the vectors, and returns the vectors it makes
+// a different decision for.
+func (m *Map) VerifyVectors(s *VectorSet) []Vector {
+	var out []Vector
+	for _, v := range m.GenerateVectors(s.keys(), s.Replicas).Vectors {
+		if expected := s.vector(v.Key); expected.Owner != v.Owner || !equalStrings(expected.Replicas, v.Replicas) {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+func (s *VectorSet) keys() []string {
+	keys := make([]string, len(s.Vectors))
+	for i, v := range s.Vectors {
+		keys[i] = v.Key
+	}
+	return keys
+}
+
+func (s *VectorSet) vector(key string) Vector {
+	for _, v := range s.Vectors {
+		if v.Key == key {
+			return v
+		}
+	}
+	return Vector{}
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
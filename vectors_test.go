@@ -0,0 +1,67 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"testing"
+)
+
+func parityKeys() []string {
+	keys := []string{"", "a", "user:1", "user:2", "user:1337", "session/abc", "ключ", "日本語", "emoji-🙂"}
+	for i := 0; i < 16; i++ {
+		keys = append(keys, fmt.Sprintf("key-%d", i))
+	}
+	return keys
+}
+
+func TestGenerateVectors(t *testing.T) {
+	nodes := []string{"cache-a", "cache-b", "cache-c", "cache-d"}
+	weights := map[string]int{"cache-c": 100, "cache-d": 25}
+	set, err := GenerateVectors(50, nil, nodes, weights, parityKeys(), 3)
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	// The reference vectors were generated this way.
+	var buf bytes.Buffer
+	if err := set.WriteJSON(&buf); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	expected, _ := os.ReadFile("testdata/parity_vectors.json")
+	if !bytes.Equal(buf.Bytes(), expected) {
+		t.Errorf("Expected generated vectors to match testdata/parity_vectors.json")
+	}
+
+	var decoded VectorSet
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	hash := New(50, nil)
+	hash.AddString("cache-a", "cache-b", "cache-d")
+	if res := hash.VerifyVectors(&decoded); len(res) == 0 {
+		t.Errorf("Expected a different ring to fail verification")
+	}
+	hash.AddStringWithWeight("cache-c", 100)
+	hash.Apply(WeightChange("cache-d", 25))
+	if res := hash.VerifyVectors(&decoded); len(res) != 0 {
+		t.Errorf("Expected identical ring to pass verification, but got: %v", res)
+	}
+}
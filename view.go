@@ -0,0 +1,77 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+// A read-only view over the items of a hash that match a predicate. The
+// view holds no state of its own: the predicate is evaluated during every
+// lookup, so the view always reflects the current state of the hash.
+type RingView struct {
+	m         *Map
+	predicate func(EntryValue) bool
+}
+
+// Returns a view over the items for which predicate returns true, e.g.
+// to only route to healthy or zone-local items.
+func (m *Map) View(predicate func(EntryValue) bool) RingView {
+	return RingView{m: m, predicate: predicate}
+}
+
+func (v RingView) accepts(key string) bool {
+	return v.m.available(key) && v.predicate(v.m.entries[key].value)
+}
+
+// Gets the closest matching item to the provided key.
+func (v RingView) Get(key string) string {
+	res := v.GetN(key, 1, nil)
+	if len(res) == 0 {
+		return ""
+	}
+	return res[0]
+}
+
+// Gets the N closest matching items to the provided key, see Map.GetN.
+func (v RingView) GetN(key string, n int, accept func([]string, string) bool) []string {
+	v.m.mu.RLock()
+	defer v.m.mu.RUnlock()
+
+	if accept == nil {
+		accept = AcceptAny
+	}
+	check := placementCheck{m: v.m, first: true, accepts: func(picked []string, owner string) bool {
+		return v.accepts(owner) && (len(picked) == 0 || accept(picked, owner))
+	}}
+	return v.m.placeChecked(PlacementView{m: v.m}, []string{}, v.m.hashKey(key), n, check)
+}
+
+// Returns the values of all matching items.
+func (v RingView) Members() []EntryValue {
+	v.m.mu.RLock()
+	defer v.m.mu.RUnlock()
+
+	out := []EntryValue{}
+	for key, e := range v.m.entries {
+		if v.accepts(key) {
+			out = append(out, e.value)
+		}
+	}
+	return out
+}
+
+// Returns true if no item matches.
+func (v RingView) IsEmpty() bool {
+	return len(v.Members()) == 0
+}
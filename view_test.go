@@ -0,0 +1,53 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestView(t *testing.T) {
+	hash := New(1, intHash)
+	hash.AddWithWeight(&Node{ID: "10", Tags: map[string]string{"zone": "a"}}, 1)
+	hash.AddWithWeight(&Node{ID: "20", Tags: map[string]string{"zone": "b"}}, 1)
+	hash.AddWithWeight(&Node{ID: "30", Tags: map[string]string{"zone": "a"}}, 1)
+
+	zoneA := hash.View(func(v EntryValue) bool {
+		return v.(*Node).Tags["zone"] == "a"
+	})
+	if res := zoneA.Get("15"); res != "30" {
+		t.Errorf("Entry 15 should map to 30, but instead got: %s", res)
+	}
+	if res := zoneA.GetN("5", 3, nil); !reflect.DeepEqual(res, []string{"10", "30"}) {
+		t.Errorf("Expected only zone a items, but got: %v", res)
+	}
+
+	// The view follows changes of the parent ring.
+	hash.AddWithWeight(&Node{ID: "15", Tags: map[string]string{"zone": "a"}}, 1)
+	if res := zoneA.Get("12"); res != "15" {
+		t.Errorf("Entry 12 should map to 15, but instead got: %s", res)
+	}
+	if len(zoneA.Members()) != 3 {
+		t.Errorf("Expected 3 members, but got: %v", zoneA.Members())
+	}
+
+	none := hash.View(func(EntryValue) bool { return false })
+	if !none.IsEmpty() || none.Get("5") != "" {
+		t.Errorf("Expected an empty view")
+	}
+}
@@ -0,0 +1,97 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"sort"
+)
+
+// How the virtual nodes of a single item are laid out on the ring.
+type VNodeStats struct {
+	Node         string
+	VirtualNodes int
+	// Fraction of the ring the item owns, between 0 and 1.
+	Share float64
+	// Fraction of the ring the item would own if ownership was exactly
+	// proportional to weight.
+	FairShare float64
+	// The size of the largest contiguous range the item owns, in
+	// positions.
+	LargestArc uint64
+	// The largest gap between consecutive virtual nodes of the item,
+	// relative to the gap if they were spread evenly over the ring. 1 is
+	// perfectly even, higher values mean the virtual nodes are clustered.
+	Spread float64
+}
+
+// Returns how the virtual nodes of every item are laid out on the ring,
+// ordered by item.
+func (m *Map) VNodeReport() []VNodeStats {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.vnodeReport()
+}
+
+func (m *Map) vnodeReport() []VNodeStats {
+	stats := make(map[string]*VNodeStats, len(m.entries))
+	totalWeight := 0
+	for _, e := range m.entries {
+		totalWeight += e.weight
+	}
+	for key, e := range m.entries {
+		s := &VNodeStats{Node: key, VirtualNodes: len(e.positions), Spread: spread(e.positions)}
+		if totalWeight > 0 {
+			s.FairShare = float64(e.weight) / float64(totalWeight)
+		}
+		stats[key] = s
+	}
+
+	m.arcs(func(owner string, _ uint32, size uint64) {
+		s := stats[owner]
+		s.Share += float64(size) / ringSize
+		if size > s.LargestArc {
+			s.LargestArc = size
+		}
+	})
+
+	out := make([]VNodeStats, 0, len(stats))
+	for _, s := range stats {
+		out = append(out, *s)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Node < out[j].Node
+	})
+	return out
+}
+
+// Returns the largest gap between the positions, relative to the gap
+// between evenly spread positions.
+func spread(positions []int) float64 {
+	if len(positions) == 0 {
+		return 0
+	}
+	sorted := append([]int(nil), positions...)
+	sort.Ints(sorted)
+
+	var largest uint64
+	for i, k := range sorted {
+		if gap := arcSize(sorted[(i+len(sorted)-1)%len(sorted)], k); gap > largest {
+			largest = gap
+		}
+	}
+	return float64(largest) * float64(len(sorted)) / ringSize
+}
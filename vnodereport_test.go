@@ -0,0 +1,57 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestVNodeReport(t *testing.T) {
+	hash := New(1, intHash)
+	hash.AddWithTokens(&Node{ID: "a"}, []uint32{100, 200})
+	hash.AddWithTokens(&Node{ID: "b"}, []uint32{300, 1 << 31})
+	hash.AddWithTokens(&Node{ID: "c"}, []uint32{1<<32 - 1})
+
+	expected := []VNodeStats{
+		{Node: "a", VirtualNodes: 2, Share: 201 / ringSize, FairShare: 0.4, LargestArc: 101, Spread: (1<<32 - 100) * 2 / ringSize},
+		{Node: "b", VirtualNodes: 2, Share: (1<<31 - 200) / ringSize, FairShare: 0.4, LargestArc: 1<<31 - 300, Spread: (1<<31 + 300) * 2 / ringSize},
+		{Node: "c", VirtualNodes: 1, Share: (1<<31 - 1) / ringSize, FairShare: 0.2, LargestArc: 1<<31 - 1, Spread: 1},
+	}
+	report := hash.VNodeReport()
+	for i := range report {
+		if math.Abs(report[i].Share-expected[i].Share) < 1e-12 {
+			report[i].Share = expected[i].Share
+		}
+	}
+	if !reflect.DeepEqual(report, expected) {
+		t.Errorf("Expected %+v, but got: %+v", expected, report)
+	}
+
+	hash = New(1, intHash, WithDirection(Counterclockwise))
+	hash.AddWithTokens(&Node{ID: "a"}, []uint32{100, 200})
+	hash.AddWithTokens(&Node{ID: "c"}, []uint32{1<<32 - 1})
+	report = hash.VNodeReport()
+	if report[1].LargestArc != 101 || report[0].LargestArc != 1<<32-201 {
+		t.Errorf("Unexpected counterclockwise report: %+v", report)
+	}
+
+	if report := New(1, nil).VNodeReport(); len(report) != 0 {
+		t.Errorf("Expected empty report for empty hash, but got: %+v", report)
+	}
+}
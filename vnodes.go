@@ -0,0 +1,121 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Places an extra virtual node of an item at the provided position, e.g.
+// to split an oversized arc without changing the weight of the item.
+// Manually placed virtual nodes are kept when the weight changes.
+func (m *Map) AddVirtualNode(key string, position uint32) error {
+	c := Change{Op: ChangeAddVirtualNode, Key: key, Tokens: []uint32{position}}
+	return m.mutate([]Change{c}, func() error {
+		return m.addVirtualNode(key, position)
+	})
+}
+
+// Removes a virtual node of an item at the provided position. Both
+// manually placed and derived virtual nodes can be removed this way, and
+// removed derived virtual nodes stay removed when the weight changes.
+func (m *Map) RemoveVirtualNode(key string, position uint32) error {
+	c := Change{Op: ChangeRemoveVirtualNode, Key: key, Tokens: []uint32{position}}
+	return m.mutate([]Change{c}, func() error {
+		return m.removeVirtualNode(key, position)
+	})
+}
+
+// Returns the positions of all virtual nodes of an item in ring order,
+// including manually placed ones, or nil if there is no such item.
+func (m *Map) VirtualNodesOf(node string) []uint32 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	e, exists := m.entries[node]
+	if !exists {
+		return nil
+	}
+	out := make([]uint32, len(e.positions))
+	for i, pos := range e.positions {
+		out[i] = uint32(pos)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i] < out[j]
+	})
+	return out
+}
+
+func (m *Map) addVirtualNode(key string, position uint32) error {
+	e, exists := m.entries[key]
+	if !exists {
+		return fmt.Errorf("No node with name '%s' found", key)
+	}
+	pos := int(position)
+	if owner, taken := m.hashMap[pos]; taken {
+		return fmt.Errorf("Position %d is already taken by '%s'", position, m.names[owner])
+	}
+
+	updated := *e
+	updated.positions = append(append([]int{}, e.positions...), pos)
+	if i := indexOf(e.removed, pos); i >= 0 {
+		// The position is derived from the weight, so it simply returns.
+		updated.removed = append(append([]int{}, e.removed[:i]...), e.removed[i+1:]...)
+	} else {
+		updated.manual = append(append([]int{}, e.manual...), pos)
+	}
+	m.entries[key] = &updated
+
+	m.claim(e.id, pos)
+	m.keys = append(m.keys, pos)
+	sort.Ints(m.keys)
+	return nil
+}
+
+func (m *Map) removeVirtualNode(key string, position uint32) error {
+	e, exists := m.entries[key]
+	if !exists {
+		return fmt.Errorf("No node with name '%s' found", key)
+	}
+	pos := int(position)
+	idx := indexOf(e.positions, pos)
+	if idx < 0 {
+		return fmt.Errorf("Node '%s' has no virtual node at position %d", key, position)
+	}
+
+	updated := *e
+	updated.positions = append(append([]int{}, e.positions[:idx]...), e.positions[idx+1:]...)
+	if i := indexOf(e.manual, pos); i >= 0 {
+		updated.manual = append(append([]int{}, e.manual[:i]...), e.manual[i+1:]...)
+	} else if !e.explicit {
+		updated.removed = append(append([]int{}, e.removed...), pos)
+	}
+	m.entries[key] = &updated
+
+	m.unclaim(e.id, pos)
+	return nil
+}
+
+func indexOf(values []int, v int) int {
+	for i, value := range values {
+		if value == v {
+			return i
+		}
+	}
+	return -1
+}
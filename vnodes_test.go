@@ -0,0 +1,71 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestVirtualNodes(t *testing.T) {
+	hash := New(1, intHash)
+	hash.AddString("10", "100")
+
+	if err := hash.AddVirtualNode("10", 50); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if res := hash.Get("40"); res != "10" {
+		t.Errorf("Entry 40 should map to 10, but instead got: %s", res)
+	}
+	if err := hash.AddVirtualNode("100", 50); err == nil {
+		t.Errorf("Expected error, but it wasn't returned")
+	}
+
+	// Manually placed virtual nodes survive weight changes.
+	hash.Apply(WeightChange("10", 2))
+	if tokens, _ := hash.Tokens("10"); !reflect.DeepEqual(tokens, []uint32{10, 110, 50}) {
+		t.Errorf("Unexpected tokens after weight change: %v", tokens)
+	}
+
+	if err := hash.RemoveVirtualNode("10", 50); err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+	if res := hash.Get("40"); res != "100" {
+		t.Errorf("Entry 40 should map to 100, but instead got: %s", res)
+	}
+	if err := hash.RemoveVirtualNode("10", 50); err == nil {
+		t.Errorf("Expected error, but it wasn't returned")
+	}
+
+	hash.Del("10")
+	if len(hash.keys) != 1 || len(hash.hashMap) != 1 {
+		t.Errorf("Expected only 100 to remain on the ring, but got: %v", hash.keys)
+	}
+}
+
+func TestVirtualNodesOf(t *testing.T) {
+	hash := New(1, intHash)
+	hash.AddStringWithWeight("10", 2)
+	hash.AddVirtualNode("10", 50)
+
+	if positions := hash.VirtualNodesOf("10"); !reflect.DeepEqual(positions, []uint32{10, 50, 110}) {
+		t.Errorf("Unexpected virtual nodes: %v", positions)
+	}
+	if positions := hash.VirtualNodesOf("20"); positions != nil {
+		t.Errorf("Expected no virtual nodes for unknown item, but got: %v", positions)
+	}
+}
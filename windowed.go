@@ -0,0 +1,46 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Gets the closest item to the time bucket of seriesKey that t falls in.
+// Buckets are window long and aligned to the Unix epoch, so consecutive
+// windows of the same series are spread over the items deterministically.
+// Fails if the window is not positive.
+func (m *Map) GetWindowed(seriesKey string, t time.Time, window time.Duration) (string, error) {
+	if window <= 0 {
+		return "", fmt.Errorf("Invalid window %s, must be positive", window)
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.get(windowedKey(seriesKey, t, window)), nil
+}
+
+func windowedKey(seriesKey string, t time.Time, window time.Duration) string {
+	nanos := t.UnixNano()
+	bucket := nanos / int64(window)
+	if nanos < 0 && nanos%int64(window) != 0 {
+		bucket--
+	}
+	return seriesKey + "@" + strconv.FormatInt(bucket, 10)
+}
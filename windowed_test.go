@@ -0,0 +1,65 @@
+/*
+Copyright 2016 Dolf Schimmel, Freeaqingme
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package GoConsistentHash
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWindowedKey(t *testing.T) {
+	testCases := []struct {
+		t        time.Time
+		expected string
+	}{
+		{time.Unix(0, 0), "cpu@0"},
+		{time.Unix(3599, 0), "cpu@0"},
+		{time.Unix(3600, 0), "cpu@1"},
+		{time.Unix(-1, 0), "cpu@-1"},
+		{time.Unix(-3600, 0), "cpu@-1"},
+	}
+	for _, tc := range testCases {
+		if key := windowedKey("cpu", tc.t, time.Hour); key != tc.expected {
+			t.Errorf("Expected %s for %s, but got: %s", tc.expected, tc.t, key)
+		}
+	}
+}
+
+func TestGetWindowed(t *testing.T) {
+	hash := New(50, nil)
+	hash.AddString("A", "B", "C", "D")
+
+	start := time.Date(2016, 1, 1, 0, 0, 0, 0, time.UTC)
+	owners := make(map[string]bool)
+	for i := 0; i < 24; i++ {
+		at := start.Add(time.Duration(i) * time.Hour)
+		owner, _ := hash.GetWindowed("cpu", at, time.Hour)
+		if later, _ := hash.GetWindowed("cpu", at.Add(59*time.Minute), time.Hour); later != owner {
+			t.Errorf("Expected the same owner within a window, but got: %s and %s", owner, later)
+		}
+		owners[owner] = true
+	}
+	if len(owners) < 3 {
+		t.Errorf("Expected consecutive windows to spread over the items, but got: %v", owners)
+	}
+
+	for _, window := range []time.Duration{0, -time.Hour} {
+		if _, err := hash.GetWindowed("cpu", start, window); err == nil {
+			t.Errorf("Expected error for window %s", window)
+		}
+	}
+}